@@ -0,0 +1,86 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"mania/store"
+)
+
+// Cache provides menu cache management functions
+type Cache interface {
+	GetStopList() []store.StopEntry
+	SetAvailability(ctx context.Context, itemID int, available bool, until time.Time) error
+	Refresh(ctx context.Context) error
+	Stats() store.CacheStats
+	Catalog() store.Catalog
+	ValidationReport() store.ValidationReport
+	GetPins() []int
+	SetPins(ctx context.Context, ids []int) error
+}
+
+// Server provides admin HTTP endpoints
+type Server struct {
+	cache Cache
+	token string
+	mux   *http.ServeMux
+}
+
+// NewServer returns new *Server instance.
+// Requests must carry "Authorization: Bearer <token>" header,
+// empty token disables admin endpoints altogether.
+func NewServer(c Cache, token string) *Server {
+	s := Server{
+		cache: c,
+		token: token,
+		mux:   http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("/admin/stoplist", s.stopListHandler)
+	s.mux.HandleFunc("/admin/cache", s.cacheStatsHandler)
+	s.mux.HandleFunc("/admin/cache/refresh", s.cacheRefreshHandler)
+	s.mux.HandleFunc("/admin/cache/dump", s.cacheDumpHandler)
+	s.mux.HandleFunc("/admin/cache/validation", s.cacheValidationHandler)
+	s.mux.HandleFunc("/admin/pins", s.pinsHandler)
+
+	return &s
+}
+
+// ServeHTTP checks authorization and routes request to the handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+// authorized checks request bearer token
+func (s *Server) authorized(r *http.Request) bool {
+	if s.token == "" {
+		return false
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+// writeJSON writes v as JSON response
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Add("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("failed to write admin response: %v", err)
+	}
+}
+
+// writeJSONError writes error message as JSON response
+func writeJSONError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]string{"error": msg})
+}
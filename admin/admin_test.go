@@ -0,0 +1,226 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"mania/store"
+)
+
+// fakeCache keeps stop list in memory
+type fakeCache struct {
+	stopList   map[int]store.StopEntry
+	pins       []int
+	refreshes  int
+	refreshErr error
+}
+
+func (fc *fakeCache) Refresh(ctx context.Context) error {
+	fc.refreshes++
+	return fc.refreshErr
+}
+
+func (fc *fakeCache) Stats() store.CacheStats {
+	st := store.CacheStats{Categories: 1, Items: 2}
+	if fc.refreshErr != nil {
+		st.LastError = fc.refreshErr.Error()
+	}
+	return st
+}
+
+func (fc *fakeCache) Catalog() store.Catalog {
+	return store.Catalog{
+		Categories: []*store.Category{{ID: 1, Name: "Блины", Products: []int{1, 2}}},
+		Items:      []*store.Item{{ID: 1, Name: "Блинчики"}, {ID: 2, Name: "Блины с творогом"}},
+	}
+}
+
+func (fc *fakeCache) ValidationReport() store.ValidationReport {
+	return store.ValidationReport{
+		Errors: 1,
+		Issues: []store.Issue{{
+			Level:      store.LevelError,
+			Code:       store.IssueDanglingProduct,
+			Collection: "categories",
+			DocID:      "1",
+		}},
+	}
+}
+
+func (fc *fakeCache) GetPins() []int {
+	return fc.pins
+}
+
+func (fc *fakeCache) SetPins(ctx context.Context, ids []int) error {
+	fc.pins = ids
+	return nil
+}
+
+func (fc *fakeCache) GetStopList() []store.StopEntry {
+	res := []store.StopEntry{}
+	for _, e := range fc.stopList {
+		res = append(res, e)
+	}
+	return res
+}
+
+func (fc *fakeCache) SetAvailability(ctx context.Context, itemID int, available bool, until time.Time) error {
+	if available {
+		delete(fc.stopList, itemID)
+		return nil
+	}
+	fc.stopList[itemID] = store.StopEntry{ItemID: itemID, Until: until}
+	return nil
+}
+
+// do performs request against admin server
+func do(s *Server, method, path, token, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	return w
+}
+
+func TestAuthorization(t *testing.T) {
+	fc := &fakeCache{stopList: map[int]store.StopEntry{}}
+
+	s := NewServer(fc, "secret")
+	if w := do(s, http.MethodGet, "/admin/stoplist", "", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without token, got %d", w.Code)
+	}
+	if w := do(s, http.MethodGet, "/admin/stoplist", "wrong", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 with wrong token, got %d", w.Code)
+	}
+	if w := do(s, http.MethodGet, "/admin/stoplist", "secret", ""); w.Code != http.StatusOK {
+		t.Errorf("expected 200 with token, got %d", w.Code)
+	}
+
+	disabled := NewServer(fc, "")
+	if w := do(disabled, http.MethodGet, "/admin/stoplist", "", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 when admin is disabled, got %d", w.Code)
+	}
+}
+
+func TestStopList(t *testing.T) {
+	fc := &fakeCache{stopList: map[int]store.StopEntry{}}
+	s := NewServer(fc, "secret")
+
+	w := do(s, http.MethodPost, "/admin/stoplist", "secret",
+		`{"product_id": 102, "available": false, "until": "2030-01-01T11:00:00+03:00"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected code %d: %s", w.Code, w.Body.String())
+	}
+
+	entries := []store.StopEntry{}
+	if err := json.NewDecoder(w.Body).Decode(&entries); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(entries) != 1 || entries[0].ItemID != 102 || entries[0].Until.IsZero() {
+		t.Errorf("unexpected stop list: %v", entries)
+	}
+
+	w = do(s, http.MethodPost, "/admin/stoplist", "secret", `{"product_id": 102, "available": true}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected code %d: %s", w.Code, w.Body.String())
+	}
+	if len(fc.stopList) != 0 {
+		t.Errorf("expected empty stop list, got %v", fc.stopList)
+	}
+
+	if w := do(s, http.MethodPost, "/admin/stoplist", "secret", `{"available": true}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for missing product_id, got %d", w.Code)
+	}
+	if w := do(s, http.MethodDelete, "/admin/stoplist", "secret", ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for DELETE, got %d", w.Code)
+	}
+}
+
+func TestCache(t *testing.T) {
+	fc := &fakeCache{stopList: map[int]store.StopEntry{}}
+	s := NewServer(fc, "secret")
+
+	w := do(s, http.MethodGet, "/admin/cache", "secret", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected code %d: %s", w.Code, w.Body.String())
+	}
+	st := store.CacheStats{}
+	if err := json.NewDecoder(w.Body).Decode(&st); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if st.Categories != 1 || st.Items != 2 {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+
+	if w := do(s, http.MethodGet, "/admin/cache/refresh", "secret", ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET refresh, got %d", w.Code)
+	}
+	if w := do(s, http.MethodPost, "/admin/cache/refresh", "secret", ""); w.Code != http.StatusOK {
+		t.Errorf("unexpected refresh code %d", w.Code)
+	}
+	fc.refreshErr = errors.New("firestore is down")
+	w = do(s, http.MethodPost, "/admin/cache/refresh", "secret", "")
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "firestore is down") {
+		t.Errorf("expected refresh error to be reported, got %d: %s", w.Code, w.Body.String())
+	}
+	if fc.refreshes != 2 {
+		t.Errorf("expected 2 refreshes, got %d", fc.refreshes)
+	}
+
+	w = do(s, http.MethodGet, "/admin/cache/dump", "secret", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected code %d: %s", w.Code, w.Body.String())
+	}
+	cat := store.Catalog{}
+	if err := json.NewDecoder(w.Body).Decode(&cat); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(cat.Categories) != 1 || len(cat.Items) != 2 || cat.Items[0].Name != "Блинчики" {
+		t.Errorf("unexpected catalog dump: %+v", cat)
+	}
+
+	w = do(s, http.MethodGet, "/admin/cache/validation", "secret", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected code %d: %s", w.Code, w.Body.String())
+	}
+	report := store.ValidationReport{}
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if report.Errors != 1 || len(report.Issues) != 1 || report.Issues[0].Code != store.IssueDanglingProduct {
+		t.Errorf("unexpected validation report: %+v", report)
+	}
+}
+
+func TestPins(t *testing.T) {
+	fc := &fakeCache{stopList: map[int]store.StopEntry{}}
+	s := NewServer(fc, "secret")
+
+	w := do(s, http.MethodPut, "/admin/pins", "secret", `{"product_ids": [104, 101]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected code %d: %s", w.Code, w.Body.String())
+	}
+	if len(fc.pins) != 2 || fc.pins[0] != 104 {
+		t.Errorf("unexpected pins %v", fc.pins)
+	}
+
+	w = do(s, http.MethodGet, "/admin/pins", "secret", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"product_ids":[104,101]`) {
+		t.Errorf("unexpected response %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := do(s, http.MethodPut, "/admin/pins", "secret", `{"product_ids": [0]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for bad id, got %d", w.Code)
+	}
+	if w := do(s, http.MethodPost, "/admin/pins", "secret", `{}`); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for POST, got %d", w.Code)
+	}
+}
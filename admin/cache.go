@@ -0,0 +1,57 @@
+package admin
+
+import (
+	"log"
+	"net/http"
+)
+
+// cacheStatsHandler handles GET /admin/cache returning cache state:
+// snapshot age, items and categories counts and the last refresh error
+func (s *Server) cacheStatsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.cache.Stats())
+}
+
+// cacheRefreshHandler handles POST /admin/cache/refresh
+// reloading catalog from the source right away
+func (s *Server) cacheRefreshHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	if err := s.cache.Refresh(r.Context()); err != nil {
+		log.Printf("ERROR: forced cache refresh failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, s.cache.Stats())
+		return
+	}
+
+	log.Print("INFO: cache refreshed by admin request")
+	writeJSON(w, http.StatusOK, s.cache.Stats())
+}
+
+// cacheDumpHandler handles GET /admin/cache/dump returning
+// the whole catalog the bot serves
+func (s *Server) cacheDumpHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.cache.Catalog())
+}
+
+// cacheValidationHandler handles GET /admin/cache/validation returning
+// the latest catalog validation report
+func (s *Server) cacheValidationHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.cache.ValidationReport())
+}
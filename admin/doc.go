@@ -0,0 +1,3 @@
+// Package admin implements authenticated HTTP endpoints
+// for restaurant staff, served next to the webhook.
+package admin
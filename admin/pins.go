@@ -0,0 +1,48 @@
+package admin
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// pinsRequest is a pinned items request and response body
+type pinsRequest struct {
+	ItemIDs []int `json:"product_ids"`
+}
+
+// pinsHandler handles /admin/pins requests:
+//
+//	GET - returns pinned item IDs in listing order
+//	PUT - replaces pinned items, see pinsRequest
+func (s *Server) pinsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, pinsRequest{ItemIDs: s.cache.GetPins()})
+	case http.MethodPut:
+		req := pinsRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "bad request body")
+			return
+		}
+		defer r.Body.Close()
+
+		for _, id := range req.ItemIDs {
+			if id <= 0 {
+				writeJSONError(w, http.StatusBadRequest, "bad product_id")
+				return
+			}
+		}
+
+		if err := s.cache.SetPins(r.Context(), req.ItemIDs); err != nil {
+			log.Printf("ERROR: failed to set pinned items: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to update pinned items")
+			return
+		}
+
+		log.Printf("INFO: pinned items set to %v", req.ItemIDs)
+		writeJSON(w, http.StatusOK, pinsRequest{ItemIDs: s.cache.GetPins()})
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
@@ -0,0 +1,51 @@
+package admin
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+)
+
+// availabilityRequest is a stop list change request body
+type availabilityRequest struct {
+	ItemID    int  `json:"product_id"`
+	Available bool `json:"available"`
+	// Until is when unavailable item is back, optional
+	Until time.Time `json:"until"`
+}
+
+// stopListHandler handles /admin/stoplist requests:
+//
+//	GET  - returns active stop list entries
+//	POST - changes item availability, see availabilityRequest
+func (s *Server) stopListHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.cache.GetStopList())
+	case http.MethodPost:
+		req := availabilityRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "bad request body")
+			return
+		}
+		defer r.Body.Close()
+
+		if req.ItemID <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "bad product_id")
+			return
+		}
+
+		if err := s.cache.SetAvailability(r.Context(), req.ItemID, req.Available, req.Until); err != nil {
+			log.Printf("ERROR: failed to set product %d availability: %v", req.ItemID, err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to update stop list")
+			return
+		}
+
+		log.Printf("INFO: product %d availability set to %v until %v",
+			req.ItemID, req.Available, req.Until)
+		writeJSON(w, http.StatusOK, s.cache.GetStopList())
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"mania/store"
+	"mania/tenant"
+)
+
+// command is a maintenance command run as "mania <name> [flags]"
+type command func(ctx context.Context, args []string) error
+
+// commands are run instead of serving the bot when
+// the first argument names one
+var commands = map[string]command{
+	"export-menu":   exportMenu,
+	"import-menu":   importMenu,
+	"sync-entities": syncEntities,
+}
+
+// runCommand runs command by name
+func runCommand(ctx context.Context, name string, args []string) error {
+	cmd, ok := commands[name]
+	if !ok {
+		names := make([]string, 0, len(commands))
+		for n := range commands {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown command %q, expected one of: %s", name, strings.Join(names, ", "))
+	}
+	return cmd(ctx, args)
+}
+
+// findTenant returns settings of tenant id
+func findTenant(id string) (tenant.Settings, error) {
+	settings, err := tenantSettings()
+	if err != nil {
+		return tenant.Settings{}, err
+	}
+	for _, ts := range settings {
+		if ts.ID == id {
+			return ts, nil
+		}
+	}
+	return tenant.Settings{}, fmt.Errorf("%w: %s", tenant.ErrUnknownTenant, id)
+}
+
+// loadCatalog loads tenant catalog the way the bot does, so
+// validation, quarantine and text cleanup apply. Unlike the bot
+// cache it writes no snapshots and order log and watches nothing.
+func loadCatalog(ctx context.Context, ts tenant.Settings) (store.Catalog, error) {
+	src, err := (&sources{}).get(ctx, ts)
+	if err != nil {
+		return store.Catalog{}, fmt.Errorf("failed to open catalog: %w", err)
+	}
+
+	c, r, err := store.LoadValidCatalog(ctx, src)
+	if err != nil {
+		return store.Catalog{}, fmt.Errorf("failed to load catalog: %w", err)
+	}
+	if r.Errors > 0 {
+		fmt.Fprintf(os.Stderr, "WARNING: %d catalog errors, invalid entries are skipped\n", r.Errors)
+	}
+	return c, nil
+}
+
+// confirm asks yes/no question, anything but "y" or "yes" is no
+func confirm(r io.Reader, w io.Writer, question string) bool {
+	fmt.Fprintf(w, "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(r).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes", "д", "да":
+		return true
+	default:
+		return false
+	}
+}
@@ -27,8 +27,32 @@ type OriginalRequest struct {
 
 // OriginalRequestPayload struct
 type OriginalRequestPayload struct {
-	Device OriginalRequestDevice `json:"device"`
-	User   OriginalRequestUser   `json:"user"`
+	Device  OriginalRequestDevice  `json:"device"`
+	User    OriginalRequestUser    `json:"user"`
+	Surface OriginalRequestSurface `json:"surface"`
+	Inputs  []OriginalRequestInput `json:"inputs"`
+}
+
+// OriginalRequestInput struct
+type OriginalRequestInput struct {
+	Intent    string     `json:"intent"`
+	Arguments []Argument `json:"arguments"`
+}
+
+// Argument struct
+type Argument struct {
+	Name      string `json:"name"`
+	TextValue string `json:"textValue"`
+}
+
+// OriginalRequestSurface struct
+type OriginalRequestSurface struct {
+	Capabilities []Capability `json:"capabilities"`
+}
+
+// Capability struct
+type Capability struct {
+	Name string `json:"name"`
 }
 
 // OriginalRequestDevice struct
@@ -71,17 +95,41 @@ type ResponseGoogle struct {
 
 // RichResponse struct
 type RichResponse struct {
-	Items []Item `json:"items"`
+	Items       []Item       `json:"items"`
+	Suggestions []Suggestion `json:"suggestions,omitempty"`
 }
 
-// Item struct
+// Item struct, one of the fields is set
 type Item struct {
-	SimpleResponse SimpleResponse `json:"simpleResponse"`
+	SimpleResponse *SimpleResponse `json:"simpleResponse,omitempty"`
+	BasicCard      *BasicCard      `json:"basicCard,omitempty"`
 }
 
 // SimpleResponse struct
 type SimpleResponse struct {
 	TextToSpeech string `json:"textToSpeech"`
+	DisplayText  string `json:"displayText,omitempty"`
+}
+
+// BasicCard struct
+type BasicCard struct {
+	Title         string `json:"title,omitempty"`
+	Subtitle      string `json:"subtitle,omitempty"`
+	FormattedText string `json:"formattedText,omitempty"`
+	Image         *Image `json:"image,omitempty"`
+	// ImageDisplayOptions is DEFAULT, WHITE or CROPPED
+	ImageDisplayOptions string `json:"imageDisplayOptions,omitempty"`
+}
+
+// Image struct
+type Image struct {
+	URL               string `json:"url"`
+	AccessibilityText string `json:"accessibilityText"`
+}
+
+// Suggestion is a suggestion chip
+type Suggestion struct {
+	Title string `json:"title"`
 }
 
 // ResponseSystemIntent struct
@@ -92,7 +140,34 @@ type ResponseSystemIntent struct {
 
 // ResponseSystemIntentData struct
 type ResponseSystemIntentData struct {
-	Type        string   `json:"@type"`
-	OptContext  string   `json:"optContext"`
-	Permissions []string `json:"permissions"`
+	Type           string          `json:"@type"`
+	OptContext     string          `json:"optContext,omitempty"`
+	Permissions    []string        `json:"permissions,omitempty"`
+	ListSelect     *ListSelect     `json:"listSelect,omitempty"`
+	CarouselSelect *CarouselSelect `json:"carouselSelect,omitempty"`
+}
+
+// ListSelect struct
+type ListSelect struct {
+	Title string       `json:"title,omitempty"`
+	Items []OptionItem `json:"items"`
+}
+
+// CarouselSelect struct
+type CarouselSelect struct {
+	Items []OptionItem `json:"items"`
+}
+
+// OptionItem is a list or carousel item
+type OptionItem struct {
+	OptionInfo  OptionInfo `json:"optionInfo"`
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	Image       *Image     `json:"image,omitempty"`
+}
+
+// OptionInfo struct, Key is sent back when the item is selected
+type OptionInfo struct {
+	Key      string   `json:"key"`
+	Synonyms []string `json:"synonyms,omitempty"`
 }
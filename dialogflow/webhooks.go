@@ -1,5 +1,27 @@
 package dialogflow
 
+import "unicode/utf8"
+
+const (
+	// ScreenOutput is a capability of devices having a screen
+	ScreenOutput = "actions.capability.SCREEN_OUTPUT"
+	// OptionIntent is a system intent asking to pick a list
+	// or carousel item
+	OptionIntent = "actions.intent.OPTION"
+	// OptionArgument is the argument holding picked option key
+	OptionArgument = "OPTION"
+	// OptionValueSpec is a list or carousel system intent data type
+	OptionValueSpec = "type.googleapis.com/google.actions.v2.OptionValueSpec"
+
+	// list needs at least 2 items, carousel takes at most 10
+	minSelectItems   = 2
+	maxListItems     = 30
+	maxCarouselItems = 10
+	maxSuggestions   = 8
+	// longer chip titles make the whole response invalid
+	maxSuggestionLen = 25
+)
+
 // GenerateResponse .
 func GenerateResponse(expectUserResponse bool, msg string) Response {
 	return Response{
@@ -9,7 +31,7 @@ func GenerateResponse(expectUserResponse bool, msg string) Response {
 				RichResponse: RichResponse{
 					Items: []Item{
 						{
-							SimpleResponse: SimpleResponse{
+							SimpleResponse: &SimpleResponse{
 								TextToSpeech: msg,
 							},
 						},
@@ -19,3 +41,104 @@ func GenerateResponse(expectUserResponse bool, msg string) Response {
 		},
 	}
 }
+
+// HasScreen reports whether request came from a device with a screen
+func (r Request) HasScreen() bool {
+	for _, c := range r.OriginalRequest.Payload.Surface.Capabilities {
+		if c.Name == ScreenOutput {
+			return true
+		}
+	}
+	return false
+}
+
+// OptionKey returns key of the list or carousel item customer picked
+func (r Request) OptionKey() (string, bool) {
+	for _, in := range r.OriginalRequest.Payload.Inputs {
+		for _, arg := range in.Arguments {
+			if arg.Name == OptionArgument && arg.TextValue != "" {
+				return arg.TextValue, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Text returns the first simple response text
+func (r Response) Text() string {
+	for _, item := range r.Payload.Google.RichResponse.Items {
+		if item.SimpleResponse != nil {
+			return item.SimpleResponse.TextToSpeech
+		}
+	}
+	return ""
+}
+
+// WithCard returns response with basic card shown after the speech
+func (r Response) WithCard(card BasicCard) Response {
+	rich := &r.Payload.Google.RichResponse
+	items := make([]Item, len(rich.Items), len(rich.Items)+1)
+	copy(items, rich.Items)
+	rich.Items = append(items, Item{BasicCard: &card})
+	return r
+}
+
+// WithSuggestions returns response with suggestion chips added.
+// Titles longer than chips take are skipped, a chip tapped sends
+// its title, so it can't be cut.
+func (r Response) WithSuggestions(titles ...string) Response {
+	rich := &r.Payload.Google.RichResponse
+	chips := make([]Suggestion, len(rich.Suggestions), len(rich.Suggestions)+len(titles))
+	copy(chips, rich.Suggestions)
+	for _, t := range titles {
+		if len(chips) == maxSuggestions {
+			break
+		}
+		if utf8.RuneCountInString(t) > maxSuggestionLen {
+			continue
+		}
+		chips = append(chips, Suggestion{Title: t})
+	}
+	rich.Suggestions = chips
+	return r
+}
+
+// WithList returns response showing items as a list to pick from.
+// Lists need at least 2 items, response is returned as is otherwise.
+func (r Response) WithList(title string, items []OptionItem) Response {
+	if len(items) < minSelectItems {
+		return r
+	}
+	if len(items) > maxListItems {
+		items = items[:maxListItems]
+	}
+
+	r.Payload.Google.SystemIntent = &ResponseSystemIntent{
+		Intent: OptionIntent,
+		Data: ResponseSystemIntentData{
+			Type:       OptionValueSpec,
+			ListSelect: &ListSelect{Title: title, Items: items},
+		},
+	}
+	return r
+}
+
+// WithCarousel returns response showing items as a carousel of cards.
+// Carousels need at least 2 items, response is returned as is otherwise.
+func (r Response) WithCarousel(items []OptionItem) Response {
+	if len(items) < minSelectItems {
+		return r
+	}
+	if len(items) > maxCarouselItems {
+		items = items[:maxCarouselItems]
+	}
+
+	r.Payload.Google.SystemIntent = &ResponseSystemIntent{
+		Intent: OptionIntent,
+		Data: ResponseSystemIntentData{
+			Type:           OptionValueSpec,
+			CarouselSelect: &CarouselSelect{Items: items},
+		},
+	}
+	return r
+}
@@ -0,0 +1,92 @@
+package dialogflow
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestHasScreen(t *testing.T) {
+	req := Request{}
+	body := `{"originalDetectIntentRequest": {"payload": {"surface": {"capabilities": [
+		{"name": "actions.capability.AUDIO_OUTPUT"},
+		{"name": "actions.capability.SCREEN_OUTPUT"}
+	]}}}}`
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatalf("failed to decode request: %v", err)
+	}
+	if !req.HasScreen() {
+		t.Error("expected screen capability")
+	}
+	if (Request{}).HasScreen() {
+		t.Error("expected no screen for speaker request")
+	}
+}
+
+func TestOptionKey(t *testing.T) {
+	req := Request{}
+	body := `{"originalDetectIntentRequest": {"payload": {"inputs": [{
+		"intent": "actions.intent.OPTION",
+		"arguments": [{"name": "OPTION", "textValue": "item:Блинчики"}]
+	}]}}}`
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatalf("failed to decode request: %v", err)
+	}
+	if key, ok := req.OptionKey(); !ok || key != "item:Блинчики" {
+		t.Errorf("unexpected option key %q", key)
+	}
+	if _, ok := (Request{}).OptionKey(); ok {
+		t.Error("expected no option key in request without selection")
+	}
+}
+
+func TestSuggestionsLength(t *testing.T) {
+	resp := GenerateResponse(true, "Вот что есть").
+		WithSuggestions("Блины с курицей и грибами", "Блины с курицей, грибами и сыром", "Оформить")
+
+	chips := resp.Payload.Google.RichResponse.Suggestions
+	if len(chips) != 2 || chips[0].Title != "Блины с курицей и грибами" || chips[1].Title != "Оформить" {
+		t.Errorf("expected chips over 25 characters to be skipped, got %v", chips)
+	}
+}
+
+func TestRichResponse(t *testing.T) {
+	options := []OptionItem{
+		{OptionInfo: OptionInfo{Key: "Блинчики"}, Title: "Блинчики"},
+		{OptionInfo: OptionInfo{Key: "Борщ"}, Title: "Борщ"},
+	}
+	resp := GenerateResponse(true, "Вот что есть").
+		WithCard(BasicCard{Title: "Блинчики", Image: &Image{URL: "https://example.com/1.jpg"}}).
+		WithCarousel(options).
+		WithSuggestions("Дальше", "Оформить")
+
+	b, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatalf("failed to encode response: %v", err)
+	}
+	for _, expected := range []string{
+		`"simpleResponse":{"textToSpeech":"Вот что есть"}`,
+		`"basicCard":{"title":"Блинчики","image":{"url":"https://example.com/1.jpg","accessibilityText":""}}`,
+		`"suggestions":[{"title":"Дальше"},{"title":"Оформить"}]`,
+		`"intent":"actions.intent.OPTION"`,
+		`"carouselSelect":{"items":[{"optionInfo":{"key":"Блинчики"},"title":"Блинчики"}`,
+	} {
+		if !strings.Contains(string(b), expected) {
+			t.Errorf("expected %s in response %s", expected, b)
+		}
+	}
+	if resp.Text() != "Вот что есть" {
+		t.Errorf("unexpected text %q", resp.Text())
+	}
+
+	// single item can not be a list
+	if single := GenerateResponse(true, "Борщ").WithList("Супы", options[:1]); single.Payload.Google.SystemIntent != nil {
+		t.Error("expected no list for a single item")
+	}
+
+	// plain response keeps its previous shape
+	b, _ = json.Marshal(GenerateResponse(false, "Корзина пуста"))
+	if strings.Contains(string(b), "suggestions") || strings.Contains(string(b), "systemIntent") {
+		t.Errorf("unexpected rich fields in plain response %s", b)
+	}
+}
@@ -0,0 +1,88 @@
+package entities
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// agentEntityType is entity type file of Dialogflow agent export,
+// entities are kept in a separate file per language
+type agentEntityType struct {
+	Name                 string `json:"name"`
+	IsOverridable        bool   `json:"isOverridable"`
+	IsEnum               bool   `json:"isEnum"`
+	IsRegexp             bool   `json:"isRegexp"`
+	AutomatedExpansion   bool   `json:"automatedExpansion"`
+	AllowFuzzyExtraction bool   `json:"allowFuzzyExtraction"`
+}
+
+// agentPaths returns entity type and entries file paths in agent
+// export directory: entities/item.json, entities/item_entries_ru.json
+func agentPaths(dir, lang, name string) (string, string) {
+	base := filepath.Join(dir, "entities", name)
+	return base + ".json", base + "_entries_" + lang + ".json"
+}
+
+// WriteAgent writes entity types to agent export directory dir,
+// the files can be zipped with the rest of the agent and restored
+// or imported in Dialogflow console
+func WriteAgent(dir, lang string, types []EntityType) error {
+	if err := os.MkdirAll(filepath.Join(dir, "entities"), 0755); err != nil {
+		return fmt.Errorf("failed to create agent directory: %w", err)
+	}
+
+	for _, et := range types {
+		typePath, entriesPath := agentPaths(dir, lang, et.DisplayName)
+		if err := writeJSON(typePath, agentEntityType{Name: et.DisplayName, IsOverridable: true}); err != nil {
+			return err
+		}
+		entities := et.Entities
+		if entities == nil {
+			entities = []Entity{}
+		}
+		if err := writeJSON(entriesPath, entities); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadAgent reads entity types named names from agent export
+// directory dir, missing ones are skipped
+func ReadAgent(dir, lang string, names ...string) ([]EntityType, error) {
+	res := []EntityType{}
+	for _, name := range names {
+		typePath, entriesPath := agentPaths(dir, lang, name)
+		if _, err := os.Stat(typePath); os.IsNotExist(err) {
+			continue
+		}
+
+		et := EntityType{DisplayName: name, Kind: KindMap}
+		b, err := ioutil.ReadFile(entriesPath)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read %s entries: %w", name, err)
+		}
+		if err == nil {
+			if err := json.Unmarshal(b, &et.Entities); err != nil {
+				return nil, fmt.Errorf("failed to decode %s entries: %w", name, err)
+			}
+		}
+		res = append(res, et)
+	}
+	return res, nil
+}
+
+// writeJSON writes v to path as indented JSON
+func writeJSON(path string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", filepath.Base(path), err)
+	}
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
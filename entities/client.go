@@ -0,0 +1,242 @@
+package entities
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/oauth2/google"
+)
+
+// Client reads and updates agent entity types
+type Client interface {
+	// ListEntityTypes returns all agent entity types
+	ListEntityTypes(ctx context.Context) ([]EntityType, error)
+	// CreateEntityType creates entity type returning it with Name set
+	CreateEntityType(ctx context.Context, et EntityType) (EntityType, error)
+	// UpdateEntityType replaces entities of et named by et.Name
+	UpdateEntityType(ctx context.Context, et EntityType) error
+}
+
+// Sync updates agent entity types to types creating missing ones
+// and returns changes made. Nothing is written on dry run.
+func Sync(ctx context.Context, cl Client, types []EntityType, dryRun bool) ([]Change, error) {
+	current, err := cl.ListEntityTypes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list entity types: %w", err)
+	}
+	byName := map[string]EntityType{}
+	for _, et := range current {
+		byName[et.DisplayName] = et
+	}
+
+	res := []Change{}
+	for _, et := range types {
+		old, exists := byName[et.DisplayName]
+		changes := Diff(old, et)
+		res = append(res, changes...)
+		if dryRun || (exists && len(changes) == 0) {
+			continue
+		}
+
+		if !exists {
+			if _, err := cl.CreateEntityType(ctx, et); err != nil {
+				return res, fmt.Errorf("failed to create %s entity type: %w", et.DisplayName, err)
+			}
+			continue
+		}
+		et.Name = old.Name
+		if err := cl.UpdateEntityType(ctx, et); err != nil {
+			return res, fmt.Errorf("failed to update %s entity type: %w", et.DisplayName, err)
+		}
+	}
+	return res, nil
+}
+
+// DirClient keeps entity types in agent export directory
+type DirClient struct {
+	dir  string
+	lang string
+}
+
+// NewDirClient returns client of agent export directory dir
+func NewDirClient(dir, lang string) *DirClient {
+	return &DirClient{dir: dir, lang: lang}
+}
+
+// ListEntityTypes returns category and item entity types found
+// in the directory
+func (d *DirClient) ListEntityTypes(ctx context.Context) ([]EntityType, error) {
+	return ReadAgent(d.dir, d.lang, CategoryType, ItemType)
+}
+
+// CreateEntityType writes entity type files
+func (d *DirClient) CreateEntityType(ctx context.Context, et EntityType) (EntityType, error) {
+	return et, WriteAgent(d.dir, d.lang, []EntityType{et})
+}
+
+// UpdateEntityType rewrites entity type files
+func (d *DirClient) UpdateEntityType(ctx context.Context, et EntityType) error {
+	return WriteAgent(d.dir, d.lang, []EntityType{et})
+}
+
+const (
+	// DefaultEndpoint is Dialogflow API v2 endpoint
+	DefaultEndpoint = "https://dialogflow.googleapis.com/v2"
+	// apiScope is OAuth scope of Dialogflow API
+	apiScope = "https://www.googleapis.com/auth/dialogflow"
+)
+
+// APIClient manages entity types of agent project through
+// Dialogflow REST API
+type APIClient struct {
+	endpoint string
+	project  string
+	lang     string
+	hc       *http.Client
+}
+
+// APIOption configures APIClient
+type APIOption func(*APIClient)
+
+// WithEndpoint sets API endpoint, tests point it to a fake server
+func WithEndpoint(endpoint string) APIOption {
+	return func(c *APIClient) {
+		c.endpoint = strings.TrimSuffix(endpoint, "/")
+	}
+}
+
+// WithHTTPClient sets HTTP client making authorized requests,
+// application default credentials are used otherwise
+func WithHTTPClient(hc *http.Client) APIOption {
+	return func(c *APIClient) {
+		c.hc = hc
+	}
+}
+
+// NewAPIClient returns client of agent of project, entities are
+// read and written in language lang
+func NewAPIClient(ctx context.Context, project, lang string, opts ...APIOption) (*APIClient, error) {
+	if project == "" {
+		return nil, errors.New("no agent project")
+	}
+
+	c := &APIClient{endpoint: DefaultEndpoint, project: project, lang: lang}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.hc == nil {
+		hc, err := google.DefaultClient(ctx, apiScope)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create dialogflow client: %w", err)
+		}
+		c.hc = hc
+	}
+	return c, nil
+}
+
+// ListEntityTypes returns all agent entity types
+func (c *APIClient) ListEntityTypes(ctx context.Context) ([]EntityType, error) {
+	res := []EntityType{}
+	pageToken := ""
+	for {
+		q := url.Values{"languageCode": {c.lang}}
+		if pageToken != "" {
+			q.Set("pageToken", pageToken)
+		}
+
+		page := struct {
+			EntityTypes   []EntityType `json:"entityTypes"`
+			NextPageToken string       `json:"nextPageToken"`
+		}{}
+		if err := c.do(ctx, http.MethodGet, c.agentPath()+"/entityTypes", q, nil, &page); err != nil {
+			return nil, err
+		}
+		res = append(res, page.EntityTypes...)
+
+		if page.NextPageToken == "" {
+			return res, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+// CreateEntityType creates entity type in the agent
+func (c *APIClient) CreateEntityType(ctx context.Context, et EntityType) (EntityType, error) {
+	q := url.Values{"languageCode": {c.lang}}
+	created := EntityType{}
+	if err := c.do(ctx, http.MethodPost, c.agentPath()+"/entityTypes", q, et, &created); err != nil {
+		return EntityType{}, err
+	}
+	return created, nil
+}
+
+// UpdateEntityType replaces entities of entity type
+func (c *APIClient) UpdateEntityType(ctx context.Context, et EntityType) error {
+	if et.Name == "" {
+		return errors.New("entity type has no name")
+	}
+	q := url.Values{"languageCode": {c.lang}, "updateMask": {"entities"}}
+	return c.do(ctx, http.MethodPatch, et.Name, q, et, nil)
+}
+
+// agentPath is agent resource name
+func (c *APIClient) agentPath() string {
+	return "projects/" + c.project + "/agent"
+}
+
+// do makes API request to resource decoding response to out if set
+func (c *APIClient) do(ctx context.Context, method, resource string, q url.Values, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, c.endpoint+"/"+resource+"?"+q.Encode(), body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req = req.WithContext(ctx)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call dialogflow: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		apiErr := struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}{}
+		b, _ := ioutil.ReadAll(resp.Body)
+		if json.Unmarshal(b, &apiErr) == nil && apiErr.Error.Message != "" {
+			return fmt.Errorf("dialogflow %s %s: %s: %s", method, resource, resp.Status, apiErr.Error.Message)
+		}
+		return fmt.Errorf("dialogflow %s %s: %s", method, resource, resp.Status)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode dialogflow response: %w", err)
+	}
+	return nil
+}
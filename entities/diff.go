@@ -0,0 +1,82 @@
+package entities
+
+import (
+	"fmt"
+	"strings"
+
+	"mania/store"
+)
+
+// Change is added, removed or changed entity of an entity type
+type Change struct {
+	Op         store.DiffOp `json:"op"`
+	EntityType string       `json:"entity_type"`
+	Value      string       `json:"value"`
+	// Added and Removed are changed synonyms
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+func (c Change) String() string {
+	s := fmt.Sprintf("%s %s %s", c.Op, c.EntityType, c.Value)
+	changes := []string{}
+	if len(c.Added) > 0 {
+		changes = append(changes, "+"+strings.Join(c.Added, ", +"))
+	}
+	if len(c.Removed) > 0 {
+		changes = append(changes, "-"+strings.Join(c.Removed, ", -"))
+	}
+	if len(changes) > 0 {
+		s += ": " + strings.Join(changes, ", ")
+	}
+	return s
+}
+
+// Diff returns changes turning old entity type into new one,
+// entities are matched by value and ordered the way new has them
+// followed by removed ones
+func Diff(old, new EntityType) []Change {
+	res := []Change{}
+
+	oldEntities := map[string]Entity{}
+	for _, e := range old.Entities {
+		oldEntities[e.Value] = e
+	}
+	seen := map[string]bool{}
+	for _, e := range new.Entities {
+		seen[e.Value] = true
+		o, ok := oldEntities[e.Value]
+		if !ok {
+			res = append(res, Change{Op: store.DiffAdded, EntityType: new.DisplayName, Value: e.Value})
+			continue
+		}
+		added, removed := missing(o.Synonyms, e.Synonyms), missing(e.Synonyms, o.Synonyms)
+		if len(added) > 0 || len(removed) > 0 {
+			res = append(res, Change{
+				Op:         store.DiffChanged,
+				EntityType: new.DisplayName,
+				Value:      e.Value,
+				Added:      added,
+				Removed:    removed,
+			})
+		}
+	}
+	for _, e := range old.Entities {
+		if !seen[e.Value] {
+			res = append(res, Change{Op: store.DiffRemoved, EntityType: new.DisplayName, Value: e.Value})
+		}
+	}
+
+	return res
+}
+
+// missing returns synonyms of ss not in from
+func missing(from, ss []string) []string {
+	var res []string
+	for _, s := range ss {
+		if !containsFold(from, s) {
+			res = append(res, s)
+		}
+	}
+	return res
+}
@@ -0,0 +1,4 @@
+// Package entities builds Dialogflow "category" and "item" entity
+// types from the catalog, writes them in agent export format and
+// syncs them with the agent through Dialogflow API v2.
+package entities
@@ -0,0 +1,154 @@
+package entities
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"mania/store"
+)
+
+// Entity type names intents take parameters of
+const (
+	CategoryType = "category"
+	ItemType     = "item"
+)
+
+// KindMap is a kind of entity types mapping synonyms to values
+const KindMap = "KIND_MAP"
+
+// EntityType is Dialogflow entity type
+type EntityType struct {
+	// Name is API resource name, empty until the type is created:
+	// projects/{project}/agent/entityTypes/{id}
+	Name        string   `json:"name,omitempty"`
+	DisplayName string   `json:"displayName"`
+	Kind        string   `json:"kind"`
+	Entities    []Entity `json:"entities"`
+}
+
+// Entity is entity type value recognized by any of its synonyms,
+// intents get the value
+type Entity struct {
+	Value    string   `json:"value"`
+	Synonyms []string `json:"synonyms"`
+}
+
+// Build returns category and item entity types of catalog,
+// values are category and item names intents look documents up by.
+// Synonyms resolve the way the cache indexes them: names win over
+// synonyms and a synonym goes to the first document.
+func Build(c store.Catalog) []EntityType {
+	categories := newBuilder()
+	for _, cat := range c.Categories {
+		categories.claim(cat.Name)
+	}
+	for _, cat := range c.Categories {
+		categories.add(cat.Name, cat.Synonyms...)
+	}
+	items := newBuilder()
+	for _, item := range c.Items {
+		items.claim(item.Name)
+	}
+	for _, item := range c.Items {
+		items.add(item.Name, item.Synonyms...)
+	}
+
+	return []EntityType{
+		{DisplayName: CategoryType, Kind: KindMap, Entities: categories.entities()},
+		{DisplayName: ItemType, Kind: KindMap, Entities: items.entities()},
+	}
+}
+
+// builder collects entities merging ones with the same value,
+// like subcategories named alike under different parents
+type builder struct {
+	values map[string]*Entity
+	// owners maps lowercased synonyms to values they resolve to
+	owners map[string]string
+}
+
+func newBuilder() *builder {
+	return &builder{
+		values: make(map[string]*Entity),
+		owners: make(map[string]string),
+	}
+}
+
+// claim reserves value for its own entity before synonyms are added
+func (b *builder) claim(value string) {
+	value = strings.TrimSpace(value)
+	if value != "" {
+		b.owners[strings.ToLower(value)] = value
+	}
+}
+
+// add adds entity of value with synonyms derived from it and given
+// ones, synonyms resolving to another value are skipped
+func (b *builder) add(value string, synonyms ...string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+
+	e, ok := b.values[value]
+	if !ok {
+		e = &Entity{Value: value}
+		b.values[value] = e
+	}
+	for _, s := range append([]string{value}, synonyms...) {
+		for _, v := range variants(s) {
+			if v == "" {
+				continue
+			}
+			key := strings.ToLower(v)
+			if owner, ok := b.owners[key]; ok && owner != value {
+				continue
+			}
+			b.owners[key] = value
+			if !containsFold(e.Synonyms, v) {
+				e.Synonyms = append(e.Synonyms, v)
+			}
+		}
+	}
+}
+
+// entities returns entities ordered by value
+func (b *builder) entities() []Entity {
+	res := make([]Entity, 0, len(b.values))
+	for _, e := range b.values {
+		res = append(res, *e)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Value < res[j].Value })
+	return res
+}
+
+// parenthesized matches remarks like " (0,5 л)" customers do not say
+var parenthesized = regexp.MustCompile(`\s*\([^)]*\)`)
+
+// variants returns s and its spoken variants,
+// Dialogflow matches synonyms case-insensitively
+func variants(s string) []string {
+	res := []string{s}
+	if short := strings.TrimSpace(parenthesized.ReplaceAllString(s, "")); short != "" {
+		res = append(res, short)
+	}
+	for _, v := range res {
+		res = append(res, strings.NewReplacer("ё", "е", "Ё", "Е").Replace(v))
+	}
+
+	for i, v := range res {
+		res[i] = strings.Join(strings.Fields(v), " ")
+	}
+	return res
+}
+
+// containsFold reports whether ss contains s ignoring case
+func containsFold(ss []string, s string) bool {
+	for _, v := range ss {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,329 @@
+package entities
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"mania/store"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func testCatalog() store.Catalog {
+	return store.Catalog{
+		Categories: []*store.Category{
+			{ID: 1, Name: "Блины", Synonyms: []string{"блинчики"}},
+			{ID: 2, ParentID: 1, Name: "С начинкой"},
+			{ID: 3, Name: "Пироги"},
+			{ID: 4, ParentID: 3, Name: "С начинкой"},
+		},
+		Items: []*store.Item{
+			{ID: 101, Name: "Блины с сёмгой", Synonyms: []string{"Блинчики", "блины с лососем"}},
+			{ID: 102, Name: "Морс (0,5 л)"},
+			{ID: 103, Name: " Блинчики "},
+		},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	expected := []EntityType{
+		{
+			DisplayName: CategoryType,
+			Kind:        KindMap,
+			Entities: []Entity{
+				{Value: "Блины", Synonyms: []string{"Блины", "блинчики"}},
+				{Value: "Пироги", Synonyms: []string{"Пироги"}},
+				{Value: "С начинкой", Synonyms: []string{"С начинкой"}},
+			},
+		},
+		{
+			DisplayName: ItemType,
+			Kind:        KindMap,
+			Entities: []Entity{
+				{Value: "Блинчики", Synonyms: []string{"Блинчики"}},
+				{Value: "Блины с сёмгой", Synonyms: []string{"Блины с сёмгой", "Блины с семгой", "блины с лососем"}},
+				{Value: "Морс (0,5 л)", Synonyms: []string{"Морс (0,5 л)", "Морс"}},
+			},
+		},
+	}
+	if diff := cmp.Diff(expected, Build(testCatalog())); diff != "" {
+		t.Errorf("entity types differ:\n%s", diff)
+	}
+}
+
+func TestAgent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	types := Build(testCatalog())
+	if err := WriteAgent(dir, "ru", types); err != nil {
+		t.Fatalf("unexpected error in WriteAgent: %v", err)
+	}
+	for _, name := range []string{"category.json", "category_entries_ru.json", "item.json", "item_entries_ru.json"} {
+		if _, err := os.Stat(filepath.Join(dir, "entities", name)); err != nil {
+			t.Errorf("expected %s to be written: %v", name, err)
+		}
+	}
+
+	got, err := ReadAgent(dir, "ru", CategoryType, ItemType, "size")
+	if err != nil {
+		t.Fatalf("unexpected error in ReadAgent: %v", err)
+	}
+	if diff := cmp.Diff(types, got); diff != "" {
+		t.Errorf("entity types differ after reading agent:\n%s", diff)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := EntityType{DisplayName: ItemType, Entities: []Entity{
+		{Value: "Блинчики", Synonyms: []string{"Блинчики", "Блинчик"}},
+		{Value: "Морс", Synonyms: []string{"Морс"}},
+		{Value: "Квас", Synonyms: []string{"Квас"}},
+	}}
+	new := EntityType{DisplayName: ItemType, Entities: []Entity{
+		{Value: "Блинчики", Synonyms: []string{"блинчики", "Тонкие блинчики"}},
+		{Value: "Морс", Synonyms: []string{"Морс"}},
+		{Value: "Чай", Synonyms: []string{"Чай"}},
+	}}
+
+	lines := []string{}
+	for _, c := range Diff(old, new) {
+		lines = append(lines, c.String())
+	}
+	expected := []string{
+		"~ item Блинчики: +Тонкие блинчики, -Блинчик",
+		"+ item Чай",
+		"- item Квас",
+	}
+	if diff := cmp.Diff(expected, lines); diff != "" {
+		t.Errorf("diff differs:\n%s", diff)
+	}
+}
+
+// fakeAgent is Dialogflow entity types API of project "test"
+// serving one entity type per page
+type fakeAgent struct {
+	mux     sync.Mutex
+	types   map[string]EntityType
+	nextID  int
+	methods []string
+}
+
+func newFakeAgent(types ...EntityType) *fakeAgent {
+	a := &fakeAgent{types: make(map[string]EntityType)}
+	for _, et := range types {
+		a.nextID++
+		et.Name = "projects/test/agent/entityTypes/" + strconv.Itoa(a.nextID)
+		a.types[et.Name] = et
+	}
+	return a
+}
+
+func (a *fakeAgent) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+
+	if r.URL.Query().Get("languageCode") != "ru" {
+		http.Error(w, `{"error": {"message": "bad language"}}`, http.StatusBadRequest)
+		return
+	}
+	a.methods = append(a.methods, r.Method)
+
+	const collection = "/v2/projects/test/agent/entityTypes"
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == collection:
+		names := []string{}
+		for name := range a.types {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		page, _ := strconv.Atoi(r.URL.Query().Get("pageToken"))
+		res := map[string]interface{}{"entityTypes": []EntityType{}}
+		if page < len(names) {
+			res["entityTypes"] = []EntityType{a.types[names[page]]}
+		}
+		if page+1 < len(names) {
+			res["nextPageToken"] = strconv.Itoa(page + 1)
+		}
+		_ = json.NewEncoder(w).Encode(res)
+
+	case r.Method == http.MethodPost && r.URL.Path == collection:
+		et := EntityType{}
+		if err := json.NewDecoder(r.Body).Decode(&et); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		a.nextID++
+		et.Name = "projects/test/agent/entityTypes/" + strconv.Itoa(a.nextID)
+		a.types[et.Name] = et
+		_ = json.NewEncoder(w).Encode(et)
+
+	case r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, collection+"/"):
+		name := strings.TrimPrefix(r.URL.Path, "/v2/")
+		et, ok := a.types[name]
+		if !ok {
+			http.Error(w, `{"error": {"message": "entity type not found"}}`, http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("updateMask") != "entities" {
+			http.Error(w, `{"error": {"message": "bad update mask"}}`, http.StatusBadRequest)
+			return
+		}
+		upd := EntityType{}
+		if err := json.NewDecoder(r.Body).Decode(&upd); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		et.Entities = upd.Entities
+		a.types[name] = et
+		_ = json.NewEncoder(w).Encode(et)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// writes returns number of create and update requests
+func (a *fakeAgent) writes() int {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+
+	n := 0
+	for _, m := range a.methods {
+		if m != http.MethodGet {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSyncAPI(t *testing.T) {
+	ctx := context.Background()
+	agent := newFakeAgent(
+		EntityType{DisplayName: "size", Kind: KindMap},
+		EntityType{DisplayName: ItemType, Kind: KindMap, Entities: []Entity{
+			{Value: "Los Angeles", Synonyms: []string{"Los Angeles", "LA"}},
+			{Value: "Морс (0,5 л)", Synonyms: []string{"Морс (0,5 л)"}},
+		}},
+	)
+	srv := httptest.NewServer(agent)
+	defer srv.Close()
+
+	cl, err := NewAPIClient(ctx, "test", "ru", WithEndpoint(srv.URL+"/v2/"), WithHTTPClient(srv.Client()))
+	if err != nil {
+		t.Fatalf("unexpected error in NewAPIClient: %v", err)
+	}
+	types := Build(testCatalog())
+
+	changes, err := Sync(ctx, cl, types, true)
+	if err != nil {
+		t.Fatalf("unexpected error in dry run: %v", err)
+	}
+	lines := []string{}
+	for _, c := range changes {
+		lines = append(lines, c.String())
+	}
+	expected := []string{
+		"+ category Блины",
+		"+ category Пироги",
+		"+ category С начинкой",
+		"+ item Блинчики",
+		"+ item Блины с сёмгой",
+		"~ item Морс (0,5 л): +Морс",
+		"- item Los Angeles",
+	}
+	if diff := cmp.Diff(expected, lines); diff != "" {
+		t.Errorf("dry run changes differ:\n%s", diff)
+	}
+	if n := agent.writes(); n != 0 {
+		t.Errorf("expected dry run to write nothing, got %d writes", n)
+	}
+
+	if _, err := Sync(ctx, cl, types, false); err != nil {
+		t.Fatalf("unexpected error in Sync: %v", err)
+	}
+	if n := agent.writes(); n != 2 {
+		t.Errorf("expected category to be created and item updated, got %d writes", n)
+	}
+
+	got, err := cl.ListEntityTypes(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error in ListEntityTypes: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected 3 entity types, got %d", len(got))
+	}
+	for _, et := range got {
+		for _, want := range types {
+			if et.DisplayName == want.DisplayName {
+				if diff := cmp.Diff(want.Entities, et.Entities); diff != "" {
+					t.Errorf("%s entities differ:\n%s", et.DisplayName, diff)
+				}
+			}
+		}
+	}
+
+	changes, err = Sync(ctx, cl, types, false)
+	if err != nil {
+		t.Fatalf("unexpected error in Sync: %v", err)
+	}
+	if len(changes) != 0 || agent.writes() != 2 {
+		t.Errorf("expected synced agent to stay intact, got changes %v", changes)
+	}
+}
+
+func TestSyncAPIError(t *testing.T) {
+	ctx := context.Background()
+	srv := httptest.NewServer(newFakeAgent())
+	defer srv.Close()
+
+	cl, err := NewAPIClient(ctx, "test", "en", WithEndpoint(srv.URL+"/v2"), WithHTTPClient(srv.Client()))
+	if err != nil {
+		t.Fatalf("unexpected error in NewAPIClient: %v", err)
+	}
+	_, err = Sync(ctx, cl, Build(testCatalog()), false)
+	if err == nil || !strings.Contains(err.Error(), "bad language") {
+		t.Errorf("expected API error message, got %v", err)
+	}
+}
+
+func TestSyncDir(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cl := NewDirClient(dir, "ru")
+	types := Build(testCatalog())
+	changes, err := Sync(ctx, cl, types, false)
+	if err != nil {
+		t.Fatalf("unexpected error in Sync: %v", err)
+	}
+	if len(changes) != 6 {
+		t.Errorf("expected 6 added entities, got %v", changes)
+	}
+
+	changes, err = Sync(ctx, cl, types, true)
+	if err != nil {
+		t.Fatalf("unexpected error in dry run: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("expected no changes after sync, got %v", changes)
+	}
+}
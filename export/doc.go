@@ -0,0 +1,4 @@
+// Package export writes the catalog out as normalized JSON, CSV
+// import-menu reads back, or a printable Markdown or HTML menu.
+// Texts are the cleaned up ones customers hear.
+package export
@@ -0,0 +1,88 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"mania/store"
+)
+
+// Export formats
+const (
+	FormatJSON     = "json"
+	FormatCSV      = "csv"
+	FormatMarkdown = "md"
+	FormatHTML     = "html"
+)
+
+// FormatByExt returns format of file name extension, JSON if unknown
+func FormatByExt(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return FormatCSV
+	case ".md", ".markdown":
+		return FormatMarkdown
+	case ".html", ".htm":
+		return FormatHTML
+	default:
+		return FormatJSON
+	}
+}
+
+// Write writes catalog in format, title is the printable menu heading
+func Write(w io.Writer, c store.Catalog, format, title string) error {
+	switch format {
+	case FormatJSON:
+		return JSON(w, c)
+	case FormatCSV:
+		return CSV(w, c)
+	case FormatMarkdown:
+		return Markdown(w, c, title)
+	case FormatHTML:
+		return HTML(w, c, title)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// JSON writes catalog as a JSON catalog file the file source reads
+func JSON(w io.Writer, c store.Catalog) error {
+	return store.EncodeCatalogJSON(w, c)
+}
+
+// csvHeader are CSV columns import-menu reads
+var csvHeader = []string{"id", "name", "category", "price", "composition", "description", "image"}
+
+// CSV writes one row per item in menu order. Category column holds
+// category paths like "Блины/Сладкие блины" separated by ";",
+// items not in any category are left out.
+func CSV(w io.Writer, c store.Catalog) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	t := newTree(c)
+	for _, item := range t.itemsInOrder() {
+		paths := t.itemPaths(item.ID)
+		err := cw.Write([]string{
+			strconv.Itoa(item.ID),
+			item.Name,
+			strings.Join(paths, "; "),
+			item.Price.Decimal(),
+			item.Composition,
+			item.Description,
+			item.Image,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
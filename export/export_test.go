@@ -0,0 +1,163 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mania/pos"
+	"mania/store"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// testCatalog returns catalog mapped from documents the way sources
+// map them, so texts are cleaned up
+func testCatalog(t *testing.T) store.Catalog {
+	t.Helper()
+
+	docs := []map[string]interface{}{
+		{
+			"product_id":  "101",
+			"name":        "Блинчики",
+			"composition": "Мука, молоко, яйцаБ-6,5Ж-8У-25,3 Ккал-201",
+			"description": "Тонкие&nbsp;\n блинчики",
+			"price":       "150",
+		},
+		{"product_id": "102", "name": "Блины с <сёмгой>", "price": "390.50"},
+		{"product_id": "103", "name": "Блины с творогом", "composition": "Творог, сахар", "price": "190"},
+		{"product_id": "401", "name": "Морс", "price": "90"},
+	}
+	c := store.Catalog{
+		Categories: []*store.Category{
+			{ID: 1, Name: "Блины", Products: []int{101, 102, 103}},
+			{ID: 2, ParentID: 1, Name: "Сладкие блины", Products: []int{103}},
+			{ID: 4, Name: "Напитки", Products: []int{401}},
+		},
+	}
+	for _, doc := range docs {
+		item, err := store.MapItem(doc)
+		if err != nil {
+			t.Fatalf("unexpected error in MapItem: %v", err)
+		}
+		c.Items = append(c.Items, &item)
+	}
+	return c
+}
+
+func TestMarkdown(t *testing.T) {
+	b := &bytes.Buffer{}
+	if err := Write(b, testCatalog(t), FormatMarkdown, "Меню"); err != nil {
+		t.Fatalf("unexpected error in Write: %v", err)
+	}
+
+	expected := `# Меню
+
+## Блины
+
+- **Блинчики** — 150 руб.
+  Мука, молоко, яйца Б-6,5%, Ж-8%, У-25,3%, пищевая ценность: Ккал-201
+  _Тонкие блинчики_
+- **Блины с <сёмгой>** — 390,50 руб.
+- **Блины с творогом** — 190 руб.
+  Творог, сахар
+
+### Сладкие блины
+
+- **Блины с творогом** — 190 руб.
+  Творог, сахар
+
+## Напитки
+
+- **Морс** — 90 руб.
+`
+	if diff := cmp.Diff(expected, b.String()); diff != "" {
+		t.Errorf("markdown differs:\n%s", diff)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	b := &bytes.Buffer{}
+	if err := Write(b, testCatalog(t), FormatHTML, "Меню"); err != nil {
+		t.Fatalf("unexpected error in Write: %v", err)
+	}
+
+	s := b.String()
+	for _, part := range []string{
+		"<title>Меню</title>",
+		`<section class="level-3">` + "\n<h2>Сладкие блины</h2>",
+		"Блины с &lt;сёмгой&gt;",
+		`<div class="description">Тонкие блинчики</div>`,
+	} {
+		if !strings.Contains(s, part) {
+			t.Errorf("expected html to contain %q, got:\n%s", part, s)
+		}
+	}
+}
+
+func TestJSONFileRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "catalog.json")
+
+	c := testCatalog(t)
+	b := &bytes.Buffer{}
+	if err := Write(b, c, FormatJSON, ""); err != nil {
+		t.Fatalf("unexpected error in Write: %v", err)
+	}
+	if err := ioutil.WriteFile(path, b.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := store.LoadCatalog(context.Background(), store.NewFileSource(path))
+	if err != nil {
+		t.Fatalf("unexpected error in LoadCatalog: %v", err)
+	}
+	if diff := store.DiffCatalogs(c, loaded); len(diff) != 0 {
+		t.Errorf("expected no changes after export and loading, got %v", diff)
+	}
+	if !strings.Contains(b.String(), `"price": "390.50"`) {
+		t.Errorf("expected price in rubles, got:\n%s", b.String())
+	}
+}
+
+func TestCSVImportRoundTrip(t *testing.T) {
+	c := testCatalog(t)
+	b := &bytes.Buffer{}
+	if err := Write(b, c, FormatCSV, ""); err != nil {
+		t.Fatalf("unexpected error in Write: %v", err)
+	}
+
+	m, err := pos.ReadCSV(b)
+	if err != nil {
+		t.Fatalf("unexpected error in ReadCSV: %v", err)
+	}
+	imported, err := m.Catalog(c)
+	if err != nil {
+		t.Fatalf("unexpected error in Catalog: %v", err)
+	}
+	if diff := store.DiffCatalogs(c, imported); len(diff) != 0 {
+		t.Errorf("expected no changes after export and import, got %v", diff)
+	}
+}
+
+func TestFormatByExt(t *testing.T) {
+	for name, expected := range map[string]string{
+		"menu.csv":  FormatCSV,
+		"menu.MD":   FormatMarkdown,
+		"menu.html": FormatHTML,
+		"menu.json": FormatJSON,
+		"menu":      FormatJSON,
+	} {
+		if got := FormatByExt(name); got != expected {
+			t.Errorf("FormatByExt(%q) = %q, expected %q", name, got, expected)
+		}
+	}
+}
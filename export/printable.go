@@ -0,0 +1,105 @@
+package export
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"mania/store"
+)
+
+// maxHeadingLevel is the deepest markdown and html heading
+const maxHeadingLevel = 6
+
+// section is a printable menu category with its own items,
+// subcategories follow as separate sections
+type section struct {
+	Title string
+	// Level is heading level, top categories are 2 under menu title
+	Level int
+	Items []*store.Item
+}
+
+// sections returns printable menu sections in tree order
+func sections(c store.Catalog) []section {
+	t := newTree(c)
+	res := []section{}
+	t.walk(func(cat *store.Category, level int) {
+		level += 2
+		if level > maxHeadingLevel {
+			level = maxHeadingLevel
+		}
+		res = append(res, section{Title: cat.Name, Level: level, Items: t.categoryItems(cat)})
+	})
+	return res
+}
+
+// Markdown writes printable menu
+func Markdown(w io.Writer, c store.Catalog, title string) error {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "# %s\n", title)
+
+	for _, s := range sections(c) {
+		fmt.Fprintf(b, "\n%s %s\n", strings.Repeat("#", s.Level), s.Title)
+		if len(s.Items) > 0 {
+			b.WriteString("\n")
+		}
+		for _, item := range s.Items {
+			fmt.Fprintf(b, "- **%s** — %s\n", item.Name, item.Price)
+			if item.Composition != "" {
+				fmt.Fprintf(b, "  %s\n", item.Composition)
+			}
+			if item.Description != "" {
+				fmt.Fprintf(b, "  _%s_\n", item.Description)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var htmlTemplate = template.Must(template.New("menu").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: Georgia, serif; max-width: 40em; margin: 2em auto; }
+.level-3 h2 { font-size: 1.2em; margin-left: 1em; }
+.level-4 h2, .level-5 h2, .level-6 h2 { font-size: 1em; margin-left: 2em; }
+.item { margin: 0.5em 0; }
+.price { float: right; }
+.composition, .description { color: #555; font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{- range .Sections}}
+<section class="level-{{.Level}}">
+<h2>{{.Title}}</h2>
+{{- range .Items}}
+<div class="item">
+<span class="name">{{.Name}}</span> <span class="price">{{.Price}}</span>
+{{- if .Composition}}
+<div class="composition">{{.Composition}}</div>
+{{- end}}
+{{- if .Description}}
+<div class="description">{{.Description}}</div>
+{{- end}}
+</div>
+{{- end}}
+</section>
+{{- end}}
+</body>
+</html>
+`))
+
+// HTML writes printable menu page
+func HTML(w io.Writer, c store.Catalog, title string) error {
+	return htmlTemplate.Execute(w, struct {
+		Title    string
+		Sections []section
+	}{title, sections(c)})
+}
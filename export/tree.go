@@ -0,0 +1,113 @@
+package export
+
+import (
+	"strings"
+
+	"mania/store"
+)
+
+// tree is catalog category tree in catalog order
+type tree struct {
+	roots    []*store.Category
+	children map[int][]*store.Category
+	parents  map[int]*store.Category
+	items    map[int]*store.Item
+}
+
+// newTree builds category tree, categories with unknown
+// or self parent are top level like in the cache
+func newTree(c store.Catalog) *tree {
+	t := &tree{
+		children: map[int][]*store.Category{},
+		parents:  map[int]*store.Category{},
+		items:    map[int]*store.Item{},
+	}
+
+	byID := map[int]*store.Category{}
+	for _, cat := range c.Categories {
+		byID[cat.ID] = cat
+	}
+	for _, cat := range c.Categories {
+		parent, ok := byID[cat.ParentID]
+		if !ok || parent == cat {
+			t.roots = append(t.roots, cat)
+			continue
+		}
+		t.parents[cat.ID] = parent
+		t.children[parent.ID] = append(t.children[parent.ID], cat)
+	}
+	for _, item := range c.Items {
+		t.items[item.ID] = item
+	}
+
+	return t
+}
+
+// walk calls fn for categories depth first, level of roots is 0.
+// Cycles are walked once.
+func (t *tree) walk(fn func(cat *store.Category, level int)) {
+	seen := map[int]bool{}
+	var visit func(cats []*store.Category, level int)
+	visit = func(cats []*store.Category, level int) {
+		for _, cat := range cats {
+			if seen[cat.ID] {
+				continue
+			}
+			seen[cat.ID] = true
+			fn(cat, level)
+			visit(t.children[cat.ID], level+1)
+		}
+	}
+	visit(t.roots, 0)
+}
+
+// categoryItems returns known category items in category order
+func (t *tree) categoryItems(cat *store.Category) []*store.Item {
+	res := []*store.Item{}
+	for _, id := range cat.Products {
+		if item, ok := t.items[id]; ok {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+// itemsInOrder returns items as they first appear walking the tree
+func (t *tree) itemsInOrder() []*store.Item {
+	res := []*store.Item{}
+	seen := map[int]bool{}
+	t.walk(func(cat *store.Category, level int) {
+		for _, item := range t.categoryItems(cat) {
+			if !seen[item.ID] {
+				seen[item.ID] = true
+				res = append(res, item)
+			}
+		}
+	})
+	return res
+}
+
+// path returns category path like "Блины/Сладкие блины"
+func (t *tree) path(cat *store.Category) string {
+	names := []string{cat.Name}
+	seen := map[int]bool{cat.ID: true}
+	for p := t.parents[cat.ID]; p != nil && !seen[p.ID]; p = t.parents[p.ID] {
+		seen[p.ID] = true
+		names = append([]string{p.Name}, names...)
+	}
+	return strings.Join(names, "/")
+}
+
+// itemPaths returns paths of categories listing the item in tree order
+func (t *tree) itemPaths(itemID int) []string {
+	res := []string{}
+	t.walk(func(cat *store.Category, level int) {
+		for _, id := range cat.Products {
+			if id == itemID {
+				res = append(res, t.path(cat))
+				break
+			}
+		}
+	})
+	return res
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"mania/export"
+	"mania/tenant"
+)
+
+// exportMenu writes tenant catalog as customers get it:
+//
+//	mania export-menu [-tenant id] [-format json|csv|md|html] [-title title] [-o file]
+//
+// Catalog is validated, so quarantine and text cleanup apply the
+// same way they do when serving the bot.
+func exportMenu(ctx context.Context, args []string) error {
+	fl := flag.NewFlagSet("export-menu", flag.ContinueOnError)
+	tenantID := fl.String("tenant", tenant.DefaultID, "tenant to export menu of")
+	format := fl.String("format", "", "output format: json, csv, md or html, by output file extension if empty")
+	title := fl.String("title", "Меню", "printable menu title")
+	output := fl.String("o", "", "output file, stdout if empty")
+	if err := fl.Parse(args); err != nil {
+		return err
+	}
+	if fl.NArg() != 0 {
+		return errors.New("usage: mania export-menu [flags]")
+	}
+	if *format == "" {
+		*format = export.FormatByExt(*output)
+	}
+
+	ts, err := findTenant(*tenantID)
+	if err != nil {
+		return err
+	}
+	catalog, err := loadCatalog(ctx, ts)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if err := export.Write(out, catalog, *format, *title); err != nil {
+		return fmt.Errorf("failed to export menu: %w", err)
+	}
+	if *output != "" {
+		fmt.Printf("Exported %d categories and %d items to %s\n", len(catalog.Categories), len(catalog.Items), *output)
+	}
+	return nil
+}
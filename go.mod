@@ -6,8 +6,12 @@ require (
 	cloud.google.com/go v0.38.0
 	firebase.google.com/go v3.12.0+incompatible
 	github.com/google/go-cmp v0.3.0
+	go.etcd.io/bbolt v1.3.5
 	go.opencensus.io v0.22.3 // indirect
 	golang.org/x/net v0.7.0
+	golang.org/x/oauth2 v0.0.0-20190604053449-0f29369cfe45
 	google.golang.org/api v0.21.0
 	google.golang.org/genproto v0.0.0-20200410110633-0848e9f44c36 // indirect
+	google.golang.org/grpc v1.27.0
+	gopkg.in/yaml.v3 v3.0.1
 )
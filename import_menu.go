@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"mania/pos"
+	"mania/store"
+	"mania/tenant"
+)
+
+// importMenu imports menu from a POS export replacing tenant catalog:
+//
+//	mania import-menu [-tenant id] [-format csv|iiko-json|iiko-xml] [-yes] export.csv
+//
+// Changes against the current catalog are printed and written
+// to the tenant catalog file or Firestore only after confirmation.
+func importMenu(ctx context.Context, args []string) error {
+	fl := flag.NewFlagSet("import-menu", flag.ContinueOnError)
+	tenantID := fl.String("tenant", tenant.DefaultID, "tenant to import menu to")
+	format := fl.String("format", "", "export format: csv, iiko-json or iiko-xml, by file extension if empty")
+	yes := fl.Bool("yes", false, "write changes without confirmation")
+	if err := fl.Parse(args); err != nil {
+		return err
+	}
+	if fl.NArg() != 1 {
+		return errors.New("usage: mania import-menu [flags] <export file>")
+	}
+
+	ts, err := findTenant(*tenantID)
+	if err != nil {
+		return err
+	}
+	src, err := (&sources{}).get(ctx, ts)
+	if err != nil {
+		return fmt.Errorf("failed to open catalog: %w", err)
+	}
+	w, ok := src.(store.CatalogWriter)
+	if !ok {
+		return errors.New("catalog source is read only")
+	}
+
+	current, err := store.LoadCatalog(ctx, src)
+	var docErrs store.DocumentErrors
+	if errors.As(err, &docErrs) {
+		fmt.Printf("WARNING: %d malformed documents in current catalog will be replaced\n", len(docErrs))
+	} else if err != nil {
+		return fmt.Errorf("failed to load current catalog: %w", err)
+	}
+
+	menu, err := pos.ReadFile(fl.Arg(0), *format)
+	if err != nil {
+		return err
+	}
+	imported, err := menu.Catalog(current)
+	if err != nil {
+		return fmt.Errorf("failed to map menu: %w", err)
+	}
+
+	diff := store.DiffCatalogs(current, imported)
+	if len(diff) == 0 {
+		fmt.Println("Catalog is up to date")
+		return nil
+	}
+	for _, e := range diff {
+		fmt.Println(e)
+	}
+
+	dest := "Firestore"
+	if ts.CatalogFile != "" {
+		dest = ts.CatalogFile
+	}
+	question := fmt.Sprintf("Write %d changes to %s of tenant %s?", len(diff), dest, ts.ID)
+	if !*yes && !confirm(os.Stdin, os.Stdout, question) {
+		fmt.Println("Nothing written")
+		return nil
+	}
+
+	if err := w.WriteCatalog(ctx, imported); err != nil {
+		return fmt.Errorf("failed to write catalog: %w", err)
+	}
+	fmt.Printf("Imported %d categories and %d items\n", len(imported.Categories), len(imported.Items))
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"mania/dialogflow"
 	"mania/store"
 	"strconv"
+	"unicode/utf8"
 )
 
 // AddToCartHandler handles add_to_cart_context intent
@@ -15,10 +16,23 @@ func (d *Dispatcher) AddToCartHandler(req dialogflow.Request) (dialogflow.Respon
 		return dialogflow.GenerateResponse(true, "Не могу распознать блюдо"), nil
 	}
 
-	item, err := d.cache.GetItem(itemName)
+	// version is read first, so the item is never older than
+	// the version and checkout rechecks it after any swap
+	version := d.cache.Version()
+	item, candidates, err := d.findItem(itemName)
 	if err != nil {
 		return dialogflow.GenerateResponse(false, "Не удалось получить информацию о блюде"), err
 	}
+	if item == nil {
+		return clarifyItemResponse(candidates), nil
+	}
+
+	if !d.cache.IsAvailable(item.ID) {
+		return d.unavailableResponse(item), nil
+	}
+	if !d.cache.IsOffered(item.ID) {
+		return d.notOfferedResponse(item), nil
+	}
 
 	quantity := uint(1)
 	numberStr, ok := req.QueryResult.Parameters["number"].(string)
@@ -29,22 +43,67 @@ func (d *Dispatcher) AddToCartHandler(req dialogflow.Request) (dialogflow.Respon
 		}
 	}
 
-	d.sessions.AddPosition(
-		req.Session,
-		store.Position{
-			Item:     *item,
-			Quantity: quantity,
-		})
+	pos := store.Position{
+		Item:     *item,
+		Quantity: quantity,
+		Version:  version,
+	}
+	for _, name := range stringsParam(req, "option") {
+		if group, option, ok := item.FindOption(name); ok {
+			pos.Options = item.SelectOption(pos.Options, group, option)
+		}
+	}
+
+	return d.addPosition(req, pos), nil
+}
+
+// addPosition adds position to the cart and reads cart total out.
+// If position lacks required options it is kept pending and
+// customer is asked to choose.
+func (d *Dispatcher) addPosition(req dialogflow.Request, pos store.Position) dialogflow.Response {
+	if missing := pos.Item.MissingGroups(pos.Options); len(missing) > 0 {
+		d.sessions.SetPending(req.Session, &pos)
+		return dialogflow.GenerateResponse(true, askOptionText(&pos.Item, missing[0]))
+	}
+
+	pos.Price = pos.UnitPrice()
+	d.sessions.SetPending(req.Session, nil)
+	d.sessions.AddPosition(req.Session, pos)
 
 	sess := d.sessions.GetSession(req.Session)
-	cnt := uint(0)
-	amount := 0.0
-	for _, pos := range sess.Cart {
-		cnt += pos.Quantity
-		amount += pos.Item.Price
+	cnt, amount := sess.CartTotal()
+
+	text := fmt.Sprintf("В корзине %d товаров на сумму %s", cnt, amount.Spoken())
+	if warning := allergenWarning(&pos.Item, sess.Excluded); warning != "" {
+		text = fmt.Sprintf("%s %s", warning, text)
+	}
+
+	// upsell the item most often ordered with the cart, names too
+	// long for a chip are only told
+	chips := []string{chipCheckout}
+	if upsell := d.cache.FrequentlyBoughtWith(sess.ItemIDs(), 1); len(upsell) > 0 {
+		text = fmt.Sprintf("%s. К этому часто берут %s.", text, upsell[0].Name)
+		if utf8.RuneCountInString(upsell[0].Name) <= maxChipLen {
+			chips = append([]string{upsell[0].Name}, chips...)
+		}
 	}
 
-	text := fmt.Sprintf("В корзине %d товаров на сумму %5.2f рублей", cnt, amount)
+	return withSuggestions(req, dialogflow.GenerateResponse(true, text), chips...)
+}
+
+// unavailableResponse explains that item is out of stock and
+// suggests alternatives from the same category
+func (d *Dispatcher) unavailableResponse(item *store.Item) dialogflow.Response {
+	text := fmt.Sprintf("К сожалению, %s сейчас нет в наличии.", item.Name)
+
+	alternatives := d.cache.GetAlternatives(item.ID, maxCandidates)
+	if len(alternatives) > 0 {
+		names := make([]string, len(alternatives))
+		for i, alt := range alternatives {
+			names[i] = alt.Name
+		}
+		text = fmt.Sprintf("%s Могу предложить %s.", text, joinWords(names, "или"))
+	}
 
-	return dialogflow.GenerateResponse(true, text), nil
+	return dialogflow.GenerateResponse(true, text)
 }
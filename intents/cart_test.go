@@ -0,0 +1,40 @@
+package intents
+
+import (
+	"strings"
+	"testing"
+
+	"mania/money"
+	"mania/store"
+)
+
+func TestAddToCartUpsell(t *testing.T) {
+	st := testStore()
+	d, _ := newTestDispatcher(t, st)
+	add := withScreen(testRequest(map[string]interface{}{"item": "Борщ"}))
+
+	st.upsell = []*store.Item{{ID: 401, Name: "Морс", Price: money.FromRubles(90)}}
+	resp, err := d.AddToCartHandler(add)
+	if err != nil {
+		t.Fatalf("unexpected error in AddToCartHandler: %v", err)
+	}
+	if !strings.Contains(resp.Text(), "К этому часто берут Морс.") {
+		t.Errorf("expected upsell in %q", resp.Text())
+	}
+	if chips := resp.Payload.Google.RichResponse.Suggestions; len(chips) != 2 || chips[0].Title != "Морс" {
+		t.Errorf("expected upsell chip, got %v", chips)
+	}
+
+	// too long for a chip, still told
+	long := "Блины с курицей, грибами и сыром"
+	st.upsell = []*store.Item{{ID: 104, Name: long, Price: money.FromRubles(290)}}
+	if resp, err = d.AddToCartHandler(add); err != nil {
+		t.Fatalf("unexpected error in AddToCartHandler: %v", err)
+	}
+	if !strings.Contains(resp.Text(), long) {
+		t.Errorf("expected upsell in %q", resp.Text())
+	}
+	if chips := resp.Payload.Google.RichResponse.Suggestions; len(chips) != 1 || chips[0].Title != chipCheckout {
+		t.Errorf("expected checkout chip only, got %v", chips)
+	}
+}
@@ -2,7 +2,9 @@ package intents
 
 import (
 	"fmt"
+	"log"
 	"mania/dialogflow"
+	"mania/store"
 )
 
 // CheckoutHandler handles checkout_intent
@@ -18,21 +20,80 @@ func (d *Dispatcher) CheckoutHandler(req dialogflow.Request) (dialogflow.Respons
 		return dialogflow.GenerateResponse(false, "Корзина пуста"), nil
 	}
 
-	cnt := uint(0)
-	amount := 0.0
+	// catalog may have changed while customer was choosing,
+	// the order is sent only at prices customer agreed to
+	if changes := d.cache.CheckCart(sess.Positions()); len(changes) > 0 {
+		d.sessions.ApplyCartChanges(req.Session, changes)
+		return d.cartChangedResponse(req, changes), nil
+	}
+
+	cnt, amount := sess.CartTotal()
 
 	items := ""
 
-	for _, pos := range sess.Cart {
-		cnt += pos.Quantity
-		amount += pos.Item.Price
-		items = fmt.Sprintf("%s%s - %dшт\n", items, pos.Item.Name, pos.Quantity)
+	for _, pos := range sess.Positions() {
+		items = fmt.Sprintf("%s%s - %dшт x %s = %s\n",
+			items, pos.Name(), pos.Quantity, pos.UnitPrice(), pos.Total())
 	}
 
-	text := fmt.Sprintf("Заказ от %s: %d товаров на сумму %5.2f рублей:\n%s", phoneNumber, cnt, amount, items)
-	if err := d.Send(text, phoneNumber); err != nil {
+	address := phoneNumber
+	if d.orderPhone != "" {
+		address = d.orderPhone
+	}
+
+	text := fmt.Sprintf("Заказ от %s: %d товаров на сумму %s:\n%s", phoneNumber, cnt, amount, items)
+	if err := d.Send(text, address); err != nil {
 		return dialogflow.GenerateResponse(false, "Ошибка отправки заказа, попробуйте ещё"), err
 	}
 
-	return dialogflow.GenerateResponse(true, "Ваш заказ зарегистрирован. Ожидайте звонка. Спасибо!"), nil
+	// sent order leaves the cart, so it is never sent twice
+	d.sessions.RemoveOrdered(req.Session, sess.Positions())
+	d.recordOrder(sess)
+
+	reply := "Ваш заказ зарегистрирован. Ожидайте звонка. Спасибо!"
+	if d.hours != "" {
+		reply = fmt.Sprintf("%s Мы работаем %s.", reply, d.hours)
+	}
+
+	return dialogflow.GenerateResponse(true, reply), nil
+}
+
+// cartChangedResponse tells customer about repriced and removed
+// positions and the new total, and asks to confirm the order
+func (d *Dispatcher) cartChangedResponse(req dialogflow.Request, changes []store.CartChange) dialogflow.Response {
+	lines := make([]string, len(changes))
+	for i, ch := range changes {
+		if ch.Removed() {
+			lines[i] = fmt.Sprintf("%s больше нет в меню", ch.Position.Name())
+			continue
+		}
+		lines[i] = fmt.Sprintf("%s теперь стоит %s вместо %s",
+			ch.Current.Name(), ch.Current.Price.Spoken(), ch.Position.Price.Spoken())
+	}
+	text := fmt.Sprintf("Пока вы выбирали, меню обновилось: %s.", joinWords(lines, "и"))
+
+	sess := d.sessions.GetSession(req.Session)
+	if len(sess.Cart) == 0 {
+		return dialogflow.GenerateResponse(true, text+" Корзина пуста.")
+	}
+
+	_, amount := sess.CartTotal()
+	text = fmt.Sprintf("%s Новая сумма заказа %s. Оформить заказ?", text, amount.Spoken())
+	return withSuggestions(req, dialogflow.GenerateResponse(true, text), chipCheckout)
+}
+
+// recordOrder stores sent order for popularity ranking,
+// failure does not fail the checkout
+func (d *Dispatcher) recordOrder(sess store.Session) {
+	o := store.Order{
+		Time:  d.cache.Now(),
+		Items: make(map[int]uint, len(sess.Cart)),
+	}
+	for _, pos := range sess.Cart {
+		o.Items[pos.Item.ID] += pos.Quantity
+	}
+
+	if err := d.cache.RecordOrder(d.ctx, o); err != nil {
+		log.Printf("ERROR: failed to record order: %v", err)
+	}
 }
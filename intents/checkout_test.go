@@ -0,0 +1,69 @@
+package intents
+
+import (
+	"strings"
+	"testing"
+
+	"mania/money"
+	"mania/store"
+)
+
+func TestCheckout(t *testing.T) {
+	st := testStore()
+	d, sent := newTestDispatcher(t, st)
+	checkout := testRequest(map[string]interface{}{"phonenum": "+79001234567"})
+
+	call(t, d.AddToCartHandler, testRequest(map[string]interface{}{"item": "Борщ", "number": "2"}))
+	text := call(t, d.CheckoutHandler, checkout)
+	if !strings.HasPrefix(text, "Ваш заказ зарегистрирован.") {
+		t.Errorf("expected order to be registered, got %q", text)
+	}
+	if len(*sent) != 1 || !strings.Contains((*sent)[0], "Борщ - 2шт x 250 руб. = 500 руб.") {
+		t.Errorf("unexpected sent orders %q", *sent)
+	}
+	if len(st.orders) != 1 || st.orders[0].Items[201] != 2 {
+		t.Errorf("unexpected recorded orders %v", st.orders)
+	}
+
+	// sent cart is gone, the order is not sent twice
+	if sess := d.sessions.GetSession(testSession); len(sess.Cart) != 0 {
+		t.Errorf("expected empty cart after checkout, got %v", sess.Cart)
+	}
+	if text := call(t, d.CheckoutHandler, checkout); text != "Корзина пуста" {
+		t.Errorf("expected empty cart, got %q", text)
+	}
+	if len(*sent) != 1 || len(st.orders) != 1 {
+		t.Errorf("expected the order once, got %d sent and %d recorded", len(*sent), len(st.orders))
+	}
+}
+
+func TestCheckoutCartChanged(t *testing.T) {
+	st := testStore()
+	d, sent := newTestDispatcher(t, st)
+	checkout := testRequest(map[string]interface{}{"phonenum": "+79001234567"})
+
+	call(t, d.AddToCartHandler, testRequest(map[string]interface{}{"item": "Борщ"}))
+	pos := d.sessions.GetSession(testSession).Positions()[0]
+	cur := pos
+	cur.Item.Price = money.FromRubles(270)
+	cur.Price = cur.UnitPrice()
+	st.changes = []store.CartChange{{Position: pos, Current: &cur}}
+
+	text := call(t, d.CheckoutHandler, checkout)
+	if !strings.HasPrefix(text, "Пока вы выбирали, меню обновилось: Борщ теперь стоит двести семьдесят рублей") {
+		t.Errorf("expected repriced position, got %q", text)
+	}
+	if len(*sent) != 0 {
+		t.Errorf("expected order not to be sent, got %q", *sent)
+	}
+	if sess := d.sessions.GetSession(testSession); sess.Cart[pos.Key()].Price != cur.Price {
+		t.Errorf("expected cart to be repriced, got %v", sess.Cart)
+	}
+
+	// confirmed at the new price
+	st.changes = nil
+	call(t, d.CheckoutHandler, checkout)
+	if len(*sent) != 1 || !strings.Contains((*sent)[0], "= 270 руб.") {
+		t.Errorf("expected order at the new price, got %q", *sent)
+	}
+}
@@ -3,6 +3,7 @@ package intents
 import (
 	"context"
 	"errors"
+	"time"
 
 	"mania/dialogflow"
 	"mania/store"
@@ -26,13 +27,41 @@ const (
 	GetItem               IntentName = "get_category_item"
 	AddToCartContext      IntentName = "add_to_cart_context"
 	Checkout              IntentName = "checkout"
+	SearchItems           IntentName = "search_items"
+	SearchItemsNext       IntentName = "search_items_next"
+	ListSubcategories     IntentName = "list_subcategories"
+	ListSubcategoriesNext IntentName = "list_subcategories_next"
+	NavigateBack          IntentName = "navigate_back"
+	GetItemNutrition      IntentName = "get_item_nutrition"
+	ListItemsByKcal       IntentName = "list_items_by_calories"
+	ListItemsByKcalNext   IntentName = "list_items_by_calories_next"
+	SelectOption          IntentName = "select_option"
+	Recommend             IntentName = "recommend"
+	OptionSelected        IntentName = "actions_intent_OPTION"
 )
 
 // Store provides functions to access menu data
 type Store interface {
 	GetCategoriesPage(pageNum, pageSize int) []*store.Category
-	GetItemsPage(categoryName string, pageNum, pageSize int) ([]*store.Item, error)
+	GetSubcategoriesPage(categoryName string, pageNum, pageSize int) ([]*store.Category, error)
+	GetParentCategory(categoryName string) (*store.Category, error)
+	GetItemsPage(categoryName string, pageNum, pageSize int, filters ...store.ItemFilter) ([]*store.Item, error)
+	GetAllItemsPage(pageNum, pageSize int, filters ...store.ItemFilter) []*store.Item
 	GetItem(itemName string) (*store.Item, error)
+	FindItems(itemName string, limit int) []store.ItemMatch
+	FindCategories(categoryName string, limit int) []store.CategoryMatch
+	SearchItems(query string, pageNum, pageSize int, filters ...store.ItemFilter) []*store.Item
+	IsAvailable(itemID int) bool
+	GetAlternatives(itemID, limit int) []*store.Item
+	Recommend(basket []int, limit int) []*store.Item
+	FrequentlyBoughtWith(basket []int, limit int) []*store.Item
+	IsOffered(itemID int) bool
+	NextOffered(itemID int) (time.Time, bool)
+	NextCategoryOpen(categoryName string) (time.Time, bool)
+	Now() time.Time
+	RecordOrder(ctx context.Context, o store.Order) error
+	Version() uint64
+	CheckCart(positions []store.Position) []store.CartChange
 }
 
 // Sender provides send method to deliver order to the kitchen
@@ -42,25 +71,81 @@ type Sender interface {
 
 // Dispatcher provides handlers for intents
 type Dispatcher struct {
+	ctx       context.Context
 	cache     Store
 	sessions  *store.Sessions
 	intentMap map[IntentName]IntentHandler
 	pageSize  int
+	// orderPhone receives orders, customer phone is used if empty
+	orderPhone string
+	// hours are opening hours told to customer after checkout
+	hours string
+	// sessionOpts configure sessions storage
+	sessionOpts []store.SessionsOption
 	Sender
 }
 
-// NewDispatcher returns new *Dispatcher instance
+// DispatcherOption configures Dispatcher
+type DispatcherOption func(*Dispatcher)
+
+// WithPageSize sets how many categories or items are read out at once
+func WithPageSize(n int) DispatcherOption {
+	return func(d *Dispatcher) {
+		if n > 0 {
+			d.pageSize = n
+		}
+	}
+}
+
+// WithOrderPhone makes dispatcher send orders to phone
+func WithOrderPhone(phone string) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.orderPhone = phone
+	}
+}
+
+// WithHours sets opening hours text, e.g. "с 10:00 до 22:00"
+func WithHours(hours string) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.hours = hours
+	}
+}
+
+// WithSessionStore keeps customer sessions in st,
+// in memory of the instance otherwise
+func WithSessionStore(st store.SessionStore) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.sessionOpts = append(d.sessionOpts, store.WithSessionStore(st))
+	}
+}
+
+// WithSessionTTL sets how long idle customer sessions are kept
+func WithSessionTTL(ttl time.Duration) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.sessionOpts = append(d.sessionOpts, store.WithSessionTTL(ttl))
+	}
+}
+
+// NewDispatcher returns new *Dispatcher instance.
+// Every dispatcher keeps its own sessions, so dispatchers
+// of different restaurants never share customer carts
+// unless given the same session store.
 func NewDispatcher(
 	ctx context.Context,
 	st Store,
 	sn Sender,
+	opts ...DispatcherOption,
 ) *Dispatcher {
 	d := Dispatcher{
+		ctx:      ctx,
 		cache:    st,
-		sessions: store.NewSessions(ctx),
 		pageSize: 7,
 		Sender:   sn,
 	}
+	for _, opt := range opts {
+		opt(&d)
+	}
+	d.sessions = store.NewSessions(ctx, d.sessionOpts...)
 
 	d.intentMap = map[IntentName]IntentHandler{
 		ListCategories:        d.ListCategoriesHandler,
@@ -70,11 +155,28 @@ func NewDispatcher(
 		GetItem:               d.GetItemHandler,
 		AddToCartContext:      d.AddToCartHandler,
 		Checkout:              d.CheckoutHandler,
+		SearchItems:           d.SearchItemsHandler,
+		SearchItemsNext:       d.SearchItemsNextHandler,
+		ListSubcategories:     d.ListSubcategoriesHandler,
+		ListSubcategoriesNext: d.ListSubcategoriesNextHandler,
+		NavigateBack:          d.NavigateBackHandler,
+		GetItemNutrition:      d.GetItemNutritionHandler,
+		ListItemsByKcal:       d.ListItemsByKcalHandler,
+		ListItemsByKcalNext:   d.ListItemsByKcalNextHandler,
+		SelectOption:          d.SelectOptionHandler,
+		Recommend:             d.RecommendHandler,
+		OptionSelected:        d.OptionSelectedHandler,
 	}
 
 	return &d
 }
 
+// sessionErrorResponse is returned when customer session
+// could not be saved
+func sessionErrorResponse(err error) (dialogflow.Response, error) {
+	return dialogflow.GenerateResponse(false, "Не удалось сохранить ваш выбор, попробуйте ещё раз"), err
+}
+
 // GetHandler returns a handler for intent webhook
 func (d *Dispatcher) GetHandler(intentName string) (IntentHandler, error) {
 	h, ok := d.intentMap[IntentName(intentName)]
@@ -0,0 +1,208 @@
+package intents
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"mania/dialogflow"
+	"mania/money"
+	"mania/store"
+)
+
+const testSession = "test-session"
+
+// stubStore serves fixed categories and items. Methods handlers
+// under test don't call panic through the embedded nil Store.
+type stubStore struct {
+	Store
+	categories []*store.Category
+	items      []*store.Item
+	// upsell is returned by FrequentlyBoughtWith
+	upsell []*store.Item
+	// changes are returned by CheckCart
+	changes []store.CartChange
+	orders  []store.Order
+}
+
+func (s *stubStore) GetItem(itemName string) (*store.Item, error) {
+	for _, item := range s.items {
+		if strings.EqualFold(item.Name, itemName) {
+			return item, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (s *stubStore) FindItems(itemName string, limit int) []store.ItemMatch {
+	return nil
+}
+
+func (s *stubStore) FindCategories(categoryName string, limit int) []store.CategoryMatch {
+	for _, cat := range s.categories {
+		if strings.EqualFold(cat.Name, categoryName) {
+			return []store.CategoryMatch{{Category: cat, Score: 1}}
+		}
+	}
+	return nil
+}
+
+func (s *stubStore) GetItemsPage(categoryName string, pageNum, pageSize int, filters ...store.ItemFilter) ([]*store.Item, error) {
+	for _, cat := range s.categories {
+		if cat.Name != categoryName {
+			continue
+		}
+		items := []*store.Item{}
+		for _, id := range cat.Products {
+			for _, item := range s.filter(filters) {
+				if item.ID == id {
+					items = append(items, item)
+				}
+			}
+		}
+		return page(items, pageNum, pageSize), nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (s *stubStore) GetSubcategoriesPage(categoryName string, pageNum, pageSize int) ([]*store.Category, error) {
+	return nil, nil
+}
+
+func (s *stubStore) GetAllItemsPage(pageNum, pageSize int, filters ...store.ItemFilter) []*store.Item {
+	return page(s.filter(filters), pageNum, pageSize)
+}
+
+func (s *stubStore) SearchItems(query string, pageNum, pageSize int, filters ...store.ItemFilter) []*store.Item {
+	items := []*store.Item{}
+	for _, item := range s.filter(filters) {
+		if strings.Contains(strings.ToLower(item.Name), strings.ToLower(query)) {
+			items = append(items, item)
+		}
+	}
+	return page(items, pageNum, pageSize)
+}
+
+func (s *stubStore) IsAvailable(itemID int) bool { return true }
+func (s *stubStore) IsOffered(itemID int) bool   { return true }
+func (s *stubStore) Now() time.Time              { return time.Now() }
+func (s *stubStore) Version() uint64             { return 1 }
+
+func (s *stubStore) FrequentlyBoughtWith(basket []int, limit int) []*store.Item {
+	return s.upsell
+}
+
+func (s *stubStore) CheckCart(positions []store.Position) []store.CartChange {
+	return s.changes
+}
+
+func (s *stubStore) RecordOrder(ctx context.Context, o store.Order) error {
+	s.orders = append(s.orders, o)
+	return nil
+}
+
+// filter returns items passing every filter
+func (s *stubStore) filter(filters []store.ItemFilter) []*store.Item {
+	res := []*store.Item{}
+	for _, item := range s.items {
+		ok := true
+		for _, f := range filters {
+			ok = ok && f(item)
+		}
+		if ok {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+// page returns one page of items
+func page(items []*store.Item, pageNum, pageSize int) []*store.Item {
+	from, to := pageNum*pageSize, (pageNum+1)*pageSize
+	if from >= len(items) {
+		return nil
+	}
+	if to > len(items) {
+		to = len(items)
+	}
+	return items[from:to]
+}
+
+// sentOrders records orders sent to the kitchen
+type sentOrders []string
+
+func (s *sentOrders) Send(text, address string) error {
+	*s = append(*s, text)
+	return nil
+}
+
+// testStore returns store with a small menu, Блинчики have
+// required size and optional topping option groups
+func testStore() *stubStore {
+	pancakes := &store.Item{
+		ID:    101,
+		Name:  "Блинчики",
+		Price: money.FromRubles(150),
+		OptionGroups: []store.OptionGroup{
+			{ID: 1, Name: "Размер порции", Required: true, Options: []store.Option{
+				{ID: 1, Name: "обычная"},
+				{ID: 2, Name: "большая", PriceDelta: money.FromRubles(50)},
+			}},
+			{ID: 2, Name: "Добавки", Options: []store.Option{
+				{ID: 1, Name: "сгущенка", PriceDelta: money.FromRubles(30)},
+				{ID: 2, Name: "сметана", PriceDelta: money.FromRubles(20)},
+			}},
+		},
+	}
+	items := []*store.Item{
+		pancakes,
+		{ID: 102, Name: "Блины с творогом", Price: money.FromRubles(190)},
+		{ID: 103, Name: "Блины с семгой", Price: money.FromRubles(390)},
+		{ID: 201, Name: "Борщ", Price: money.FromRubles(250)},
+		{ID: 401, Name: "Морс", Price: money.FromRubles(90)},
+	}
+	return &stubStore{
+		categories: []*store.Category{
+			{ID: 1, Name: "Блины", Products: []int{101, 102, 103}},
+			{ID: 2, Name: "Супы", Products: []int{201}},
+			{ID: 4, Name: "Напитки", Products: []int{401}},
+		},
+		items: items,
+	}
+}
+
+// newTestDispatcher returns dispatcher reading st two items a page
+func newTestDispatcher(t *testing.T, st *stubStore) (*Dispatcher, *sentOrders) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	// stops sessions cleanup, sessions are still served
+	cancel()
+
+	sent := &sentOrders{}
+	return NewDispatcher(ctx, st, sent, WithPageSize(2)), sent
+}
+
+// testRequest returns request of the test session with parameters
+func testRequest(params map[string]interface{}) dialogflow.Request {
+	req := dialogflow.Request{Session: testSession}
+	req.QueryResult.Parameters = params
+	return req
+}
+
+// withScreen returns request coming from a device with a screen
+func withScreen(req dialogflow.Request) dialogflow.Request {
+	req.OriginalRequest.Payload.Surface.Capabilities = []dialogflow.Capability{{Name: dialogflow.ScreenOutput}}
+	return req
+}
+
+// call runs handler and returns response text
+func call(t *testing.T, h IntentHandler, req dialogflow.Request) string {
+	t.Helper()
+	resp, err := h(req)
+	if err != nil {
+		t.Fatalf("unexpected handler error: %v", err)
+	}
+	return resp.Text()
+}
@@ -0,0 +1,42 @@
+package intents
+
+import (
+	"fmt"
+	"mania/dialogflow"
+	"mania/store"
+)
+
+// itemFilters remembers ingredients customer asked to avoid in
+// the request "excluded" parameter and returns filters skipping items
+// with any of the ingredients mentioned in the session so far
+func (d *Dispatcher) itemFilters(req dialogflow.Request) []store.ItemFilter {
+	if excluded := stringsParam(req, "excluded"); len(excluded) > 0 {
+		d.sessions.AddExcluded(req.Session, excluded...)
+	}
+
+	sess := d.sessions.GetSession(req.Session)
+	if len(sess.Excluded) == 0 {
+		return nil
+	}
+
+	return []store.ItemFilter{store.ExcludeIngredients(sess.Excluded...)}
+}
+
+// allergenWarning returns warning text if item contains ingredients
+// customer asked to avoid, empty string otherwise
+func allergenWarning(item *store.Item, excluded []string) string {
+	found := []string{}
+	for _, w := range excluded {
+		if item.Contains(w) {
+			found = append(found, w)
+		}
+	}
+
+	if len(found) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("Обратите внимание: %s содержит %s.",
+		item.Name,
+		joinWords(found, "и"))
+}
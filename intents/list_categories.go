@@ -8,11 +8,17 @@ import (
 
 // ListCategoriesHandler handles list_categories intent
 func (d *Dispatcher) ListCategoriesHandler(req dialogflow.Request) (dialogflow.Response, error) {
+	d.sessions.SetCategory(req.Session, "")
 	sess := d.sessions.GetSession(req.Session)
-	cats := d.cache.GetCategoriesPage(sess.CurrentPage, d.pageSize)
+	cats, err := d.cache.GetSubcategoriesPage("", sess.CurrentPage, d.pageSize)
+	if err != nil {
+		return dialogflow.GenerateResponse(false, "Не удалось получить список категорий"), err
+	}
 
 	if sess.CurrentPage > 0 && len(cats) == 0 {
-		d.sessions.ResetPage(req.Session)
+		if err := d.sessions.ResetPage(req.Session); err != nil {
+			return sessionErrorResponse(err)
+		}
 		return d.ListCategoriesHandler(req)
 	}
 	catNames := make([]string, len(cats))
@@ -32,11 +38,13 @@ func (d *Dispatcher) ListCategoriesHandler(req dialogflow.Request) (dialogflow.R
 	}
 	resp := dialogflow.GenerateResponse(true, text)
 
-	return resp, nil
+	return withCategoriesList(req, resp, "Категории", cats), nil
 }
 
 // ListCategoriesNextHandler handles list_categories_next intent
 func (d *Dispatcher) ListCategoriesNextHandler(req dialogflow.Request) (dialogflow.Response, error) {
-	d.sessions.NextPage(req.Session)
+	if err := d.sessions.NextPage(req.Session); err != nil {
+		return sessionErrorResponse(err)
+	}
 	return d.ListCategoriesHandler(req)
 }
@@ -3,6 +3,7 @@ package intents
 import (
 	"fmt"
 	"mania/dialogflow"
+	"mania/store"
 	"strings"
 )
 
@@ -12,17 +13,31 @@ func (d *Dispatcher) ListCategoryItemsHandler(req dialogflow.Request) (dialogflo
 	if !ok {
 		return dialogflow.GenerateResponse(true, "Не могу распознать категорию меню"), nil
 	}
+	if name, ok := d.findCategoryName(categoryName); ok {
+		categoryName = name
+	}
+	d.sessions.SetCategory(req.Session, categoryName)
+
 	sess := d.sessions.GetSession(req.Session)
-	items, err := d.cache.GetItemsPage(categoryName, sess.CurrentPage, d.pageSize)
+	items, err := d.cache.GetItemsPage(categoryName, sess.CurrentPage, d.pageSize, d.itemFilters(req)...)
+	if err == store.ErrNotOffered {
+		return d.categoryClosedResponse(categoryName), nil
+	}
 	if err != nil {
 		return dialogflow.GenerateResponse(false, "Не удалось получить содержимое категории"), err
 	}
 
 	if len(items) == 0 {
 		if sess.CurrentPage > 0 {
-			d.sessions.ResetPage(req.Session)
+			if err := d.sessions.ResetPage(req.Session); err != nil {
+				return sessionErrorResponse(err)
+			}
 			return d.ListCategoryItemsHandler(req)
 		}
+		// category may only group subcategories
+		if subcats, err := d.cache.GetSubcategoriesPage(categoryName, 0, d.pageSize); err == nil && len(subcats) > 0 {
+			return d.listSubcategories(req, categoryName)
+		}
 		return dialogflow.GenerateResponse(
 			true,
 			fmt.Sprintf("Нет позиций в категории %s", categoryName),
@@ -46,12 +61,14 @@ Cкажите дальше, чтобы вывести ещё.`,
 	}
 	resp := dialogflow.GenerateResponse(true, text)
 
-	return resp, nil
+	return withItemsCarousel(req, resp, items), nil
 }
 
 // ListCategoryItemsNextHandler handles list_category_items_next intent
 func (d *Dispatcher) ListCategoryItemsNextHandler(req dialogflow.Request) (dialogflow.Response, error) {
-	d.sessions.NextPage(req.Session)
+	if err := d.sessions.NextPage(req.Session); err != nil {
+		return sessionErrorResponse(err)
+	}
 	return d.ListCategoryItemsHandler(req)
 }
 
@@ -62,16 +79,25 @@ func (d *Dispatcher) GetItemHandler(req dialogflow.Request) (dialogflow.Response
 		return dialogflow.GenerateResponse(true, "Не могу распознать блюдо"), nil
 	}
 
-	item, err := d.cache.GetItem(itemName)
+	item, candidates, err := d.findItem(itemName)
 	if err != nil {
 		return dialogflow.GenerateResponse(false, "Не удалось получить информацию о блюде"), err
 	}
+	if item == nil {
+		return clarifyItemResponse(candidates), nil
+	}
 
-	text := fmt.Sprintf("%s\n%s\nЦена: %5.2f рублей",
+	text := fmt.Sprintf("%s\n%s\nЦена: %s",
 		item.Description,
 		item.Composition,
-		item.Price)
+		item.Price.Spoken())
+	text += optionsText(item)
+	if !d.cache.IsAvailable(item.ID) {
+		text = fmt.Sprintf("%s\nСейчас нет в наличии.", text)
+	} else if !d.cache.IsOffered(item.ID) {
+		text = fmt.Sprintf("%s\n%s", text, d.notOfferedText(item))
+	}
 	resp := dialogflow.GenerateResponse(true, text)
 
-	return resp, nil
+	return withItemCard(req, resp, item), nil
 }
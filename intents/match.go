@@ -0,0 +1,81 @@
+package intents
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"mania/dialogflow"
+	"mania/store"
+)
+
+const (
+	// maxCandidates is how many close matches we offer to pick from
+	maxCandidates = 3
+	// matchGap is the minimal score advantage of the best fuzzy match
+	// over the next one to pick it without asking the customer
+	matchGap = 0.1
+)
+
+// findItem returns menu item by exact or fuzzy matched name.
+// When several items match equally well, item is nil and
+// candidates are returned so the customer can pick one.
+func (d *Dispatcher) findItem(itemName string) (*store.Item, []*store.Item, error) {
+	item, err := d.cache.GetItem(itemName)
+	if err == nil {
+		return item, nil, nil
+	}
+
+	matches := d.cache.FindItems(itemName, maxCandidates)
+	if len(matches) == 0 {
+		return nil, nil, sql.ErrNoRows
+	}
+
+	if len(matches) == 1 || matches[0].Score-matches[1].Score >= matchGap {
+		return matches[0].Item, nil, nil
+	}
+
+	candidates := []*store.Item{matches[0].Item}
+	for _, m := range matches[1:] {
+		if matches[0].Score-m.Score < matchGap {
+			candidates = append(candidates, m.Item)
+		}
+	}
+
+	return nil, candidates, nil
+}
+
+// findCategoryName returns canonical name of the best fuzzy matched category
+func (d *Dispatcher) findCategoryName(categoryName string) (string, bool) {
+	matches := d.cache.FindCategories(categoryName, 1)
+	if len(matches) == 0 {
+		return "", false
+	}
+
+	return matches[0].Category.Name, true
+}
+
+// joinWords joins words as "a, b или c" with given conjunction
+func joinWords(words []string, conj string) string {
+	if len(words) < 2 {
+		return strings.Join(words, "")
+	}
+
+	return fmt.Sprintf("%s %s %s",
+		strings.Join(words[:len(words)-1], ", "),
+		conj,
+		words[len(words)-1])
+}
+
+// clarifyItemResponse asks the customer to pick one of close matches
+func clarifyItemResponse(candidates []*store.Item) dialogflow.Response {
+	names := make([]string, len(candidates))
+	for i, item := range candidates {
+		names[i] = item.Name
+	}
+
+	return dialogflow.GenerateResponse(
+		true,
+		fmt.Sprintf("Уточните, какое блюдо вы имели в виду: %s?", joinWords(names, "или")),
+	)
+}
@@ -0,0 +1,96 @@
+package intents
+
+import (
+	"fmt"
+	"mania/dialogflow"
+	"mania/store"
+	"strings"
+)
+
+// ListSubcategoriesHandler handles list_subcategories intent
+func (d *Dispatcher) ListSubcategoriesHandler(req dialogflow.Request) (dialogflow.Response, error) {
+	categoryName, ok := req.QueryResult.Parameters["category"].(string)
+	if !ok || categoryName == "" {
+		categoryName = d.sessions.GetSession(req.Session).CurrentCategory
+	}
+	if categoryName == "" {
+		return d.ListCategoriesHandler(req)
+	}
+
+	if name, ok := d.findCategoryName(categoryName); ok {
+		categoryName = name
+	}
+
+	return d.listSubcategories(req, categoryName)
+}
+
+// ListSubcategoriesNextHandler handles list_subcategories_next intent
+func (d *Dispatcher) ListSubcategoriesNextHandler(req dialogflow.Request) (dialogflow.Response, error) {
+	if err := d.sessions.NextPage(req.Session); err != nil {
+		return sessionErrorResponse(err)
+	}
+	return d.ListSubcategoriesHandler(req)
+}
+
+// NavigateBackHandler handles navigate_back intent
+// moving customer one level up in the categories tree
+func (d *Dispatcher) NavigateBackHandler(req dialogflow.Request) (dialogflow.Response, error) {
+	sess := d.sessions.GetSession(req.Session)
+	if sess.CurrentCategory == "" {
+		return d.ListCategoriesHandler(req)
+	}
+
+	parent, err := d.cache.GetParentCategory(sess.CurrentCategory)
+	if err != nil || parent == nil {
+		return d.ListCategoriesHandler(req)
+	}
+
+	return d.listSubcategories(req, parent.Name)
+}
+
+// listSubcategories makes categoryName current and lists its subcategories
+func (d *Dispatcher) listSubcategories(req dialogflow.Request, categoryName string) (dialogflow.Response, error) {
+	d.sessions.SetCategory(req.Session, categoryName)
+	sess := d.sessions.GetSession(req.Session)
+
+	cats, err := d.cache.GetSubcategoriesPage(categoryName, sess.CurrentPage, d.pageSize)
+	if err == store.ErrNotOffered {
+		return d.categoryClosedResponse(categoryName), nil
+	}
+	if err != nil {
+		return dialogflow.GenerateResponse(false, "Не удалось получить подкатегории"), err
+	}
+
+	if len(cats) == 0 {
+		if sess.CurrentPage > 0 {
+			if err := d.sessions.ResetPage(req.Session); err != nil {
+				return sessionErrorResponse(err)
+			}
+			return d.listSubcategories(req, categoryName)
+		}
+		return dialogflow.GenerateResponse(
+			true,
+			fmt.Sprintf("В категории %s нет подкатегорий. Скажите назад, чтобы вернуться.", categoryName),
+		), nil
+	}
+
+	catNames := make([]string, len(cats))
+	for i, cat := range cats {
+		catNames[i] = cat.Name
+	}
+	catList := strings.Join(catNames, ", ")
+	text := fmt.Sprintf(
+		`Подкатегории %s: %s.
+Назовите подкатегорию, чтобы посмотреть товары в ней.
+Скажите дальше, чтобы вывести ещё, или назад, чтобы вернуться.`,
+		categoryName,
+		catList)
+
+	// skip details for next pages
+	if sess.CurrentPage > 0 {
+		text = catList
+	}
+	resp := dialogflow.GenerateResponse(true, text)
+
+	return withCategoriesList(req, resp, categoryName, cats), nil
+}
@@ -0,0 +1,145 @@
+package intents
+
+import (
+	"fmt"
+	"mania/dialogflow"
+	"mania/store"
+	"strings"
+)
+
+// GetItemNutritionHandler handles get_item_nutrition intent
+func (d *Dispatcher) GetItemNutritionHandler(req dialogflow.Request) (dialogflow.Response, error) {
+	itemName, ok := req.QueryResult.Parameters["item"].(string)
+	if !ok {
+		return dialogflow.GenerateResponse(true, "Не могу распознать блюдо"), nil
+	}
+
+	item, candidates, err := d.findItem(itemName)
+	if err != nil {
+		return dialogflow.GenerateResponse(false, "Не удалось получить информацию о блюде"), err
+	}
+	if item == nil {
+		return clarifyItemResponse(candidates), nil
+	}
+
+	n := item.Nutrition
+	if n == nil {
+		return dialogflow.GenerateResponse(
+			true,
+			fmt.Sprintf("К сожалению, у нас нет данных о пищевой ценности блюда %s", item.Name),
+		), nil
+	}
+
+	text := fmt.Sprintf("%s: белки %s, жиры %s, углеводы %s",
+		item.Name,
+		formatDecimal(n.Protein),
+		formatDecimal(n.Fat),
+		formatDecimal(n.Carbs))
+	if n.Kcal > 0 {
+		text = fmt.Sprintf("%s, калорийность %s килокалорий", text, formatDecimal(n.Kcal))
+	}
+
+	return dialogflow.GenerateResponse(true, text), nil
+}
+
+// kcalQueryKey prefixes calorie listings kept in session
+const kcalQueryKey = "kcal:"
+
+// ListItemsByKcalHandler handles list_items_by_calories intent
+// listing items not exceeding given calories,
+// in a category or across the whole menu
+func (d *Dispatcher) ListItemsByKcalHandler(req dialogflow.Request) (dialogflow.Response, error) {
+	kcal, categoryName, ok := d.kcalParams(req)
+	if !ok {
+		return dialogflow.GenerateResponse(true, "Назовите максимальную калорийность блюда"), nil
+	}
+	// another limit or category starts from the first page
+	d.sessions.SetQuery(req.Session, fmt.Sprintf("%s%s:%s", kcalQueryKey, formatDecimal(kcal), categoryName))
+
+	return d.listItemsByKcal(req, kcal, categoryName)
+}
+
+// kcalParams returns calories limit and canonical name of category
+// it is asked in, empty for the whole menu
+func (d *Dispatcher) kcalParams(req dialogflow.Request) (float64, string, bool) {
+	kcal, ok := numberParam(req, "number")
+	if !ok || kcal <= 0 {
+		return 0, "", false
+	}
+
+	categoryName, _ := req.QueryResult.Parameters["category"].(string)
+	if categoryName != "" {
+		if name, ok := d.findCategoryName(categoryName); ok {
+			categoryName = name
+		}
+	}
+	return kcal, categoryName, true
+}
+
+// listItemsByKcal lists current page of items not exceeding kcal
+func (d *Dispatcher) listItemsByKcal(req dialogflow.Request, kcal float64, categoryName string) (dialogflow.Response, error) {
+	sess := d.sessions.GetSession(req.Session)
+	filters := append(d.itemFilters(req), store.MaxKcal(kcal))
+
+	var (
+		items []*store.Item
+		err   error
+	)
+	if categoryName != "" {
+		items, err = d.cache.GetItemsPage(categoryName, sess.CurrentPage, d.pageSize, filters...)
+		if err == store.ErrNotOffered {
+			return d.categoryClosedResponse(categoryName), nil
+		}
+		if err != nil {
+			return dialogflow.GenerateResponse(false, "Не удалось получить содержимое категории"), err
+		}
+	} else {
+		items = d.cache.GetAllItemsPage(sess.CurrentPage, d.pageSize, filters...)
+	}
+
+	if len(items) == 0 {
+		if sess.CurrentPage > 0 {
+			if err := d.sessions.ResetPage(req.Session); err != nil {
+				return sessionErrorResponse(err)
+			}
+			return d.listItemsByKcal(req, kcal, categoryName)
+		}
+		return dialogflow.GenerateResponse(
+			true,
+			fmt.Sprintf("Нет блюд калорийностью до %s килокалорий", formatDecimal(kcal)),
+		), nil
+	}
+
+	itemNames := make([]string, len(items))
+	for i, item := range items {
+		itemNames[i] = item.Name
+	}
+	itemList := strings.Join(itemNames, ", ")
+	text := fmt.Sprintf(
+		`Блюда до %s килокалорий: %s.
+Назовите продукт, чтобы узнать о нём подробней или добавить в корзину.
+Cкажите дальше, чтобы вывести ещё.`,
+		formatDecimal(kcal),
+		itemList)
+
+	// skip details for next pages
+	if sess.CurrentPage > 0 {
+		text = itemList
+	}
+	resp := dialogflow.GenerateResponse(true, text)
+
+	return withItemsCarousel(req, resp, items), nil
+}
+
+// ListItemsByKcalNextHandler handles list_items_by_calories_next intent
+func (d *Dispatcher) ListItemsByKcalNextHandler(req dialogflow.Request) (dialogflow.Response, error) {
+	kcal, categoryName, ok := d.kcalParams(req)
+	if !ok {
+		return dialogflow.GenerateResponse(true, "Назовите максимальную калорийность блюда"), nil
+	}
+
+	if err := d.sessions.NextPage(req.Session); err != nil {
+		return sessionErrorResponse(err)
+	}
+	return d.listItemsByKcal(req, kcal, categoryName)
+}
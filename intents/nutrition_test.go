@@ -0,0 +1,41 @@
+package intents
+
+import (
+	"strings"
+	"testing"
+
+	"mania/store"
+)
+
+func TestListItemsByKcalPaging(t *testing.T) {
+	st := testStore()
+	for i, kcal := range []float64{150, 265, 312, 145, 45} {
+		st.items[i].Nutrition = &store.Nutrition{Kcal: kcal}
+	}
+	d, _ := newTestDispatcher(t, st)
+
+	up300 := testRequest(map[string]interface{}{"number": float64(300)})
+	text := call(t, d.ListItemsByKcalHandler, up300)
+	if !strings.HasPrefix(text, "Блюда до 300 килокалорий: Блинчики, Блины с творогом.") {
+		t.Errorf("unexpected first page %q", text)
+	}
+	if text := call(t, d.ListItemsByKcalNextHandler, up300); text != "Борщ, Морс" {
+		t.Errorf("unexpected second page %q", text)
+	}
+
+	// another limit starts from the first page
+	text = call(t, d.ListItemsByKcalHandler, testRequest(map[string]interface{}{"number": float64(200)}))
+	if !strings.HasPrefix(text, "Блюда до 200 килокалорий: Блинчики, Борщ.") {
+		t.Errorf("expected new limit to start from the first page, got %q", text)
+	}
+
+	// so does another category
+	d, _ = newTestDispatcher(t, st)
+	up400 := testRequest(map[string]interface{}{"number": float64(400)})
+	call(t, d.ListItemsByKcalHandler, up400)
+	call(t, d.ListItemsByKcalNextHandler, up400)
+	text = call(t, d.ListItemsByKcalHandler, testRequest(map[string]interface{}{"number": float64(400), "category": "Блины"}))
+	if !strings.HasPrefix(text, "Блюда до 400 килокалорий: Блинчики, Блины с творогом.") {
+		t.Errorf("expected new category to start from the first page, got %q", text)
+	}
+}
@@ -0,0 +1,65 @@
+package intents
+
+import (
+	"fmt"
+	"mania/dialogflow"
+	"mania/store"
+)
+
+// SelectOptionHandler handles select_option intent filling
+// options of the position waiting in the session
+func (d *Dispatcher) SelectOptionHandler(req dialogflow.Request) (dialogflow.Response, error) {
+	sess := d.sessions.GetSession(req.Session)
+	if sess.Pending == nil {
+		return dialogflow.GenerateResponse(true, "Назовите блюдо, которое хотите добавить в корзину"), nil
+	}
+	pos := *sess.Pending
+
+	names := stringsParam(req, "option")
+	if len(names) == 0 {
+		return dialogflow.GenerateResponse(true, "Не могу распознать вариант"), nil
+	}
+
+	for _, name := range names {
+		group, option, ok := pos.Item.FindOption(name)
+		if !ok {
+			missing := pos.Item.MissingGroups(pos.Options)
+			if len(missing) == 0 {
+				missing = pos.Item.OptionGroups
+			}
+			return dialogflow.GenerateResponse(
+				true,
+				fmt.Sprintf("Нет такого варианта. %s", askOptionText(&pos.Item, missing[0])),
+			), nil
+		}
+		pos.Options = pos.Item.SelectOption(pos.Options, group, option)
+	}
+
+	return d.addPosition(req, pos), nil
+}
+
+// askOptionText asks customer to choose option of the group
+func askOptionText(item *store.Item, group store.OptionGroup) string {
+	names := make([]string, len(group.Options))
+	for i, o := range group.Options {
+		names[i] = o.Name
+		if o.PriceDelta > 0 {
+			names[i] = fmt.Sprintf("%s за %s", o.Name, o.PriceDelta.Spoken())
+		}
+	}
+
+	return fmt.Sprintf("%s, %s: %s?", item.Name, group.Name, joinWords(names, "или"))
+}
+
+// optionsText describes item option groups for item details
+func optionsText(item *store.Item) string {
+	text := ""
+	for _, g := range item.OptionGroups {
+		names := make([]string, len(g.Options))
+		for i, o := range g.Options {
+			names[i] = o.Name
+		}
+		text = fmt.Sprintf("%s\n%s: %s.", text, g.Name, joinWords(names, "или"))
+	}
+	return text
+}
@@ -0,0 +1,53 @@
+package intents
+
+import (
+	"strings"
+	"testing"
+
+	"mania/money"
+)
+
+func TestSelectOptionSlotFilling(t *testing.T) {
+	d, _ := newTestDispatcher(t, testStore())
+
+	// topping has the same option ID as the required size,
+	// size is still asked for
+	text := call(t, d.AddToCartHandler, testRequest(map[string]interface{}{
+		"item":   "Блинчики",
+		"option": []interface{}{"сгущенка"},
+	}))
+	if text != "Блинчики, Размер порции: обычная или большая за пятьдесят рублей?" {
+		t.Errorf("expected size to be asked, got %q", text)
+	}
+	if sess := d.sessions.GetSession(testSession); sess.Pending == nil || len(sess.Cart) != 0 {
+		t.Fatalf("expected position to wait for options, got %+v", sess)
+	}
+
+	text = call(t, d.SelectOptionHandler, testRequest(map[string]interface{}{"option": "пепперони"}))
+	if !strings.HasPrefix(text, "Нет такого варианта. Блинчики, Размер порции:") {
+		t.Errorf("expected size to be asked again, got %q", text)
+	}
+
+	text = call(t, d.SelectOptionHandler, testRequest(map[string]interface{}{"option": "большая"}))
+	if !strings.HasPrefix(text, "В корзине 1 товаров") {
+		t.Errorf("expected position to be added, got %q", text)
+	}
+
+	sess := d.sessions.GetSession(testSession)
+	if sess.Pending != nil || len(sess.Cart) != 1 {
+		t.Fatalf("expected position in the cart, got %+v", sess)
+	}
+	for _, pos := range sess.Cart {
+		if pos.Name() != "Блинчики (сгущенка, большая)" {
+			t.Errorf("unexpected position %q", pos.Name())
+		}
+		if pos.Price != money.FromRubles(230) {
+			t.Errorf("unexpected position price %s", pos.Price)
+		}
+	}
+
+	text = call(t, d.SelectOptionHandler, testRequest(map[string]interface{}{"option": "большая"}))
+	if text != "Назовите блюдо, которое хотите добавить в корзину" {
+		t.Errorf("expected no pending position, got %q", text)
+	}
+}
@@ -0,0 +1,47 @@
+package intents
+
+import (
+	"strconv"
+	"strings"
+
+	"mania/dialogflow"
+)
+
+// numberParam returns numeric request parameter.
+// Dialogflow sends @sys.number as JSON number, but
+// it can also come as a string from some agents.
+func numberParam(req dialogflow.Request, name string) (float64, bool) {
+	switch v := req.QueryResult.Parameters[name].(type) {
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(strings.Replace(v, ",", ".", 1), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// stringsParam returns list request parameter.
+// Single value parameters are returned as one element list.
+func stringsParam(req dialogflow.Request, name string) []string {
+	switch v := req.QueryResult.Parameters[name].(type) {
+	case string:
+		if v != "" {
+			return []string{v}
+		}
+	case []interface{}:
+		res := make([]string, 0, len(v))
+		for _, e := range v {
+			if s, ok := e.(string); ok && s != "" {
+				res = append(res, s)
+			}
+		}
+		return res
+	}
+	return nil
+}
+
+// formatDecimal formats number the russian way: "20,5"
+func formatDecimal(f float64) string {
+	return strings.Replace(strconv.FormatFloat(f, 'f', -1, 64), ".", ",", 1)
+}
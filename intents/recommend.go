@@ -0,0 +1,62 @@
+package intents
+
+import (
+	"fmt"
+
+	"mania/dialogflow"
+)
+
+// maxBasket limits how many category items recommendations are made for
+const maxBasket = 100
+
+// RecommendHandler handles recommend intent: "что посоветуете",
+// "что взять к блинам". Recommendations are made for the named item
+// or category, or for the cart if none is named.
+func (d *Dispatcher) RecommendHandler(req dialogflow.Request) (dialogflow.Response, error) {
+	basket, err := d.recommendBasket(req)
+	if err != nil {
+		return dialogflow.GenerateResponse(false, "Не удалось получить информацию о блюде"), err
+	}
+
+	items := d.cache.Recommend(basket, maxCandidates)
+	if len(items) == 0 {
+		return dialogflow.GenerateResponse(true, "К сожалению, сейчас мне нечего посоветовать"), nil
+	}
+
+	names := make([]string, len(items))
+	for i, item := range items {
+		names[i] = item.Name
+	}
+	text := fmt.Sprintf("Рекомендую попробовать %s.", joinWords(names, "или"))
+
+	return withItemsCarousel(req, dialogflow.GenerateResponse(true, text), items), nil
+}
+
+// recommendBasket returns item IDs recommendations are made for:
+// the named item, items of the named category or the cart
+func (d *Dispatcher) recommendBasket(req dialogflow.Request) ([]int, error) {
+	if itemName, _ := req.QueryResult.Parameters["item"].(string); itemName != "" {
+		item, candidates, err := d.findItem(itemName)
+		if err != nil {
+			return nil, err
+		}
+		if item == nil {
+			item = candidates[0]
+		}
+		return []int{item.ID}, nil
+	}
+
+	if categoryName, _ := req.QueryResult.Parameters["category"].(string); categoryName != "" {
+		if name, ok := d.findCategoryName(categoryName); ok {
+			// closed category gives no items, popular ones are recommended then
+			items, _ := d.cache.GetItemsPage(name, 0, maxBasket)
+			ids := make([]int, len(items))
+			for i, item := range items {
+				ids[i] = item.ID
+			}
+			return ids, nil
+		}
+	}
+
+	return d.sessions.GetSession(req.Session).ItemIDs(), nil
+}
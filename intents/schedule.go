@@ -0,0 +1,66 @@
+package intents
+
+import (
+	"fmt"
+	"time"
+
+	"mania/dialogflow"
+	"mania/store"
+)
+
+// weekdaysAccusative are day names as in "в субботу"
+var weekdaysAccusative = map[time.Weekday]string{
+	time.Sunday:    "в воскресенье",
+	time.Monday:    "в понедельник",
+	time.Tuesday:   "во вторник",
+	time.Wednesday: "в среду",
+	time.Thursday:  "в четверг",
+	time.Friday:    "в пятницу",
+	time.Saturday:  "в субботу",
+}
+
+// whenText tells when at comes relative to now:
+// "сегодня с 12:00", "завтра с 7:00", "в субботу с 10:00"
+func whenText(now, at time.Time) string {
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, now.Location())
+
+	clock := fmt.Sprintf("с %d:%02d", at.Hour(), at.Minute())
+	switch {
+	case day.Equal(today):
+		return "сегодня " + clock
+	case day.Equal(today.AddDate(0, 0, 1)):
+		return "завтра " + clock
+	default:
+		return weekdaysAccusative[at.Weekday()] + " " + clock
+	}
+}
+
+// notOfferedText tells when item will be offered
+func (d *Dispatcher) notOfferedText(item *store.Item) string {
+	at, ok := d.cache.NextOffered(item.ID)
+	if !ok {
+		return fmt.Sprintf("%s сейчас не подаём.", item.Name)
+	}
+	return fmt.Sprintf("%s можно будет заказать %s.", item.Name, whenText(d.cache.Now(), at))
+}
+
+// notOfferedResponse explains that item is not served at the moment
+// and tells when it will be
+func (d *Dispatcher) notOfferedResponse(item *store.Item) dialogflow.Response {
+	return dialogflow.GenerateResponse(true, d.notOfferedText(item))
+}
+
+// categoryClosedResponse tells when category will be offered
+func (d *Dispatcher) categoryClosedResponse(categoryName string) dialogflow.Response {
+	at, ok := d.cache.NextCategoryOpen(categoryName)
+	if !ok {
+		return dialogflow.GenerateResponse(true, fmt.Sprintf("Категория %s сейчас недоступна.", categoryName))
+	}
+	return dialogflow.GenerateResponse(true, fmt.Sprintf(
+		"Категория %s будет доступна %s.",
+		categoryName,
+		whenText(d.cache.Now(), at),
+	))
+}
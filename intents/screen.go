@@ -0,0 +1,112 @@
+package intents
+
+import (
+	"strings"
+
+	"mania/dialogflow"
+	"mania/store"
+)
+
+// suggestion chips
+const (
+	chipNext      = "Дальше"
+	chipAddToCart = "В корзину"
+	chipCheckout  = "Оформить"
+	// maxChipLen is the longest chip title Actions on Google accept
+	maxChipLen = 25
+)
+
+// list and carousel option keys tell picked items from categories,
+// OptionSelectedHandler reads them
+const (
+	itemOptionPrefix     = "item:"
+	categoryOptionPrefix = "category:"
+)
+
+// itemImage returns item image or nil if item has none
+func itemImage(item *store.Item) *dialogflow.Image {
+	if item.Image == "" {
+		return nil
+	}
+	return &dialogflow.Image{URL: item.Image, AccessibilityText: item.Name}
+}
+
+// withItemsCarousel shows items as a carousel on screen devices,
+// picking one asks about the item
+func withItemsCarousel(req dialogflow.Request, resp dialogflow.Response, items []*store.Item) dialogflow.Response {
+	if !req.HasScreen() {
+		return resp
+	}
+
+	options := make([]dialogflow.OptionItem, len(items))
+	for i, item := range items {
+		options[i] = dialogflow.OptionItem{
+			OptionInfo:  dialogflow.OptionInfo{Key: itemOptionPrefix + item.Name},
+			Title:       item.Name,
+			Description: item.Price.String(),
+			Image:       itemImage(item),
+		}
+	}
+
+	return resp.WithCarousel(options).WithSuggestions(chipNext, chipCheckout)
+}
+
+// withCategoriesList shows categories as a list on screen devices,
+// picking one lists its items
+func withCategoriesList(req dialogflow.Request, resp dialogflow.Response, title string, cats []*store.Category) dialogflow.Response {
+	if !req.HasScreen() {
+		return resp
+	}
+
+	options := make([]dialogflow.OptionItem, len(cats))
+	for i, cat := range cats {
+		options[i] = dialogflow.OptionItem{
+			OptionInfo: dialogflow.OptionInfo{Key: categoryOptionPrefix + cat.Name},
+			Title:      cat.Name,
+		}
+	}
+
+	return resp.WithList(title, options).WithSuggestions(chipNext)
+}
+
+// withItemCard shows item details card on screen devices
+func withItemCard(req dialogflow.Request, resp dialogflow.Response, item *store.Item) dialogflow.Response {
+	if !req.HasScreen() {
+		return resp
+	}
+
+	card := dialogflow.BasicCard{
+		Title:         item.Name,
+		Subtitle:      item.Price.String(),
+		FormattedText: item.Composition,
+		Image:         itemImage(item),
+	}
+	if card.Image != nil {
+		card.ImageDisplayOptions = "CROPPED"
+	}
+
+	return resp.WithCard(card).WithSuggestions(chipAddToCart, chipCheckout)
+}
+
+// withSuggestions adds suggestion chips on screen devices
+func withSuggestions(req dialogflow.Request, resp dialogflow.Response, titles ...string) dialogflow.Response {
+	if !req.HasScreen() {
+		return resp
+	}
+	return resp.WithSuggestions(titles...)
+}
+
+// OptionSelectedHandler handles actions_intent_OPTION intent sent
+// when customer picks a list or carousel item on a screen device
+func (d *Dispatcher) OptionSelectedHandler(req dialogflow.Request) (dialogflow.Response, error) {
+	key, _ := req.OptionKey()
+	switch {
+	case strings.HasPrefix(key, itemOptionPrefix):
+		req.QueryResult.Parameters = map[string]interface{}{"item": strings.TrimPrefix(key, itemOptionPrefix)}
+		return d.GetItemHandler(req)
+	case strings.HasPrefix(key, categoryOptionPrefix):
+		req.QueryResult.Parameters = map[string]interface{}{"category": strings.TrimPrefix(key, categoryOptionPrefix)}
+		return d.ListCategoryItemsHandler(req)
+	}
+	return dialogflow.GenerateResponse(true, "Не могу распознать, что вы выбрали"), nil
+}
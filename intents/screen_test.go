@@ -0,0 +1,55 @@
+package intents
+
+import (
+	"strings"
+	"testing"
+
+	"mania/dialogflow"
+)
+
+// withOption returns request of customer picking option key
+// of a list or carousel
+func withOption(key string) dialogflow.Request {
+	req := withScreen(testRequest(nil))
+	req.OriginalRequest.Payload.Inputs = []dialogflow.OriginalRequestInput{{
+		Intent:    "actions.intent.OPTION",
+		Arguments: []dialogflow.Argument{{Name: dialogflow.OptionArgument, TextValue: key}},
+	}}
+	return req
+}
+
+func TestOptionSelected(t *testing.T) {
+	d, _ := newTestDispatcher(t, testStore())
+
+	resp, err := d.ListCategoryItemsHandler(withScreen(testRequest(map[string]interface{}{"category": "Блины"})))
+	if err != nil {
+		t.Fatalf("unexpected error in ListCategoryItemsHandler: %v", err)
+	}
+	intent := resp.Payload.Google.SystemIntent
+	if intent == nil || intent.Data.CarouselSelect == nil || len(intent.Data.CarouselSelect.Items) != 2 {
+		t.Fatalf("expected carousel of two items, got %+v", intent)
+	}
+
+	// picked carousel item is told about with its card
+	key := intent.Data.CarouselSelect.Items[1].OptionInfo.Key
+	resp, err = d.OptionSelectedHandler(withOption(key))
+	if err != nil {
+		t.Fatalf("unexpected error in OptionSelectedHandler: %v", err)
+	}
+	if !strings.Contains(resp.Text(), "Цена: сто девяносто рублей") {
+		t.Errorf("expected picked item details, got %q", resp.Text())
+	}
+	items := resp.Payload.Google.RichResponse.Items
+	if len(items) != 2 || items[1].BasicCard == nil || items[1].BasicCard.Title != "Блины с творогом" {
+		t.Errorf("expected picked item card, got %+v", items)
+	}
+
+	// picked list category lists its items
+	if text := call(t, d.OptionSelectedHandler, withOption(categoryOptionPrefix+"Супы")); !strings.HasPrefix(text, "Борщ.") {
+		t.Errorf("expected category items, got %q", text)
+	}
+
+	if text := call(t, d.OptionSelectedHandler, withOption("")); text != "Не могу распознать, что вы выбрали" {
+		t.Errorf("expected unknown selection, got %q", text)
+	}
+}
@@ -0,0 +1,82 @@
+package intents
+
+import (
+	"fmt"
+	"mania/dialogflow"
+	"mania/store"
+	"strings"
+)
+
+// searchQueryKey prefixes search queries kept in session
+const searchQueryKey = "search:"
+
+// SearchItemsHandler handles search_items intent
+func (d *Dispatcher) SearchItemsHandler(req dialogflow.Request) (dialogflow.Response, error) {
+	query, ok := req.QueryResult.Parameters["query"].(string)
+	if !ok || strings.TrimSpace(query) == "" {
+		return dialogflow.GenerateResponse(true, "Что вы хотите найти?"), nil
+	}
+
+	// "без" words are remembered to warn about them in cart as well
+	if _, excluded := store.SplitExclusions(query); len(excluded) > 0 {
+		d.sessions.AddExcluded(req.Session, excluded...)
+	}
+	// new query starts from its first page
+	d.sessions.SetQuery(req.Session, searchQueryKey+query)
+
+	return d.searchItems(req, query)
+}
+
+// searchItems lists current page of items found by query
+func (d *Dispatcher) searchItems(req dialogflow.Request, query string) (dialogflow.Response, error) {
+	sess := d.sessions.GetSession(req.Session)
+	items := d.cache.SearchItems(query, sess.CurrentPage, d.pageSize, d.itemFilters(req)...)
+
+	if len(items) == 0 {
+		if sess.CurrentPage > 0 {
+			if err := d.sessions.ResetPage(req.Session); err != nil {
+				return sessionErrorResponse(err)
+			}
+			return d.searchItems(req, query)
+		}
+		return dialogflow.GenerateResponse(
+			true,
+			fmt.Sprintf("К сожалению, ничего не нашлось по запросу %s", query),
+		), nil
+	}
+
+	itemNames := make([]string, len(items))
+	for i, item := range items {
+		itemNames[i] = item.Name
+	}
+	itemList := strings.Join(itemNames, ", ")
+	text := fmt.Sprintf(
+		`Вот что нашлось: %s.
+Назовите продукт, чтобы узнать о нём подробней или добавить в корзину.
+Cкажите дальше, чтобы вывести ещё.`,
+		itemList)
+
+	// skip details for next pages
+	if sess.CurrentPage > 0 {
+		text = itemList
+	}
+	resp := dialogflow.GenerateResponse(true, text)
+
+	return withItemsCarousel(req, resp, items), nil
+}
+
+// SearchItemsNextHandler handles search_items_next intent
+func (d *Dispatcher) SearchItemsNextHandler(req dialogflow.Request) (dialogflow.Response, error) {
+	query, ok := req.QueryResult.Parameters["query"].(string)
+	if !ok || strings.TrimSpace(query) == "" {
+		query = strings.TrimPrefix(d.sessions.GetSession(req.Session).CurrentQuery, searchQueryKey)
+	}
+	if strings.TrimSpace(query) == "" {
+		return dialogflow.GenerateResponse(true, "Что вы хотите найти?"), nil
+	}
+
+	if err := d.sessions.NextPage(req.Session); err != nil {
+		return sessionErrorResponse(err)
+	}
+	return d.searchItems(req, query)
+}
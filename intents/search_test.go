@@ -0,0 +1,79 @@
+package intents
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"mania/store"
+)
+
+func TestSearchItemsPaging(t *testing.T) {
+	d, _ := newTestDispatcher(t, testStore())
+
+	// customer was on the second page of a category before searching
+	d.sessions.SetCategory(testSession, "Блины")
+	d.sessions.NextPage(testSession)
+
+	query := testRequest(map[string]interface{}{"query": "блин"})
+	text := call(t, d.SearchItemsHandler, query)
+	if !strings.HasPrefix(text, "Вот что нашлось: Блинчики, Блины с творогом.") {
+		t.Errorf("expected new search to start from the first page, got %q", text)
+	}
+
+	if text := call(t, d.SearchItemsNextHandler, query); text != "Блины с семгой" {
+		t.Errorf("expected the second page, got %q", text)
+	}
+	// the same query asked again keeps its page
+	if text := call(t, d.SearchItemsHandler, query); text != "Блины с семгой" {
+		t.Errorf("expected the same query to stay on the second page, got %q", text)
+	}
+
+	text = call(t, d.SearchItemsHandler, testRequest(map[string]interface{}{"query": "борщ"}))
+	if !strings.HasPrefix(text, "Вот что нашлось: Борщ.") {
+		t.Errorf("expected another query to start from the first page, got %q", text)
+	}
+
+	// next page past the end starts over
+	d.sessions.NextPage(testSession)
+	text = call(t, d.SearchItemsNextHandler, testRequest(map[string]interface{}{"query": "борщ"}))
+	if !strings.HasPrefix(text, "Вот что нашлось: Борщ.") {
+		t.Errorf("expected paging past the end to start over, got %q", text)
+	}
+}
+
+// failingSessions fails session writes once fail is set
+type failingSessions struct {
+	*store.MemorySessionStore
+	fail bool
+}
+
+func (st *failingSessions) Put(ctx context.Context, id string, s store.Session, version int64, expiresAt time.Time) error {
+	if st.fail {
+		return errors.New("sessions unavailable")
+	}
+	return st.MemorySessionStore.Put(ctx, id, s, version, expiresAt)
+}
+
+func TestSearchItemsSessionFailure(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	sessions := &failingSessions{MemorySessionStore: store.NewMemorySessionStore()}
+	d := NewDispatcher(ctx, testStore(), &sentOrders{}, WithPageSize(2), WithSessionStore(sessions))
+
+	query := testRequest(map[string]interface{}{"query": "борщ"})
+	call(t, d.SearchItemsHandler, query)
+	d.sessions.NextPage(testSession)
+	sessions.fail = true
+
+	// page past the end can't be reset, the error is returned
+	// instead of listing the same page again and again
+	if _, err := d.SearchItemsHandler(query); err == nil {
+		t.Error("expected session error from SearchItemsHandler")
+	}
+	if _, err := d.SearchItemsNextHandler(query); err == nil {
+		t.Error("expected session error from SearchItemsNextHandler")
+	}
+}
@@ -10,11 +10,15 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
 	"syscall"
 	"time"
 
+	"mania/admin"
 	"mania/intents"
 	"mania/store"
+	"mania/tenant"
 
 	"mania/dialogflow"
 )
@@ -41,8 +45,9 @@ func logRequest(r *http.Request) {
 		string(body))
 }
 
-// MakeWebhookHandler returns handler function with dispatcher
-func MakeWebhookHandler(d *intents.Dispatcher) http.HandlerFunc {
+// MakeWebhookHandler returns handler function dispatching requests
+// to the tenant named by the webhook path or the agent project
+func MakeWebhookHandler(reg *tenant.Registry) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		logRequest(r)
 
@@ -58,7 +63,14 @@ func MakeWebhookHandler(d *intents.Dispatcher) http.HandlerFunc {
 		w.Header().Add("Access-Control-Allow-Origin", "*")
 		w.Header().Add("Content-Type", "application/json; charset=utf-8")
 
-		h, err := d.GetHandler(req.QueryResult.Intent.DisplayName) //???
+		t, err := reg.Resolve(r.URL.Path, req)
+		if err != nil {
+			log.Printf("failed to resolve tenant: %v", err)
+			writeError(w)
+			return
+		}
+
+		h, err := t.Dispatcher.GetHandler(req.QueryResult.Intent.DisplayName) //???
 		if err != nil {
 			log.Printf("failed to get handler: %v", err)
 			writeError(w)
@@ -78,16 +90,203 @@ func MakeWebhookHandler(d *intents.Dispatcher) http.HandlerFunc {
 	}
 }
 
-func initCache(ctx context.Context) *store.Cache {
+// tenantSettings returns tenants from TENANTS_FILE, or the default
+// tenant reading catalog from CATALOG_FILE or Firestore root collections
+// with fields renamed by CATALOG_SCHEMA like "price=cost,name=title"
+func tenantSettings() ([]tenant.Settings, error) {
+	if path := os.Getenv("TENANTS_FILE"); path != "" {
+		return tenant.LoadSettings(path)
+	}
+
+	schema, err := store.ParseSchema(os.Getenv("CATALOG_SCHEMA"))
+	if err != nil {
+		return nil, fmt.Errorf("bad CATALOG_SCHEMA: %w", err)
+	}
+
+	return []tenant.Settings{{
+		ID:          tenant.DefaultID,
+		CatalogFile: os.Getenv("CATALOG_FILE"),
+		TimeZone:    os.Getenv("TIME_ZONE"),
+		Schema:      schema,
+	}}, nil
+}
+
+// sources creates catalog sources and session stores, tenants
+// share one Firestore client and one sessions file
+type sources struct {
+	db   *store.DB
+	bolt *store.BoltSessionStore
+}
+
+// firestore returns tenant DB, default tenant uses root collections
+func (s *sources) firestore(ctx context.Context, ts tenant.Settings) (*store.DB, error) {
+	if s.db == nil {
+		db, err := store.New(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.db = db
+	}
+
+	if ts.ID == tenant.DefaultID {
+		return s.db, nil
+	}
+	return s.db.ForTenant(ts.ID), nil
+}
+
+// get returns tenant catalog source: local file if set, Firestore
+// otherwise
+func (s *sources) get(ctx context.Context, ts tenant.Settings) (store.Source, error) {
+	if ts.CatalogFile != "" {
+		return store.NewFileSource(ts.CatalogFile).WithSchema(ts.Schema), nil
+	}
+
+	db, err := s.firestore(ctx, ts)
+	if err != nil {
+		return nil, err
+	}
+	return db.WithSchema(ts.Schema), nil
+}
+
+// sessionStore returns tenant session store configured by environment:
+// SESSION_STORE is "memory" (default), "firestore" sharing sessions
+// between instances or "bolt" keeping them in SESSION_FILE
+// ("sessions.db" if empty) across restarts
+func (s *sources) sessionStore(ctx context.Context, ts tenant.Settings) (store.SessionStore, error) {
+	switch kind := os.Getenv("SESSION_STORE"); kind {
+	case "", "memory":
+		return store.NewMemorySessionStore(), nil
+	case "firestore":
+		db, err := s.firestore(ctx, ts)
+		if err != nil {
+			return nil, err
+		}
+		return store.NewFirestoreSessionStore(db), nil
+	case "bolt":
+		if s.bolt == nil {
+			path := os.Getenv("SESSION_FILE")
+			if path == "" {
+				path = "sessions.db"
+			}
+			st, err := store.OpenBoltSessionStore(path)
+			if err != nil {
+				return nil, err
+			}
+			s.bolt = st
+		}
+		if ts.ID == tenant.DefaultID {
+			return s.bolt, nil
+		}
+		return s.bolt.ForTenant(ts.ID), nil
+	default:
+		return nil, fmt.Errorf("unknown SESSION_STORE %q, expected memory, firestore or bolt", kind)
+	}
+}
+
+// dispatcherOptions returns tenant dispatcher options from environment:
+// session store and SESSION_TTL idle sessions are kept for
+func (s *sources) dispatcherOptions(ctx context.Context, ts tenant.Settings) []intents.DispatcherOption {
+	st, err := s.sessionStore(ctx, ts)
+	if err != nil {
+		log.Fatalf("tenant %s: failed to open session store: %v", ts.ID, err)
+	}
+
+	opts := []intents.DispatcherOption{intents.WithSessionStore(st)}
+	if v := os.Getenv("SESSION_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("bad SESSION_TTL value %q: %v", v, err)
+		}
+		opts = append(opts, intents.WithSessionTTL(d))
+	}
+	return opts
+}
+
+// cacheOptions returns tenant cache options from settings and environment:
+// SNAPSHOT_DIR enables catalog snapshots for warm start, each tenant
+// keeps them in its own subdirectory,
+// MAX_VALIDATION_ERRORS limits errors in catalog accepted on refresh,
+// ORDERS_DIR keeps tenant order logs for popularity ranking, orders are
+// kept in memory if empty, POPULARITY_HALF_LIFE sets popularity decay
+func cacheOptions(ts tenant.Settings) []store.CacheOption {
+	loc, err := ts.Location()
+	if err != nil {
+		log.Fatalf("tenant %s: %v", ts.ID, err)
+	}
+
+	opts := []store.CacheOption{store.WithLocation(loc)}
+	if dir := os.Getenv("SNAPSHOT_DIR"); dir != "" {
+		opts = append(opts, store.WithSnapshotDir(filepath.Join(dir, ts.ID)))
+	}
+	if s := os.Getenv("MAX_VALIDATION_ERRORS"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			log.Fatalf("bad MAX_VALIDATION_ERRORS value %q: %v", s, err)
+		}
+		opts = append(opts, store.WithMaxValidationErrors(n))
+	}
+	if dir := os.Getenv("ORDERS_DIR"); dir != "" {
+		opts = append(opts, store.WithOrderLog(store.NewFileOrderLog(filepath.Join(dir, ts.ID+".jsonl"))))
+	} else {
+		opts = append(opts, store.WithOrderLog(store.NewMemoryOrderLog()))
+	}
+	if s := os.Getenv("POPULARITY_HALF_LIFE"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			log.Fatalf("bad POPULARITY_HALF_LIFE value %q: %v", s, err)
+		}
+		opts = append(opts, store.WithHalfLife(d))
+	}
+
+	return opts
+}
+
+// healthResponse is a health check response body
+type healthResponse struct {
+	Status   string                      `json:"status"`
+	Catalogs map[string]store.CacheStats `json:"catalogs"`
+}
+
+// MakeHealthHandler returns handler reporting tenants catalogs state.
+// Bot running on a stale snapshot still answers, so status
+// code stays 200 and status field tells "stale".
+func MakeHealthHandler(reg *tenant.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := healthResponse{Status: "ok", Catalogs: map[string]store.CacheStats{}}
+		for _, id := range reg.IDs() {
+			t, _ := reg.Get(id)
+			st := t.Cache.Stats()
+			if st.Stale {
+				resp.Status = "stale"
+			}
+			resp.Catalogs[id] = st
+		}
+
+		w.Header().Add("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(&resp); err != nil {
+			log.Printf("failed to write health response to %s: %v", r.RemoteAddr, err)
+		}
+	}
+}
+
+func initCache(ctx context.Context, srcs *sources, ts tenant.Settings) *store.Cache {
 	var (
 		s   *store.Cache
+		src store.Source
 		err error
 	)
 
 	for i := 0; i < maxTries; i++ {
-		s, err = store.NewCache(ctx)
+		src, err = srcs.get(ctx, ts)
 		if err != nil {
-			log.Printf("failed to create Cache instance: %v", err)
+			log.Printf("failed to create catalog source: %v", err)
+			time.Sleep(5 * time.Second)
+			continue
+		}
+
+		s, err = store.NewCache(ctx, src, cacheOptions(ts)...)
+		if err != nil {
+			log.Printf("failed to create Cache instance for %s: %v", ts.ID, err)
 			time.Sleep(5 * time.Second)
 			continue
 		}
@@ -95,23 +294,62 @@ func initCache(ctx context.Context) *store.Cache {
 	}
 
 	if err != nil {
-		log.Fatalf("failed to initialize Cache after %d tries", maxTries)
+		log.Fatalf("failed to initialize Cache for %s after %d tries", ts.ID, maxTries)
 	}
 
 	return s
 }
 
+// initTenants creates caches and dispatchers of all tenants
+func initTenants(ctx context.Context, srcs *sources, sn intents.Sender) *tenant.Registry {
+	settings, err := tenantSettings()
+	if err != nil {
+		log.Fatalf("failed to load tenants: %v", err)
+	}
+
+	reg := tenant.NewRegistry()
+	for _, ts := range settings {
+		t := tenant.New(ctx, ts, initCache(ctx, srcs, ts), sn, srcs.dispatcherOptions(ctx, ts)...)
+		if err := reg.Add(t); err != nil {
+			log.Fatalf("failed to add tenant: %v", err)
+		}
+	}
+
+	return reg
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if err := runCommand(context.Background(), os.Args[1], os.Args[2:]); err != nil {
+			log.Printf("ERROR: %v", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 
-	st := initCache(ctx)
 	sn := new(intents.MockSender)
-	d := intents.NewDispatcher(ctx, st, sn)
-	handlerFunc := MakeWebhookHandler(d)
+	srcs := &sources{}
+	reg := initTenants(ctx, srcs, sn)
+	handlerFunc := MakeWebhookHandler(reg)
 
 	http.HandleFunc("/", handlerFunc)
+	http.HandleFunc("/healthz", MakeHealthHandler(reg))
+
+	// every tenant has admin endpoints under /tenants/{id}/admin/,
+	// the default one is served under /admin/ as well
+	token := os.Getenv("ADMIN_TOKEN")
+	for _, id := range reg.IDs() {
+		t, _ := reg.Get(id)
+		prefix := "/tenants/" + id
+		http.Handle(prefix+"/admin/", http.StripPrefix(prefix, admin.NewServer(t.Cache, token)))
+	}
+	if t, ok := reg.Get(tenant.DefaultID); ok {
+		http.Handle("/admin/", admin.NewServer(t.Cache, token))
+	}
 
 	go func() {
 		port := os.Getenv("PORT")
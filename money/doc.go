@@ -0,0 +1,3 @@
+// Package money implements exact money amounts in kopecks
+// with russian printed and spoken formatting.
+package money
@@ -0,0 +1,138 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in kopecks
+type Money int64
+
+// ErrBadAmount is returned when amount string can not be parsed
+var ErrBadAmount = errors.New("bad money amount")
+
+// FromRubles returns Money amount of whole rubles
+func FromRubles(rubles int64) Money {
+	return Money(rubles * 100)
+}
+
+// FromFloat returns Money amount rounded to the nearest kopeck.
+// Use only for values that already are floats, like numbers in JSON.
+func FromFloat(rubles float64) Money {
+	return Money(math.Round(rubles * 100))
+}
+
+// Parse parses amount in rubles like "250", "190.50" or "190,5".
+// Fraction digits after kopecks are rounded half up.
+func Parse(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	// either separator is used, but only one of them and only once
+	parts := []string{s}
+	if i := strings.IndexAny(s, ".,"); i >= 0 {
+		parts = strings.SplitN(s, s[i:i+1], 2)
+	}
+	if parts[0] == "" || (len(parts) == 2 && (parts[1] == "" || strings.ContainsAny(parts[1], ".,"))) {
+		return 0, fmt.Errorf("%w: %q", ErrBadAmount, s)
+	}
+
+	rubles, err := strconv.ParseUint(parts[0], 10, 62)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrBadAmount, s)
+	}
+
+	kopecks := uint64(0)
+	if len(parts) == 2 {
+		frac := parts[1]
+		if _, err := strconv.ParseUint(frac, 10, 64); err != nil {
+			return 0, fmt.Errorf("%w: %q", ErrBadAmount, s)
+		}
+		roundUp := len(frac) > 2 && frac[2] >= '5'
+		frac = (frac + "00")[:2]
+		kopecks, _ = strconv.ParseUint(frac, 10, 64)
+		if roundUp {
+			kopecks++
+		}
+	}
+
+	m := Money(rubles*100 + kopecks)
+	if neg {
+		m = -m
+	}
+
+	return m, nil
+}
+
+// Add returns sum of amounts
+func (m Money) Add(o Money) Money {
+	return m + o
+}
+
+// Mul returns amount multiplied by quantity
+func (m Money) Mul(quantity uint) Money {
+	return m * Money(quantity)
+}
+
+// Rubles returns whole rubles part of amount
+func (m Money) Rubles() int64 {
+	return int64(m) / 100
+}
+
+// Kopecks returns kopecks part of amount
+func (m Money) Kopecks() int64 {
+	return int64(m) % 100
+}
+
+// String returns printed amount like "190,50 руб." or "250 руб."
+func (m Money) String() string {
+	sign := ""
+	if m < 0 {
+		sign = "-"
+		m = -m
+	}
+
+	if m.Kopecks() == 0 {
+		return fmt.Sprintf("%s%d руб.", sign, m.Rubles())
+	}
+
+	return fmt.Sprintf("%s%d,%02d руб.", sign, m.Rubles(), m.Kopecks())
+}
+
+// Decimal returns amount like "190.50" or "-1.50", the form
+// Parse reads back and imports and exports use
+func (m Money) Decimal() string {
+	sign := ""
+	if m < 0 {
+		sign = "-"
+		m = -m
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, m.Rubles(), m.Kopecks())
+}
+
+// Spoken returns amount in words for speech:
+// "двести пятьдесят рублей", "сто девяносто рублей пятьдесят копеек"
+func (m Money) Spoken() string {
+	sign := ""
+	if m < 0 {
+		sign = "минус "
+		m = -m
+	}
+
+	rubles := fmt.Sprintf("%s %s",
+		numberWords(m.Rubles(), masculine),
+		plural(m.Rubles(), "рубль", "рубля", "рублей"))
+	if m.Kopecks() == 0 {
+		return sign + rubles
+	}
+
+	return fmt.Sprintf("%s%s %s %s",
+		sign,
+		rubles,
+		numberWords(m.Kopecks(), feminine),
+		plural(m.Kopecks(), "копейка", "копейки", "копеек"))
+}
@@ -0,0 +1,125 @@
+package money
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		s        string
+		expected Money
+	}{
+		{"250", 25000},
+		{"190.50", 19050},
+		{"190,5", 19050},
+		{"0.1", 10},
+		{"99.999", 10000},
+		{"99.994", 9999},
+		{" 70 ", 7000},
+		{"-30", -3000},
+		{"0", 0},
+	}
+
+	for _, c := range cases {
+		got, err := Parse(c.s)
+		if err != nil {
+			t.Errorf("unexpected error in Parse(%q): %v", c.s, err)
+			continue
+		}
+		if got != c.expected {
+			t.Errorf("Parse(%q) = %d, expected %d", c.s, got, c.expected)
+		}
+	}
+
+	for _, s := range []string{"", "abc", "1.2.3", "12.", "1,x", "1e3", ".5", "1,,2", "1.2,3", "-"} {
+		if _, err := Parse(s); !errors.Is(err, ErrBadAmount) {
+			t.Errorf("expected ErrBadAmount from Parse(%q), got %v", s, err)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	// 0.1 + 0.2 style float errors must not leak into totals
+	total := Money(0)
+	for i := 0; i < 10; i++ {
+		total = total.Add(FromFloat(0.1))
+	}
+	if total != FromRubles(1) {
+		t.Errorf("expected 1 ruble, got %d kopecks", total)
+	}
+
+	price, _ := Parse("190.50")
+	if got := price.Mul(3); got != 57150 {
+		t.Errorf("unexpected 3 x 190.50 = %d", got)
+	}
+}
+
+func TestString(t *testing.T) {
+	cases := []struct {
+		m        Money
+		expected string
+	}{
+		{25000, "250 руб."},
+		{19050, "190,50 руб."},
+		{5, "0,05 руб."},
+		{-3000, "-30 руб."},
+	}
+
+	for _, c := range cases {
+		if got := c.m.String(); got != c.expected {
+			t.Errorf("Money(%d).String() = %q, expected %q", c.m, got, c.expected)
+		}
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	cases := []struct {
+		m        Money
+		expected string
+	}{
+		{25000, "250.00"},
+		{19050, "190.50"},
+		{5, "0.05"},
+		{-150, "-1.50"},
+		{-5, "-0.05"},
+	}
+
+	for _, c := range cases {
+		got := c.m.Decimal()
+		if got != c.expected {
+			t.Errorf("Money(%d).Decimal() = %q, expected %q", c.m, got, c.expected)
+		}
+		if back, err := Parse(got); err != nil || back != c.m {
+			t.Errorf("Parse(%q) = %d, %v, expected %d", got, back, err, c.m)
+		}
+	}
+}
+
+func TestSpoken(t *testing.T) {
+	cases := []struct {
+		m        Money
+		expected string
+	}{
+		{25000, "двести пятьдесят рублей"},
+		{19050, "сто девяносто рублей пятьдесят копеек"},
+		{100, "один рубль"},
+		{200, "два рубля"},
+		{1100, "одиннадцать рублей"},
+		{2100, "двадцать один рубль"},
+		{101, "один рубль одна копейка"},
+		{302, "три рубля две копейки"},
+		{114, "один рубль четырнадцать копеек"},
+		{0, "ноль рублей"},
+		{100000, "одна тысяча рублей"},
+		{215000, "две тысячи сто пятьдесят рублей"},
+		{512345600, "пять миллионов сто двадцать три тысячи четыреста пятьдесят шесть рублей"},
+		{-5000, "минус пятьдесят рублей"},
+	}
+
+	for _, c := range cases {
+		if got := c.m.Spoken(); got != c.expected {
+			t.Errorf("Money(%d).Spoken() = %q, expected %q", c.m, got, c.expected)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package money
+
+import "strings"
+
+// gender of a counted noun, russian "один рубль" but "одна копейка"
+type gender int
+
+const (
+	masculine gender = iota
+	feminine
+)
+
+var (
+	units = []string{
+		"", "один", "два", "три", "четыре",
+		"пять", "шесть", "семь", "восемь", "девять",
+	}
+	unitsFeminine = []string{"", "одна", "две"}
+	teens         = []string{
+		"десять", "одиннадцать", "двенадцать", "тринадцать", "четырнадцать",
+		"пятнадцать", "шестнадцать", "семнадцать", "восемнадцать", "девятнадцать",
+	}
+	tens = []string{
+		"", "", "двадцать", "тридцать", "сорок",
+		"пятьдесят", "шестьдесят", "семьдесят", "восемьдесят", "девяносто",
+	}
+	hundreds = []string{
+		"", "сто", "двести", "триста", "четыреста",
+		"пятьсот", "шестьсот", "семьсот", "восемьсот", "девятьсот",
+	}
+)
+
+// scales are thousands powers names with their gender
+var scales = []struct {
+	forms [3]string
+	g     gender
+}{
+	{[3]string{"тысяча", "тысячи", "тысяч"}, feminine},
+	{[3]string{"миллион", "миллиона", "миллионов"}, masculine},
+	{[3]string{"миллиард", "миллиарда", "миллиардов"}, masculine},
+}
+
+// plural returns russian plural form for n: one, few or many
+func plural(n int64, one, few, many string) string {
+	n %= 100
+	if n >= 11 && n <= 14 {
+		return many
+	}
+	switch n % 10 {
+	case 1:
+		return one
+	case 2, 3, 4:
+		return few
+	}
+	return many
+}
+
+// triadWords returns words for 1..999
+func triadWords(n int64, g gender) []string {
+	words := []string{}
+	if h := n / 100; h > 0 {
+		words = append(words, hundreds[h])
+	}
+
+	n %= 100
+	switch {
+	case n >= 10 && n < 20:
+		words = append(words, teens[n-10])
+	default:
+		if t := n / 10; t > 0 {
+			words = append(words, tens[t])
+		}
+		u := n % 10
+		switch {
+		case u == 0:
+		case g == feminine && u < 3:
+			words = append(words, unitsFeminine[u])
+		default:
+			words = append(words, units[u])
+		}
+	}
+
+	return words
+}
+
+// numberWords returns non-negative number in russian words
+func numberWords(n int64, g gender) string {
+	if n == 0 {
+		return "ноль"
+	}
+
+	words := []string{}
+	triads := []int64{}
+	for ; n > 0; n /= 1000 {
+		triads = append(triads, n%1000)
+	}
+
+	for i := len(triads) - 1; i >= 0; i-- {
+		t := triads[i]
+		if t == 0 {
+			continue
+		}
+		if i == 0 {
+			words = append(words, triadWords(t, g)...)
+			continue
+		}
+		if i-1 >= len(scales) {
+			// too large for a menu anyway
+			continue
+		}
+		s := scales[i-1]
+		words = append(words, triadWords(t, s.g)...)
+		words = append(words, plural(t, s.forms[0], s.forms[1], s.forms[2]))
+	}
+
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,187 @@
+package pos
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"mania/money"
+)
+
+// csvColumns maps header names to columns, Russian ones are what
+// POS and spreadsheet exports usually have
+var csvColumns = map[string]string{
+	"id":           "id",
+	"code":         "id",
+	"код":          "id",
+	"артикул":      "id",
+	"name":         "name",
+	"наименование": "name",
+	"название":     "name",
+	"category":     "category",
+	"категория":    "category",
+	"группа":       "category",
+	"price":        "price",
+	"цена":         "price",
+	"description":  "description",
+	"описание":     "description",
+	"composition":  "composition",
+	"состав":       "composition",
+	"image":        "image",
+	"изображение":  "image",
+	"фото":         "image",
+}
+
+const (
+	// categoryPathSep separates nested category names: "Блины/Сладкие"
+	categoryPathSep = "/"
+	// categoryListSep separates categories of a product listed in several
+	categoryListSep = ";"
+)
+
+// ReadCSV reads menu from CSV with a header row, one product per row.
+// Name, category and price columns are required. Delimiter is either
+// comma or semicolon Russian spreadsheets use. Category is a path like
+// "Блины/Сладкие блины", several categories are separated by ";".
+func ReadCSV(r io.Reader) (*Menu, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv: %w", err)
+	}
+	// spreadsheets prepend UTF-8 BOM
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+
+	cr := csv.NewReader(bytes.NewReader(b))
+	cr.Comma = detectComma(b)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, ErrEmptyMenu
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	cols := map[string]int{}
+	for i, h := range header {
+		if col, ok := csvColumns[strings.ToLower(strings.TrimSpace(h))]; ok {
+			cols[col] = i
+		}
+	}
+	for _, col := range []string{"name", "category", "price"} {
+		if _, ok := cols[col]; !ok {
+			return nil, fmt.Errorf("csv has no %s column", col)
+		}
+	}
+
+	m := &Menu{}
+	groups := map[string]bool{}
+	for row := 2; ; row++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read csv: %w", err)
+		}
+
+		get := func(col string) string {
+			i, ok := cols[col]
+			if !ok || i >= len(rec) {
+				return ""
+			}
+			return strings.TrimSpace(rec[i])
+		}
+
+		p := Product{
+			Name:        get("name"),
+			Description: get("description"),
+			Composition: get("composition"),
+			Image:       get("image"),
+		}
+		if p.Name == "" {
+			return nil, fmt.Errorf("row %d: empty name", row)
+		}
+		p.Key = p.Name
+
+		if id := get("id"); id != "" {
+			if p.ID, err = strconv.Atoi(id); err != nil || p.ID <= 0 {
+				return nil, fmt.Errorf("row %d: bad id %q", row, id)
+			}
+		}
+
+		p.Price, err = parsePrice(get("price"))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", row, err)
+		}
+
+		for _, path := range strings.Split(get("category"), categoryListSep) {
+			key := m.addGroupPath(path, groups)
+			if key != "" {
+				p.GroupKeys = append(p.GroupKeys, key)
+			}
+		}
+
+		m.Products = append(m.Products, p)
+	}
+
+	if len(m.Products) == 0 {
+		return nil, ErrEmptyMenu
+	}
+	return m, nil
+}
+
+// addGroupPath adds groups of "Блины/Сладкие блины" path missing
+// from seen and returns the last group key, path itself
+func (m *Menu) addGroupPath(path string, seen map[string]bool) string {
+	names := []string{}
+	for _, name := range strings.Split(path, categoryPathSep) {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	parent := ""
+	for i, name := range names {
+		key := strings.Join(names[:i+1], categoryPathSep)
+		if !seen[key] {
+			seen[key] = true
+			m.Groups = append(m.Groups, Group{Key: key, ParentKey: parent, Name: name})
+		}
+		parent = key
+	}
+	return parent
+}
+
+// detectComma picks semicolon if the header has more of them than commas
+func detectComma(b []byte) rune {
+	line := b
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	if bytes.Count(line, []byte(";")) > bytes.Count(line, []byte(",")) {
+		return ';'
+	}
+	return ','
+}
+
+// parsePrice parses price like "1 250,50" or "190.00 руб."
+func parsePrice(s string) (money.Money, error) {
+	s = strings.TrimSuffix(strings.TrimSpace(s), "руб.")
+	s = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\u00a0' {
+			return -1
+		}
+		return r
+	}, s)
+	if s == "" {
+		return 0, errors.New("empty price")
+	}
+	return money.Parse(s)
+}
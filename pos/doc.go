@@ -0,0 +1,5 @@
+// Package pos imports menus from restaurant POS exports: CSV
+// spreadsheets and iiko nomenclature in JSON or XML. Exports are read
+// into Menu, which is mapped to the catalog keeping IDs of items
+// already there.
+package pos
@@ -0,0 +1,179 @@
+package pos
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"mania/money"
+)
+
+// iikoNomenclature is iiko nomenclature export, the same structure
+// comes from the API as JSON and from iikoOffice as XML
+type iikoNomenclature struct {
+	XMLName  xml.Name      `json:"-" xml:"nomenclature"`
+	Groups   []iikoGroup   `json:"groups" xml:"groups>group"`
+	Products []iikoProduct `json:"products" xml:"products>product"`
+}
+
+type iikoGroup struct {
+	ID          string `json:"id" xml:"id"`
+	Code        string `json:"code" xml:"code"`
+	Name        string `json:"name" xml:"name"`
+	ParentGroup string `json:"parentGroup" xml:"parentGroup"`
+	IsDeleted   bool   `json:"isDeleted" xml:"isDeleted"`
+	// IsIncludedInMenu is absent in older exports, nil is included
+	IsIncludedInMenu *bool `json:"isIncludedInMenu" xml:"isIncludedInMenu"`
+}
+
+type iikoProduct struct {
+	ID          string `json:"id" xml:"id"`
+	Code        string `json:"code" xml:"code"`
+	Name        string `json:"name" xml:"name"`
+	ParentGroup string `json:"parentGroup" xml:"parentGroup"`
+	Description string `json:"description" xml:"description"`
+	// AdditionalInfo holds composition in most restaurants
+	AdditionalInfo string `json:"additionalInfo" xml:"additionalInfo"`
+	// Type is "Dish", "Good", "Modifier" or "Service"
+	Type      string   `json:"type" xml:"type"`
+	IsDeleted bool     `json:"isDeleted" xml:"isDeleted"`
+	Images    []string `json:"imageLinks" xml:"imageLinks>imageLink"`
+	// Price is set by older exports, newer ones have SizePrices
+	Price      *float64 `json:"price" xml:"price"`
+	SizePrices []struct {
+		Price struct {
+			CurrentPrice float64 `json:"currentPrice" xml:"currentPrice"`
+		} `json:"price" xml:"price"`
+	} `json:"sizePrices" xml:"sizePrices>sizePrice"`
+	Proteins float64 `json:"proteinsAmount" xml:"proteinsAmount"`
+	Fat      float64 `json:"fatAmount" xml:"fatAmount"`
+	Carbs    float64 `json:"carbohydratesAmount" xml:"carbohydratesAmount"`
+	Energy   float64 `json:"energyAmount" xml:"energyAmount"`
+}
+
+// ReadIikoJSON reads iiko nomenclature JSON
+func ReadIikoJSON(r io.Reader) (*Menu, error) {
+	n := iikoNomenclature{}
+	if err := json.NewDecoder(r).Decode(&n); err != nil {
+		return nil, fmt.Errorf("failed to decode iiko json: %w", err)
+	}
+	return n.menu()
+}
+
+// ReadIikoXML reads iiko nomenclature XML
+func ReadIikoXML(r io.Reader) (*Menu, error) {
+	n := iikoNomenclature{}
+	if err := xml.NewDecoder(r).Decode(&n); err != nil {
+		return nil, fmt.Errorf("failed to decode iiko xml: %w", err)
+	}
+	return n.menu()
+}
+
+// menu maps nomenclature to Menu. Deleted entries, groups hidden
+// from menu with their products and non-dish products are skipped.
+func (n *iikoNomenclature) menu() (*Menu, error) {
+	m := &Menu{}
+
+	skipped := map[string]bool{}
+	for _, g := range n.Groups {
+		if g.IsDeleted || (g.IsIncludedInMenu != nil && !*g.IsIncludedInMenu) {
+			skipped[g.ID] = true
+		}
+	}
+	for _, g := range n.Groups {
+		if skipped[g.ID] {
+			continue
+		}
+		// children of skipped groups are listed at the top level
+		parent := g.ParentGroup
+		if skipped[parent] {
+			parent = ""
+		}
+		m.Groups = append(m.Groups, Group{
+			Key:       g.ID,
+			ParentKey: parent,
+			Name:      g.Name,
+			ID:        iikoCode(g.Code),
+		})
+	}
+
+	for _, p := range n.Products {
+		if p.IsDeleted || skipped[p.ParentGroup] {
+			continue
+		}
+		if p.Type != "" && !strings.EqualFold(p.Type, "dish") {
+			continue
+		}
+
+		price, ok := p.price()
+		if !ok {
+			return nil, fmt.Errorf("product %q has no price", p.Name)
+		}
+
+		mp := Product{
+			Key:         p.ID,
+			ID:          iikoCode(p.Code),
+			Name:        p.Name,
+			Price:       price,
+			Description: p.Description,
+			Composition: p.composition(),
+		}
+		if len(p.Images) > 0 {
+			mp.Image = p.Images[0]
+		}
+		if p.ParentGroup != "" {
+			mp.GroupKeys = []string{p.ParentGroup}
+		}
+		m.Products = append(m.Products, mp)
+	}
+
+	if len(m.Products) == 0 {
+		return nil, ErrEmptyMenu
+	}
+	return m, nil
+}
+
+// iikoCode returns numeric code, codes like "00101" are common,
+// non-numeric ones are ignored
+func iikoCode(code string) int {
+	id, err := strconv.Atoi(strings.TrimSpace(code))
+	if err != nil || id <= 0 {
+		return 0
+	}
+	return id
+}
+
+// price returns product price, the first size price for sized ones
+func (p *iikoProduct) price() (money.Money, bool) {
+	if p.Price != nil {
+		return money.FromFloat(*p.Price), true
+	}
+	if len(p.SizePrices) > 0 {
+		return money.FromFloat(p.SizePrices[0].Price.CurrentPrice), true
+	}
+	return 0, false
+}
+
+// composition returns product composition with nutrients appended
+// the way our composition texts have them: "Б-20,5 Ж-27,4 У-6,3 Ккал-257"
+func (p *iikoProduct) composition() string {
+	s := strings.TrimSpace(p.AdditionalInfo)
+	if p.Energy <= 0 {
+		return s
+	}
+
+	nutrients := fmt.Sprintf("Б-%s Ж-%s У-%s Ккал-%s",
+		formatNutrient(p.Proteins), formatNutrient(p.Fat), formatNutrient(p.Carbs), formatNutrient(p.Energy))
+	if s == "" {
+		return nutrients
+	}
+	return s + " " + nutrients
+}
+
+// formatAmount formats nutrient amount with decimal comma
+func formatNutrient(v float64) string {
+	return strings.Replace(strconv.FormatFloat(v, 'f', -1, 64), ".", ",", 1)
+}
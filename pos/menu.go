@@ -0,0 +1,210 @@
+package pos
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"mania/money"
+	"mania/store"
+)
+
+// ErrEmptyMenu is returned for exports without products
+var ErrEmptyMenu = errors.New("no products in menu export")
+
+// Group is a POS nomenclature group, groups become categories
+type Group struct {
+	// Key identifies group within the export, e.g. iiko UUID
+	Key string
+	// ParentKey is parent group key, empty for top level groups
+	ParentKey string
+	Name      string
+	// ID is the numeric group code, 0 if POS has none
+	ID int
+}
+
+// Product is a POS nomenclature dish, products become items
+type Product struct {
+	Key string
+	// ID is the numeric product code, 0 if POS has none
+	ID          int
+	Name        string
+	Price       money.Money
+	Description string
+	Composition string
+	Image       string
+	// GroupKeys are keys of groups listing the product
+	GroupKeys []string
+}
+
+// Menu is a POS nomenclature export
+type Menu struct {
+	Groups   []Group
+	Products []Product
+}
+
+// idAssigner assigns catalog IDs: POS codes first, then IDs of
+// current catalog entries with the same name, then new IDs after
+// the largest one in use, so repeated imports keep IDs stable
+type idAssigner struct {
+	byName map[string]int
+	taken  map[int]bool
+	next   int
+}
+
+// newIDAssigner reserves POS codes, byName are current IDs
+// by lowercased names
+func newIDAssigner(byName map[string]int, codes []int) (*idAssigner, error) {
+	a := &idAssigner{byName: byName, taken: map[int]bool{}, next: 1}
+	for _, id := range byName {
+		if id >= a.next {
+			a.next = id + 1
+		}
+	}
+	for _, code := range codes {
+		if code <= 0 {
+			continue
+		}
+		if a.taken[code] {
+			return nil, fmt.Errorf("duplicate code %d", code)
+		}
+		a.taken[code] = true
+		if code >= a.next {
+			a.next = code + 1
+		}
+	}
+	return a, nil
+}
+
+func (a *idAssigner) assign(code int, name string) int {
+	if code > 0 {
+		return code
+	}
+	if id, ok := a.byName[strings.ToLower(name)]; ok && !a.taken[id] {
+		a.taken[id] = true
+		return id
+	}
+	id := a.next
+	a.taken[id] = true
+	a.next++
+	return id
+}
+
+// Catalog maps menu to catalog. Icons, synonyms, schedules and options
+// POS exports lack are kept from current catalog entries with the same ID.
+func (m *Menu) Catalog(current store.Catalog) (store.Catalog, error) {
+	res := store.Catalog{}
+	if len(m.Products) == 0 {
+		return res, ErrEmptyMenu
+	}
+
+	curCats := map[int]*store.Category{}
+	catNames := map[string]int{}
+	for _, cat := range current.Categories {
+		curCats[cat.ID] = cat
+		catNames[strings.ToLower(cat.Name)] = cat.ID
+	}
+	curItems := map[int]*store.Item{}
+	itemNames := map[string]int{}
+	for _, item := range current.Items {
+		curItems[item.ID] = item
+		itemNames[strings.ToLower(item.Name)] = item.ID
+	}
+
+	codes := make([]int, len(m.Groups))
+	for i, g := range m.Groups {
+		codes[i] = g.ID
+	}
+	groupIDs, err := newIDAssigner(catNames, codes)
+	if err != nil {
+		return res, fmt.Errorf("bad groups: %w", err)
+	}
+
+	cats := map[string]*store.Category{}
+	for _, g := range m.Groups {
+		if _, ok := cats[g.Key]; ok {
+			return res, fmt.Errorf("duplicate group %q", g.Key)
+		}
+		cat := &store.Category{
+			ID:       groupIDs.assign(g.ID, g.Name),
+			Name:     strings.TrimSpace(g.Name),
+			Products: []int{},
+		}
+		if cur, ok := curCats[cat.ID]; ok {
+			cat.Icon = cur.Icon
+			cat.Synonyms = cur.Synonyms
+			cat.Schedule = cur.Schedule
+		}
+		cats[g.Key] = cat
+		res.Categories = append(res.Categories, cat)
+	}
+	for _, g := range m.Groups {
+		if g.ParentKey == "" {
+			continue
+		}
+		parent, ok := cats[g.ParentKey]
+		if !ok {
+			return res, fmt.Errorf("group %q: unknown parent group %q", g.Name, g.ParentKey)
+		}
+		cats[g.Key].ParentID = parent.ID
+	}
+
+	codes = make([]int, len(m.Products))
+	for i, p := range m.Products {
+		codes[i] = p.ID
+	}
+	itemIDs, err := newIDAssigner(itemNames, codes)
+	if err != nil {
+		return res, fmt.Errorf("bad products: %w", err)
+	}
+
+	for _, p := range m.Products {
+		item, err := store.MapItem(map[string]interface{}{
+			"product_id":  strconv.Itoa(itemIDs.assign(p.ID, p.Name)),
+			"name":        p.Name,
+			"price":       p.Price,
+			"composition": p.Composition,
+			"description": p.Description,
+			"image":       p.Image,
+		})
+		if err != nil {
+			return res, fmt.Errorf("product %q: %w", p.Name, err)
+		}
+		if cur, ok := curItems[item.ID]; ok {
+			item.Synonyms = cur.Synonyms
+			item.OptionGroups = cur.OptionGroups
+			item.Schedule = cur.Schedule
+			if item.Image == "" {
+				item.Image = cur.Image
+			}
+		}
+
+		for _, key := range p.GroupKeys {
+			cat, ok := cats[key]
+			if !ok {
+				return res, fmt.Errorf("product %q: unknown group %q", p.Name, key)
+			}
+			if !containsInt(cat.Products, item.ID) {
+				cat.Products = append(cat.Products, item.ID)
+			}
+		}
+		res.Items = append(res.Items, &item)
+	}
+
+	sort.Slice(res.Items, func(i, j int) bool {
+		return res.Items[i].ID < res.Items[j].ID
+	})
+
+	return res, nil
+}
+
+func containsInt(slice []int, v int) bool {
+	for _, e := range slice {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+package pos
+
+import (
+	"strings"
+	"testing"
+
+	"mania/money"
+	"mania/store"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadCSV(t *testing.T) {
+	m, err := ReadFile("testdata/menu.csv", "")
+	if err != nil {
+		t.Fatalf("unexpected error in ReadFile: %v", err)
+	}
+
+	expectedGroups := []Group{
+		{Key: "Блины", Name: "Блины"},
+		{Key: "Блины/Сладкие блины", ParentKey: "Блины", Name: "Сладкие блины"},
+		{Key: "Напитки", Name: "Напитки"},
+		{Key: "Десерты", Name: "Десерты"},
+	}
+	if diff := cmp.Diff(expectedGroups, m.Groups); diff != "" {
+		t.Errorf("groups differ:\n%s", diff)
+	}
+
+	if len(m.Products) != 4 {
+		t.Fatalf("expected 4 products, got %d", len(m.Products))
+	}
+	p := m.Products[0]
+	if p.ID != 101 || p.Name != "Блинчики" || p.Price != money.FromRubles(150) ||
+		p.Description != "Тонкие блинчики на молоке" {
+		t.Errorf("unexpected product %+v", p)
+	}
+	if m.Products[1].Price != money.Money(119050) {
+		t.Errorf("unexpected price %v", m.Products[1].Price)
+	}
+	if m.Products[2].Price != money.FromRubles(90) {
+		t.Errorf("unexpected price %v", m.Products[2].Price)
+	}
+	if keys := m.Products[3].GroupKeys; len(keys) != 2 || keys[1] != "Десерты" {
+		t.Errorf("expected product in two groups, got %v", keys)
+	}
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	cases := []struct {
+		csv, err string
+	}{
+		{"", "no products"},
+		{"name,price\nБлины,100\n", "no category column"},
+		{"name,category,price\nБлины,Блины,сто\n", "row 2"},
+		{"id,name,category,price\nx,Блины,Блины,100\n", "bad id"},
+		{"name,category,price\n", "no products"},
+	}
+	for _, c := range cases {
+		_, err := ReadCSV(strings.NewReader(c.csv))
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("expected %q error for %q, got %v", c.err, c.csv, err)
+		}
+	}
+}
+
+func TestReadIiko(t *testing.T) {
+	jm, err := ReadFile("testdata/nomenclature.json", "")
+	if err != nil {
+		t.Fatalf("unexpected error in ReadFile(json): %v", err)
+	}
+	xm, err := ReadFile("testdata/nomenclature.xml", FormatIikoXML)
+	if err != nil {
+		t.Fatalf("unexpected error in ReadFile(xml): %v", err)
+	}
+	if diff := cmp.Diff(jm, xm); diff != "" {
+		t.Errorf("json and xml menus differ:\n%s", diff)
+	}
+
+	expected := &Menu{
+		Groups: []Group{
+			{Key: "g-1", Name: "Блины", ID: 1},
+			{Key: "g-2", ParentKey: "g-1", Name: "Сладкие блины"},
+		},
+		Products: []Product{
+			{
+				Key:         "p-1",
+				ID:          101,
+				Name:        "Блинчики",
+				Price:       money.FromRubles(150),
+				Description: "Тонкие блинчики на молоке",
+				Composition: "Мука, молоко, яйца Б-6,5 Ж-8 У-25,3 Ккал-201",
+				Image:       "https://example.com/img/101.jpg",
+				GroupKeys:   []string{"g-1"},
+			},
+			{
+				Key:       "p-2",
+				Name:      "Блины с творогом",
+				Price:     money.Money(19050),
+				GroupKeys: []string{"g-2"},
+			},
+		},
+	}
+	if diff := cmp.Diff(expected, jm); diff != "" {
+		t.Errorf("menu differs:\n%s", diff)
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	schedule := &store.Schedule{From: 9 * 60, To: 12 * 60}
+	current := store.Catalog{
+		Categories: []*store.Category{
+			{ID: 1, Name: "Блины", Icon: "🥞", Products: []int{101, 103}},
+		},
+		Items: []*store.Item{
+			{ID: 101, Name: "Блинчики", Price: money.FromRubles(140)},
+			{ID: 103, Name: "Блины с творогом", Schedule: schedule, Image: "https://example.com/103.jpg"},
+		},
+	}
+
+	m, err := ReadFile("testdata/menu.csv", "")
+	if err != nil {
+		t.Fatalf("unexpected error in ReadFile: %v", err)
+	}
+	c, err := m.Catalog(current)
+	if err != nil {
+		t.Fatalf("unexpected error in Catalog: %v", err)
+	}
+
+	cats := map[string]*store.Category{}
+	for _, cat := range c.Categories {
+		cats[cat.Name] = cat
+	}
+	if cat := cats["Блины"]; cat == nil || cat.ID != 1 || cat.Icon != "🥞" || !cmp.Equal(cat.Products, []int{101}) {
+		t.Errorf("expected current category to keep its ID and icon, got %+v", cat)
+	}
+	if cat := cats["Сладкие блины"]; cat == nil || cat.ID != 2 || cat.ParentID != 1 ||
+		!cmp.Equal(cat.Products, []int{103, 105}) {
+		t.Errorf("unexpected subcategory %+v", cat)
+	}
+	if cat := cats["Десерты"]; cat == nil || !cmp.Equal(cat.Products, []int{105}) {
+		t.Errorf("unexpected category %+v", cat)
+	}
+
+	ids := []int{}
+	for _, item := range c.Items {
+		ids = append(ids, item.ID)
+	}
+	if !cmp.Equal(ids, []int{101, 103, 104, 105}) {
+		t.Errorf("unexpected item IDs %v", ids)
+	}
+	item := c.Items[1]
+	if item.Name != "Блины с творогом" || item.Schedule != schedule || item.Image != "https://example.com/103.jpg" {
+		t.Errorf("expected item to keep schedule and image, got %+v", item)
+	}
+	if len(item.Ingredients) == 0 {
+		t.Error("expected ingredients to be parsed")
+	}
+
+	diff := store.DiffCatalogs(current, c)
+	lines := make([]string, len(diff))
+	for i, e := range diff {
+		lines[i] = e.String()
+	}
+	expected := []string{
+		"~ categories 1 Блины: products: [101 103] → [101]",
+		"+ categories 2 Сладкие блины",
+		"+ categories 3 Напитки",
+		"+ categories 4 Десерты",
+		"~ products 101 Блинчики: price: 140 руб. → 150 руб., composition: \"\" → Мука, молоко, яйца, " +
+			"description: \"\" → Тонкие блинчики на молоке",
+		"~ products 103 Блины с творогом: price: 0 руб. → 1190,50 руб., composition: \"\" → Блин, творог, сахар",
+		"+ products 104 Морс клюквенный",
+		"+ products 105 Блины с вишней",
+	}
+	if d := cmp.Diff(expected, lines); d != "" {
+		t.Errorf("catalog diff differs:\n%s", d)
+	}
+}
+
+func TestCatalogErrors(t *testing.T) {
+	m := &Menu{
+		Products: []Product{
+			{Key: "1", ID: 1, Name: "Блины", Price: 100},
+			{Key: "2", ID: 1, Name: "Оладьи", Price: 100},
+		},
+	}
+	if _, err := m.Catalog(store.Catalog{}); err == nil || !strings.Contains(err.Error(), "duplicate code 1") {
+		t.Errorf("expected duplicate code error, got %v", err)
+	}
+
+	m = &Menu{Products: []Product{{Key: "1", Name: "Блины", Price: 100, GroupKeys: []string{"x"}}}}
+	if _, err := m.Catalog(store.Catalog{}); err == nil || !strings.Contains(err.Error(), "unknown group") {
+		t.Errorf("expected unknown group error, got %v", err)
+	}
+
+	if _, err := (&Menu{}).Catalog(store.Catalog{}); err != ErrEmptyMenu {
+		t.Errorf("expected ErrEmptyMenu, got %v", err)
+	}
+}
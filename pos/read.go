@@ -0,0 +1,49 @@
+package pos
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Export formats
+const (
+	FormatCSV      = "csv"
+	FormatIikoJSON = "iiko-json"
+	FormatIikoXML  = "iiko-xml"
+)
+
+// ReadFile reads menu export in format, empty format is chosen
+// by file extension: .csv, .json or .xml
+func ReadFile(path, format string) (*Menu, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv":
+			format = FormatCSV
+		case ".json":
+			format = FormatIikoJSON
+		case ".xml":
+			format = FormatIikoXML
+		default:
+			return nil, fmt.Errorf("unknown format of %s, set it explicitly", path)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open menu export: %w", err)
+	}
+	defer f.Close()
+
+	switch format {
+	case FormatCSV:
+		return ReadCSV(f)
+	case FormatIikoJSON:
+		return ReadIikoJSON(f)
+	case FormatIikoXML:
+		return ReadIikoXML(f)
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
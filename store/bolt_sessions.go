@@ -0,0 +1,121 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// BoltSessionStore keeps sessions in a local bbolt file, so carts
+// survive restarts of a single instance. The file is locked by
+// the process, instances can not share it.
+type BoltSessionStore struct {
+	db     *bolt.DB
+	bucket []byte
+}
+
+// OpenBoltSessionStore opens or creates sessions file path
+func OpenBoltSessionStore(path string) (*BoltSessionStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sessions file: %w", err)
+	}
+	return &BoltSessionStore{db: db, bucket: []byte("sessions")}, nil
+}
+
+// ForTenant returns store keeping tenant sessions in the same file
+func (st *BoltSessionStore) ForTenant(id string) *BoltSessionStore {
+	return &BoltSessionStore{db: st.db, bucket: []byte("tenants/" + id + "/sessions")}
+}
+
+// Close closes sessions file
+func (st *BoltSessionStore) Close() error {
+	return st.db.Close()
+}
+
+// Get reads session record
+func (st *BoltSessionStore) Get(ctx context.Context, id string) (Session, int64, error) {
+	r := sessionRecord{}
+	err := st.db.View(func(tx *bolt.Tx) error {
+		var err error
+		r, err = st.record(tx, id)
+		return err
+	})
+	if err != nil {
+		return Session{}, 0, err
+	}
+	if r.Version == 0 || r.expired(time.Now()) {
+		return Session{}, 0, ErrNoSession
+	}
+	return r.Session, r.Version, nil
+}
+
+// Put writes session record in a transaction checking its version
+func (st *BoltSessionStore) Put(ctx context.Context, id string, s Session, version int64, expiresAt time.Time) error {
+	return st.db.Update(func(tx *bolt.Tx) error {
+		r, err := st.record(tx, id)
+		if err != nil {
+			return err
+		}
+		current := r.Version
+		if r.expired(time.Now()) {
+			current = 0
+		}
+		if current != version {
+			return ErrSessionConflict
+		}
+
+		b, err := json.Marshal(sessionRecord{Version: version + 1, ExpiresAt: expiresAt, Session: s})
+		if err != nil {
+			return fmt.Errorf("failed to encode session: %w", err)
+		}
+		bucket, err := tx.CreateBucketIfNotExists(st.bucket)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(id), b)
+	})
+}
+
+// Expire deletes expired session records
+func (st *BoltSessionStore) Expire(ctx context.Context, now time.Time) error {
+	return st.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(st.bucket)
+		if bucket == nil {
+			return nil
+		}
+
+		c := bucket.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			r := sessionRecord{}
+			// undecodable records are dropped as well
+			if err := json.Unmarshal(v, &r); err == nil && !r.expired(now) {
+				continue
+			}
+			if err := c.Delete(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// record returns stored session record, zero if missing
+func (st *BoltSessionStore) record(tx *bolt.Tx, id string) (sessionRecord, error) {
+	r := sessionRecord{}
+	bucket := tx.Bucket(st.bucket)
+	if bucket == nil {
+		return r, nil
+	}
+	v := bucket.Get([]byte(id))
+	if v == nil {
+		return r, nil
+	}
+	if err := json.Unmarshal(v, &r); err != nil {
+		return r, fmt.Errorf("failed to decode session: %w", err)
+	}
+	return r, nil
+}
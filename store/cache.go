@@ -3,8 +3,11 @@ package store
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -13,6 +16,13 @@ import (
 const (
 	updateInterval  = time.Hour * 1
 	firebaseTimeout = time.Minute * 5
+	// watchRetryInterval is a pause before resubscribing
+	// to failed source changes stream
+	watchRetryInterval = time.Second * 10
+	stopListInterval   = time.Minute
+	// staleRetryInterval is how often we retry the source
+	// while serving a snapshot
+	staleRetryInterval = time.Second * 30
 )
 
 // Cache provides cahcing layer to limit Firestore usage
@@ -20,69 +30,321 @@ const (
 type Cache struct {
 	mux  sync.RWMutex
 	ctx  context.Context
+	src  Source
 	data *cacheData
+	// stopList is refreshed separately from the catalog
+	// as it changes much more often
+	stopList map[int]StopEntry
+	// updatedAt is the time of the last successful full refresh
+	updatedAt time.Time
+	lastErr   error
+	lastErrAt time.Time
+	// snapshotDir keeps catalog snapshots for warm start, empty disables
+	snapshotDir string
+	// stale is true while serving snapshot data because source failed
+	stale bool
+	// maxErrors is validation errors count above which fresh
+	// catalog is refused, negative means no limit
+	maxErrors int
+	// report is the latest catalog validation report
+	report ValidationReport
+	// clock and loc give restaurant local time schedules are checked at
+	clock func() time.Time
+	loc   *time.Location
+	// orders feed popularity, nil disables popularity ranking
+	orders   OrderLog
+	halfLife time.Duration
+	// stats are decayed popularity and co-occurrence of ordered items
+	stats *orderStats
+	// pins are pinned item positions by item ID
+	pins map[int]int
+	// refreshing counts refreshes populating data; watch changes
+	// applied meanwhile are kept in pending to be replayed
+	// on the fresh data, changeSeq numbers them
+	refreshing int
+	changeSeq  uint64
+	pending    []changeBatch
+}
+
+// changeBatch is a batch of watch changes and its sequence number
+type changeBatch struct {
+	seq     uint64
+	changes []Change
+}
+
+// CacheOption configures Cache
+type CacheOption func(*Cache)
+
+// WithSnapshotDir makes cache save every successfully loaded catalog
+// to dir and start from the newest snapshot there if the source
+// is unreachable
+func WithSnapshotDir(dir string) CacheOption {
+	return func(c *Cache) {
+		c.snapshotDir = dir
+	}
+}
+
+// WithMaxValidationErrors makes cache refuse catalogs having more than
+// n validation errors and keep serving the old one
+func WithMaxValidationErrors(n int) CacheOption {
+	return func(c *Cache) {
+		c.maxErrors = n
+	}
+}
+
+// WithLocation sets restaurant time zone schedules are evaluated in
+func WithLocation(loc *time.Location) CacheOption {
+	return func(c *Cache) {
+		c.loc = loc
+	}
+}
+
+// WithClock replaces time.Now, for tests
+func WithClock(now func() time.Time) CacheOption {
+	return func(c *Cache) {
+		c.clock = now
+	}
 }
 
 // cacheData internal struct that holds actual cache data
 type cacheData struct {
+	// version grows with every swapped in catalog
+	version          uint64
 	categories       []*Category
 	categoriesByName map[string]*Category
-	items            map[int]*Item
-	itemsByName      map[string]*Item
+	categoriesByID   map[int]*Category
+	// subcategories by parent category ID, top-level ones are under 0
+	children    map[int][]*Category
+	items       map[int]*Item
+	itemsByName map[string]*Item
+	// fuzzy matchers entries are aligned with
+	// categories and itemsList slices
+	categoryMatcher *nameMatcher
+	itemsList       []*Item
+	itemMatcher     *nameMatcher
+	searchIndex     searchIndex
 }
 
 // NewCache returns a pointer to a new Cache instance already populated
-// with fresh data
-func NewCache(ctx context.Context) (*Cache, error) {
+// with fresh data from src. If src fails and snapshot dir is configured,
+// cache starts with the newest snapshot and keeps retrying src.
+func NewCache(ctx context.Context, src Source, opts ...CacheOption) (*Cache, error) {
 	c := &Cache{
-		ctx:  ctx,
-		data: new(cacheData),
+		ctx:       ctx,
+		src:       src,
+		data:      new(cacheData),
+		stopList:  make(map[int]StopEntry),
+		maxErrors: -1,
+		clock:     time.Now,
+		loc:       time.Local,
+		halfLife:  defaultHalfLife,
+		stats:     newOrderStats(),
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if err := c.Refresh(ctx); err != nil {
+		if c.snapshotDir == "" {
+			return nil, fmt.Errorf("failed to populate cache: %w", err)
+		}
 
-	if err := c.data.populate(c.ctx); err != nil {
-		return nil, fmt.Errorf("failed to populate cache: %w", err)
+		data, createdAt, snapErr := loadSnapshot(c.snapshotDir)
+		if snapErr != nil {
+			return nil, fmt.Errorf("failed to populate cache: %w, and to load snapshot: %v", err, snapErr)
+		}
+
+		log.Printf("WARNING: serving catalog snapshot of %v, source failed: %v", createdAt, err)
+		c.swap(data)
+		c.updatedAt = createdAt
+		c.stale = true
+		go c.recoverLoop()
+	}
+
+	if err := c.refreshPopularity(); err != nil {
+		log.Printf("ERROR: failed to load popularity: %v", err)
+	}
+	if ps, ok := src.(PinSource); ok {
+		if err := c.refreshPins(ps); err != nil {
+			log.Printf("ERROR: failed to load pinned items: %v", err)
+		}
 	}
 
 	go c.updateLoop()
 
+	if w, ok := src.(Watcher); ok {
+		go c.watchLoop(w)
+	}
+
+	if sl, ok := src.(StopListSource); ok {
+		if err := c.refreshStopList(sl); err != nil {
+			log.Printf("ERROR: failed to load stop list: %v", err)
+		}
+		go c.stopListLoop(sl)
+	}
+
 	return c, nil
 }
 
-// populate connects to firebase, fetches categories and items
-// collections and populates *ByName index maps
-func (c *cacheData) populate(ctx context.Context) error {
+// populate loads categories and items from the source
+// and builds indexes
+func (c *cacheData) populate(ctx context.Context, src Source) (ValidationReport, error) {
+	report, err := c.load(ctx, src)
+	if err != nil {
+		return report, err
+	}
+	c.index()
+
+	return report, nil
+}
+
+// load fetches categories and items from the source and
+// validates them. Documents source failed to map are reported,
+// not returned as error.
+func (c *cacheData) load(ctx context.Context, src Source) (ValidationReport, error) {
 	timeoutCtx, cancel := context.WithTimeout(ctx, firebaseTimeout)
 	defer cancel()
 
-	db, err := New(timeoutCtx)
-	if err != nil {
-		return err
+	report := ValidationReport{CheckedAt: time.Now()}
+
+	var err error
+	var docErrs DocumentErrors
+	c.categories, err = src.GetCategories(timeoutCtx)
+	if errors.As(err, &docErrs) {
+		report.addDocumentErrors(docErrs)
+	} else if err != nil {
+		return report, err
 	}
 
-	c.categories, err = db.GetCategories(timeoutCtx)
-	if err != nil {
-		return err
+	c.items, err = src.GetItems(timeoutCtx)
+	if errors.As(err, &docErrs) {
+		report.addDocumentErrors(docErrs)
+	} else if err != nil {
+		return report, err
 	}
 
+	c.validate(&report)
+
+	return report, nil
+}
+
+// index populates *ByName index maps, category tree, fuzzy matchers
+// and full-text search index
+func (c *cacheData) index() {
 	c.categoriesByName = make(map[string]*Category, len(c.categories))
+	catNames := make([]string, len(c.categories))
 	for i := range c.categories {
 		c.categoriesByName[strings.ToLower(c.categories[i].Name)] = c.categories[i]
+		catNames[i] = c.categories[i].Name
 	}
+	// synonyms never shadow names, validation drops colliding ones
+	for _, cat := range c.categories {
+		for _, s := range cat.Synonyms {
+			if _, ok := c.categoriesByName[strings.ToLower(s)]; !ok {
+				c.categoriesByName[strings.ToLower(s)] = cat
+			}
+		}
+	}
+	c.categoryMatcher = newNameMatcher(catNames)
 
-	c.items, err = db.GetItems(timeoutCtx)
-	if err != nil {
-		return err
+	c.categoriesByID = make(map[int]*Category, len(c.categories))
+	for _, cat := range c.categories {
+		c.categoriesByID[cat.ID] = cat
+	}
+	c.children = make(map[int][]*Category)
+	for _, cat := range c.categories {
+		parentID := cat.ParentID
+		// categories with unknown or self parent are top-level
+		if _, ok := c.categoriesByID[parentID]; !ok || parentID == cat.ID {
+			parentID = 0
+		}
+		c.children[parentID] = append(c.children[parentID], cat)
 	}
 
 	c.itemsByName = make(map[string]*Item, len(c.items))
+	c.itemsList = make([]*Item, 0, len(c.items))
 	for i := range c.items {
 		c.itemsByName[strings.ToLower(c.items[i].Name)] = c.items[i]
+		c.itemsList = append(c.itemsList, c.items[i])
 	}
 
-	return nil
+	// map order is random, keep matches order stable
+	sort.Slice(c.itemsList, func(i, j int) bool {
+		return c.itemsList[i].ID < c.itemsList[j].ID
+	})
+	for _, item := range c.itemsList {
+		for _, s := range item.Synonyms {
+			if _, ok := c.itemsByName[strings.ToLower(s)]; !ok {
+				c.itemsByName[strings.ToLower(s)] = item
+			}
+		}
+	}
+	itemNames := make([]string, len(c.itemsList))
+	for i := range c.itemsList {
+		itemNames[i] = c.itemsList[i].Name
+	}
+	c.itemMatcher = newNameMatcher(itemNames)
+	c.searchIndex = newSearchIndex(c.itemsList)
 }
 
-// updateLoop periodically fetches new data from firebase and
+// apply returns a copy of cache data with changes applied and
+// validated again, found issues are added to the report.
+// Data is never modified in place as readers may still hold
+// slices returned from the old copy, validation gets copies
+// of categories and items to update.
+func (c *cacheData) apply(changes []Change, r *ValidationReport) *cacheData {
+	data := &cacheData{
+		categories: make([]*Category, len(c.categories)),
+		items:      make(map[int]*Item, len(c.items)),
+	}
+	for i, cat := range c.categories {
+		cp := *cat
+		data.categories[i] = &cp
+	}
+	for id, item := range c.items {
+		cp := *item
+		data.items[id] = &cp
+	}
+
+	for _, ch := range changes {
+		switch {
+		case ch.Category != nil:
+			data.applyCategory(ch.Category, ch.Removed)
+		case ch.Item != nil:
+			if ch.Removed {
+				delete(data.items, ch.Item.ID)
+				continue
+			}
+			data.items[ch.Item.ID] = ch.Item
+		}
+	}
+
+	data.validate(r)
+	data.index()
+
+	return data
+}
+
+// applyCategory replaces, appends or removes category keeping the order
+func (c *cacheData) applyCategory(cat *Category, removed bool) {
+	for i := range c.categories {
+		if c.categories[i].ID != cat.ID {
+			continue
+		}
+		if removed {
+			c.categories = append(c.categories[:i], c.categories[i+1:]...)
+			return
+		}
+		c.categories[i] = cat
+		return
+	}
+
+	if !removed {
+		c.categories = append(c.categories, cat)
+	}
+}
+
+// updateLoop periodically fetches new data from the source and
 // changes data pointer to point at a fresh data
 func (c *Cache) updateLoop() {
 	ticker := time.NewTicker(updateInterval)
@@ -94,38 +356,413 @@ func (c *Cache) updateLoop() {
 		case <-ticker.C:
 		}
 
-		data := new(cacheData)
-		if err := data.populate(c.ctx); err != nil {
+		if err := c.Refresh(c.ctx); err != nil {
 			log.Printf("ERROR: failed to populate cache: %v", err)
+		}
+		// decay makes popularity change without new orders too
+		if err := c.refreshPopularity(); err != nil {
+			log.Printf("ERROR: failed to refresh popularity: %v", err)
+		}
+		if ps, ok := c.src.(PinSource); ok {
+			if err := c.refreshPins(ps); err != nil {
+				log.Printf("ERROR: failed to refresh pinned items: %v", err)
+			}
+		}
+	}
+}
+
+// Refresh fetches fresh data from the source and swaps it in.
+// Failed refresh or catalog with too many validation errors
+// keeps serving old data.
+func (c *Cache) Refresh(ctx context.Context) error {
+	c.mux.Lock()
+	c.refreshing++
+	start := c.changeSeq
+	c.mux.Unlock()
+
+	data := new(cacheData)
+	report, err := data.populate(ctx, c.src)
+	data, updatedAt, err := c.install(data, report, err, start)
+	if err != nil {
+		return err
+	}
+
+	// snapshot is written unlocked, so readers don't wait for the disk
+	if c.snapshotDir != "" {
+		if err := writeSnapshot(c.snapshotDir, data, updatedAt); err != nil {
+			log.Printf("ERROR: failed to write catalog snapshot: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// install swaps in data populated by Refresh with its validation
+// report, or records populate error. Watch changes applied after
+// start sequence number are replayed, the fetch may have missed
+// them. Returns data swapped in and the refresh time.
+func (c *Cache) install(data *cacheData, report ValidationReport, err error, start uint64) (*cacheData, time.Time, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	missed := []Change{}
+	for _, b := range c.pending {
+		if b.seq > start {
+			missed = append(missed, b.changes...)
+		}
+	}
+	c.refreshing--
+	if c.refreshing == 0 {
+		c.pending = nil
+	}
+
+	if err != nil {
+		c.lastErr = err
+		c.lastErrAt = time.Now()
+		return nil, time.Time{}, err
+	}
+
+	// changes are idempotent, those the fetch has seen apply as is
+	if len(missed) > 0 {
+		r := changesReport(report, missed)
+		data = data.apply(missed, &r)
+		report = r
+	}
+
+	if err := c.checkReport(report); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	c.report = report
+	if report.Errors > 0 || report.Warnings > 0 {
+		log.Printf(
+			"WARNING: catalog has %d validation errors and %d warnings",
+			report.Errors,
+			report.Warnings,
+		)
+	}
+
+	c.swap(data)
+	c.updatedAt = time.Now()
+	c.lastErr = nil
+	c.stale = false
+
+	return data, c.updatedAt, nil
+}
+
+// recoverLoop retries source until cache started from a snapshot
+// gets fresh data
+func (c *Cache) recoverLoop() {
+	ticker := time.NewTicker(staleRetryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := c.Refresh(c.ctx); err != nil {
+			log.Printf("ERROR: still serving stale catalog: %v", err)
+			continue
+		}
+
+		log.Print("INFO: catalog recovered from the source")
+		return
+	}
+}
+
+// watchLoop keeps cache in sync with source changes stream,
+// resubscribing after failures. Hourly updateLoop stays as a safety net
+// for missed changes.
+func (c *Cache) watchLoop(w Watcher) {
+	for {
+		err := w.Watch(c.ctx, c.applyChanges)
+
+		select {
+		case <-c.ctx.Done():
+			log.Print("INFO: cache watcher stopped")
+			return
+		case <-time.After(watchRetryInterval):
+		}
+
+		log.Printf("ERROR: catalog watcher failed, resubscribing: %v", err)
+	}
+}
+
+// applyChanges applies a batch of changes and swaps data pointer.
+// Changes are kept while refresh is in flight, so data it fetched
+// before them doesn't overwrite them.
+func (c *Cache) applyChanges(changes []Change) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	// changed documents are validated the way refresh does,
+	// too many errors refuse the whole batch
+	report := changesReport(c.report, changes)
+	data := c.data.apply(changes, &report)
+	if err := c.checkReport(report); err != nil {
+		log.Printf("ERROR: catalog changes refused: %v", err)
+		return
+	}
+
+	c.changeSeq++
+	if c.refreshing > 0 {
+		c.pending = append(c.pending, changeBatch{seq: c.changeSeq, changes: changes})
+	}
+	c.report = report
+	c.swap(data)
+}
+
+// checkReport records report refused when it has more errors than
+// allowed and returns ErrTooManyErrors, cache must be locked
+func (c *Cache) checkReport(report ValidationReport) error {
+	if c.maxErrors < 0 || report.Errors <= c.maxErrors {
+		return nil
+	}
+
+	report.Refused = true
+	c.report = report
+	c.lastErr = fmt.Errorf("%w: %d, allowed %d", ErrTooManyErrors, report.Errors, c.maxErrors)
+	c.lastErrAt = time.Now()
+	return c.lastErr
+}
+
+// changesReport starts report of data validated again after changes.
+// Quarantined documents are gone from the data, so their issues are
+// kept from the previous report unless changes replace them.
+func changesReport(prev ValidationReport, changes []Change) ValidationReport {
+	changed := make(map[string]bool, len(changes))
+	for _, ch := range changes {
+		switch {
+		case ch.Category != nil:
+			changed["categories/"+strconv.Itoa(ch.Category.ID)] = true
+		case ch.Item != nil:
+			changed["products/"+strconv.Itoa(ch.Item.ID)] = true
+		}
+	}
+
+	r := ValidationReport{CheckedAt: time.Now()}
+	for _, is := range prev.Issues {
+		if is.Quarantined && !changed[is.Collection+"/"+is.DocID] {
+			r.add(is)
+		}
+	}
+	return r
+}
+
+// swap replaces cache data with the next catalog version,
+// cache must be locked
+func (c *Cache) swap(data *cacheData) {
+	data.version = c.data.version + 1
+	c.data = data
+}
+
+// Version returns catalog version, it changes whenever
+// a new catalog is swapped in
+func (c *Cache) Version() uint64 {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	return c.data.version
+}
+
+// stopListLoop periodically reloads stop list from the source
+func (c *Cache) stopListLoop(sl StopListSource) {
+	ticker := time.NewTicker(stopListInterval)
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := c.refreshStopList(sl); err != nil {
+			log.Printf("ERROR: failed to refresh stop list: %v", err)
+		}
+	}
+}
+
+// refreshStopList loads stop list from the source
+func (c *Cache) refreshStopList(sl StopListSource) error {
+	ctx, cancel := context.WithTimeout(c.ctx, firebaseTimeout)
+	defer cancel()
+
+	entries, err := sl.GetStopList(ctx)
+	if err != nil {
+		return err
+	}
+
+	stopList := make(map[int]StopEntry, len(entries))
+	for _, e := range entries {
+		stopList[e.ItemID] = e
+	}
+
+	c.mux.Lock()
+	c.stopList = stopList
+	c.mux.Unlock()
+
+	return nil
+}
+
+// isAvailable reports whether item is not in the active stop list.
+// Must be called with mux held.
+func (c *Cache) isAvailable(itemID int) bool {
+	e, ok := c.stopList[itemID]
+	return !ok || !e.Active(c.now())
+}
+
+// IsAvailable reports whether item can be ordered now
+func (c *Cache) IsAvailable(itemID int) bool {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	return c.isAvailable(itemID)
+}
+
+// GetStopList returns active stop list entries
+func (c *Cache) GetStopList() []StopEntry {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	now := c.now()
+	entries := make([]StopEntry, 0, len(c.stopList))
+	for _, e := range c.stopList {
+		if e.Active(now) {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ItemID < entries[j].ItemID
+	})
+
+	return entries
+}
+
+// SetAvailability stores item availability in the source and applies
+// it to the cache immediately. Unavailable item stays in the stop list
+// until given time, or until made available if until is zero.
+func (c *Cache) SetAvailability(ctx context.Context, itemID int, available bool, until time.Time) error {
+	sl, ok := c.src.(StopListSource)
+	if !ok {
+		return errors.New("catalog source does not support stop list")
+	}
+
+	var err error
+	e := StopEntry{ItemID: itemID, Until: until}
+	if available {
+		err = sl.DeleteStopEntry(ctx, itemID)
+	} else {
+		err = sl.PutStopEntry(ctx, e)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update stop list: %w", err)
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	// copy so refreshStopList swap stays the only writer of the map
+	stopList := make(map[int]StopEntry, len(c.stopList)+1)
+	for id, e := range c.stopList {
+		stopList[id] = e
+	}
+	if available {
+		delete(stopList, itemID)
+	} else {
+		stopList[itemID] = e
+	}
+	c.stopList = stopList
+
+	return nil
+}
+
+// GetAlternatives returns up to limit available items sharing
+// a category with the item
+func (c *Cache) GetAlternatives(itemID, limit int) []*Item {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	now := c.now()
+	seen := map[int]bool{itemID: true}
+	res := []*Item{}
+	for _, cat := range c.data.categories {
+		if !containsInt(cat.Products, itemID) {
 			continue
 		}
+		for _, id := range cat.Products {
+			item, ok := c.data.items[id]
+			if !ok || seen[id] || !c.isAvailable(id) || !c.isOffered(id, now) {
+				continue
+			}
+			seen[id] = true
+			res = append(res, item)
+			if len(res) == limit {
+				return res
+			}
+		}
+	}
+
+	return res
+}
 
-		c.mux.Lock()
-		c.data = data
-		c.mux.Unlock()
+// containsInt reports whether slice contains v
+func containsInt(slice []int, v int) bool {
+	for _, e := range slice {
+		if e == v {
+			return true
+		}
 	}
+	return false
 }
 
-// GetCategoriesPage returns one page of categories from cache
+// GetCategoriesPage returns one page of categories offered now,
+// ones with pinned and popular items first
 func (c *Cache) GetCategoriesPage(pageNum, pageSize int) []*Category {
 	c.mux.RLock()
 	defer c.mux.RUnlock()
 
-	if len(c.data.categories) < pageNum*pageSize {
+	return categoriesPage(c.openCategories(c.data.categories), pageNum, pageSize)
+}
+
+// openCategories returns ranked categories which schedules are open now.
+// Must be called with mux held.
+func (c *Cache) openCategories(cats []*Category) []*Category {
+	now := c.now()
+	res := make([]*Category, 0, len(cats))
+	for _, cat := range cats {
+		if c.categoryOpen(cat, now) {
+			res = append(res, cat)
+		}
+	}
+	c.rankCategories(res)
+	return res
+}
+
+// categoriesPage returns one page of categories slice
+func categoriesPage(cats []*Category, pageNum, pageSize int) []*Category {
+	if len(cats) < pageNum*pageSize {
 		return nil
 	}
 
 	from := pageNum * pageSize
 	to := (pageNum + 1) * pageSize
-	if to > len(c.data.categories) {
-		to = len(c.data.categories)
+	if to > len(cats) {
+		to = len(cats)
 	}
 
-	return c.data.categories[from:to]
+	return cats[from:to]
 }
 
-// GetItemsPage returns one page of category's items from cache
-func (c *Cache) GetItemsPage(categoryName string, pageNum, pageSize int) ([]*Item, error) {
+// GetItemsPage returns one page of category's items from cache.
+// Only available items offered now and passing every filter are paged,
+// pinned and popular items first.
+// ErrNotOffered is returned if category schedule is closed.
+func (c *Cache) GetItemsPage(
+	categoryName string,
+	pageNum, pageSize int,
+	filters ...ItemFilter,
+) ([]*Item, error) {
 	c.mux.RLock()
 	defer c.mux.RUnlock()
 
@@ -133,34 +770,68 @@ func (c *Cache) GetItemsPage(categoryName string, pageNum, pageSize int) ([]*Ite
 	if !ok {
 		return nil, sql.ErrNoRows
 	}
-
-	if len(cat.Products) < pageNum*pageSize {
-		return nil, nil
-	}
-
-	from := pageNum * pageSize
-	to := (pageNum + 1) * pageSize
-	if to > len(cat.Products) {
-		to = len(cat.Products)
+	now := c.now()
+	if !c.categoryOpen(cat, now) {
+		return nil, ErrNotOffered
 	}
 
-	// TODO: can preallocate len
-	// and/or cache pages
-	products := []*Item{}
-	for i := from; i < to; i++ {
-		item, ok := c.data.items[cat.Products[i]]
+	products := make([]*Item, 0, len(cat.Products))
+	for _, id := range cat.Products {
+		item, ok := c.data.items[id]
 		if !ok {
 			log.Printf(
 				"ERROR: product_id %d from category_id %d is not found",
-				cat.Products[i],
+				id,
 				cat.ID,
 			)
 			continue
 		}
-		products = append(products, item)
+		if c.isAvailable(id) && c.isOffered(id, now) && matchFilters(item, filters) {
+			products = append(products, item)
+		}
 	}
+	c.rankItems(products)
 
-	return products, nil
+	return itemsPage(products, pageNum, pageSize), nil
+}
+
+// GetAllItemsPage returns one page of all available menu items
+// offered now and passing every filter, pinned and popular items first
+func (c *Cache) GetAllItemsPage(pageNum, pageSize int, filters ...ItemFilter) []*Item {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	return itemsPage(c.allItems(filters), pageNum, pageSize)
+}
+
+// allItems returns ranked items available, offered now and passing
+// every filter, cache must be locked
+func (c *Cache) allItems(filters []ItemFilter) []*Item {
+	now := c.now()
+	items := make([]*Item, 0, len(c.data.itemsList))
+	for _, item := range c.data.itemsList {
+		if c.isAvailable(item.ID) && c.isOffered(item.ID, now) && matchFilters(item, filters) {
+			items = append(items, item)
+		}
+	}
+	c.rankItems(items)
+
+	return items
+}
+
+// itemsPage returns one page of items slice
+func itemsPage(items []*Item, pageNum, pageSize int) []*Item {
+	if len(items) < pageNum*pageSize {
+		return nil
+	}
+
+	from := pageNum * pageSize
+	to := (pageNum + 1) * pageSize
+	if to > len(items) {
+		to = len(items)
+	}
+
+	return items[from:to]
 }
 
 // GetItem returns menu item by its name
@@ -175,3 +846,103 @@ func (c *Cache) GetItem(itemName string) (*Item, error) {
 
 	return item, nil
 }
+
+// FindItems returns up to limit menu items fuzzy matching
+// the name, best match first
+func (c *Cache) FindItems(itemName string, limit int) []ItemMatch {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	matches := c.data.itemMatcher.match(itemName, limit)
+	res := make([]ItemMatch, len(matches))
+	for i, m := range matches {
+		res[i] = ItemMatch{Item: c.data.itemsList[m.idx], Score: m.score}
+	}
+
+	return res
+}
+
+// FindCategories returns up to limit categories fuzzy matching
+// the name, best match first
+func (c *Cache) FindCategories(categoryName string, limit int) []CategoryMatch {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	matches := c.data.categoryMatcher.match(categoryName, limit)
+	res := make([]CategoryMatch, len(matches))
+	for i, m := range matches {
+		res[i] = CategoryMatch{Category: c.data.categories[m.idx], Score: m.score}
+	}
+
+	return res
+}
+
+// SearchItems returns one page of menu items which names,
+// descriptions or compositions match the query, available ones
+// offered now and passing every filter.
+// Words following "без" in the query exclude ingredients, query of
+// exclusions only like "что-нибудь без орехов" lists all items without them.
+func (c *Cache) SearchItems(query string, pageNum, pageSize int, filters ...ItemFilter) []*Item {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	query, excluded := SplitExclusions(query)
+	if len(excluded) > 0 {
+		filters = append(filters, ExcludeIngredients(excluded...))
+		if len(searchTerms(query)) == 0 {
+			return itemsPage(c.allItems(filters), pageNum, pageSize)
+		}
+	}
+
+	now := c.now()
+	ids := c.data.searchIndex.search(query)
+	items := make([]*Item, 0, len(ids))
+	for _, id := range ids {
+		if item := c.data.items[id]; c.isAvailable(id) && c.isOffered(id, now) && matchFilters(item, filters) {
+			items = append(items, item)
+		}
+	}
+
+	return itemsPage(items, pageNum, pageSize)
+}
+
+// GetSubcategoriesPage returns one page of category's subcategories
+// offered now. Empty categoryName stands for the menu root, so top-level
+// categories are returned. ErrNotOffered is returned if category
+// schedule is closed.
+func (c *Cache) GetSubcategoriesPage(categoryName string, pageNum, pageSize int) ([]*Category, error) {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	parentID := 0
+	if categoryName != "" {
+		cat, ok := c.data.categoriesByName[strings.ToLower(categoryName)]
+		if !ok {
+			return nil, sql.ErrNoRows
+		}
+		if !c.categoryOpen(cat, c.now()) {
+			return nil, ErrNotOffered
+		}
+		parentID = cat.ID
+	}
+
+	return categoriesPage(c.openCategories(c.data.children[parentID]), pageNum, pageSize), nil
+}
+
+// GetParentCategory returns parent of the category
+// or nil if category is a top-level one
+func (c *Cache) GetParentCategory(categoryName string) (*Category, error) {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	cat, ok := c.data.categoriesByName[strings.ToLower(categoryName)]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	if cat.ParentID == cat.ID {
+		return nil, nil
+	}
+
+	return c.data.categoriesByID[cat.ParentID], nil
+}
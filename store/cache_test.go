@@ -7,13 +7,22 @@ import (
 	"github.com/google/go-cmp/cmp"
 )
 
-func TestGetCategoriesPage(t *testing.T) {
-	ctx := context.Background()
-	c, err := NewCache(ctx)
+const testCatalog = "testdata/catalog.json"
+
+// newTestCache returns a Cache populated from testdata catalog
+func newTestCache(t *testing.T) *Cache {
+	t.Helper()
+	c, err := NewCache(context.Background(), NewFileSource(testCatalog))
 	if err != nil {
 		t.Fatalf("unexpected error in NewCache: %v", err)
 	}
 
+	return c
+}
+
+func TestGetCategoriesPage(t *testing.T) {
+	c := newTestCache(t)
+
 	cats1 := c.GetCategoriesPage(0, 2)
 	if len(cats1) != 2 {
 		t.Fatalf("unexpected len=%d from GetCategoriesPage (expected 2)", len(cats1))
@@ -32,11 +41,7 @@ func TestGetCategoriesPage(t *testing.T) {
 }
 
 func TestGetItemsPage(t *testing.T) {
-	ctx := context.Background()
-	c, err := NewCache(ctx)
-	if err != nil {
-		t.Fatalf("unexpected error in NewCache: %v", err)
-	}
+	c := newTestCache(t)
 
 	items, err := c.GetItemsPage("Блины", 0, 3)
 	if err != nil {
@@ -51,11 +56,7 @@ func TestGetItemsPage(t *testing.T) {
 }
 
 func TestGetItem(t *testing.T) {
-	ctx := context.Background()
-	c, err := NewCache(ctx)
-	if err != nil {
-		t.Fatalf("unexpected error in NewCache: %v", err)
-	}
+	c := newTestCache(t)
 
 	item, err := c.GetItem("Блинчики")
 	if err != nil {
@@ -68,3 +69,80 @@ func TestGetItem(t *testing.T) {
 		t.Fatalf("unexpected item.Name=%s from GetItem (expected Блинчики)", item.Name)
 	}
 }
+
+func TestSynonyms(t *testing.T) {
+	c := newTestCache(t)
+
+	item, err := c.GetItem("Панкейки")
+	if err != nil {
+		t.Fatalf("unexpected error in GetItem: %v", err)
+	}
+	if item.Name != "Блинчики" {
+		t.Errorf("expected Блинчики by synonym, got %s", item.Name)
+	}
+
+	items, err := c.GetItemsPage("питье", 0, 10)
+	if err != nil {
+		t.Fatalf("unexpected error in GetItemsPage: %v", err)
+	}
+	if len(items) != 2 {
+		t.Errorf("expected 2 drinks by category synonym, got %d", len(items))
+	}
+}
+
+func TestGetSubcategoriesPage(t *testing.T) {
+	c := newTestCache(t)
+
+	top, err := c.GetSubcategoriesPage("", 0, 10)
+	if err != nil {
+		t.Fatalf("unexpected error in GetSubcategoriesPage: %v", err)
+	}
+	if len(top) != 4 {
+		t.Fatalf("unexpected len=%d of top-level categories (expected 4)", len(top))
+	}
+
+	subs, err := c.GetSubcategoriesPage("блины", 0, 10)
+	if err != nil {
+		t.Fatalf("unexpected error in GetSubcategoriesPage: %v", err)
+	}
+	if len(subs) != 2 || subs[0].Name != "Сладкие блины" || subs[1].Name != "Блины с начинкой" {
+		t.Errorf("unexpected subcategories of Блины: %v", subs)
+	}
+
+	subs, err = c.GetSubcategoriesPage("Блины", 1, 1)
+	if err != nil {
+		t.Fatalf("unexpected error in GetSubcategoriesPage: %v", err)
+	}
+	if len(subs) != 1 || subs[0].Name != "Блины с начинкой" {
+		t.Errorf("unexpected second page of Блины subcategories: %v", subs)
+	}
+
+	subs, err = c.GetSubcategoriesPage("Супы", 0, 10)
+	if err != nil || len(subs) != 0 {
+		t.Errorf("expected no subcategories of Супы, got %v, %v", subs, err)
+	}
+
+	if _, err := c.GetSubcategoriesPage("Пицца", 0, 10); err == nil {
+		t.Error("expected error for unknown category")
+	}
+}
+
+func TestGetParentCategory(t *testing.T) {
+	c := newTestCache(t)
+
+	parent, err := c.GetParentCategory("Сладкие блины")
+	if err != nil {
+		t.Fatalf("unexpected error in GetParentCategory: %v", err)
+	}
+	if parent == nil || parent.Name != "Блины" {
+		t.Errorf("unexpected parent of Сладкие блины: %v", parent)
+	}
+
+	parent, err = c.GetParentCategory("Блины")
+	if err != nil {
+		t.Fatalf("unexpected error in GetParentCategory: %v", err)
+	}
+	if parent != nil {
+		t.Errorf("expected top-level category to have no parent, got %v", parent)
+	}
+}
@@ -0,0 +1,135 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// Catalog is a plain copy of the menu loaded in the cache
+type Catalog struct {
+	Categories []*Category `json:"categories"`
+	Items      []*Item     `json:"products"`
+}
+
+// CacheStats describes cache state
+type CacheStats struct {
+	UpdatedAt  time.Time `json:"updated_at"`
+	Age        string    `json:"age"`
+	Version    uint64    `json:"version"`
+	Categories int       `json:"categories"`
+	Items      int       `json:"items"`
+	// LastError is the last failed refresh error, empty if the
+	// latest refresh succeeded
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+	// Stale is true when cache serves data from a snapshot file
+	// as the source was unreachable at start
+	Stale bool `json:"stale"`
+	// ValidationErrors and ValidationWarnings are counts from
+	// the latest catalog validation
+	ValidationErrors   int `json:"validation_errors"`
+	ValidationWarnings int `json:"validation_warnings"`
+}
+
+// Stats returns cache state for monitoring
+func (c *Cache) Stats() CacheStats {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	st := CacheStats{
+		UpdatedAt:  c.updatedAt,
+		Age:        time.Since(c.updatedAt).Truncate(time.Second).String(),
+		Version:    c.data.version,
+		Categories: len(c.data.categories),
+		Items:      len(c.data.items),
+		Stale:      c.stale,
+
+		ValidationErrors:   c.report.Errors,
+		ValidationWarnings: c.report.Warnings,
+	}
+	if c.lastErr != nil {
+		at := c.lastErrAt
+		st.LastError = c.lastErr.Error()
+		st.LastErrorAt = &at
+	}
+
+	return st
+}
+
+// ValidationReport returns the latest catalog validation report,
+// including the refused one
+func (c *Cache) ValidationReport() ValidationReport {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	return c.report
+}
+
+// Catalog returns menu the cache serves now
+func (c *Cache) Catalog() Catalog {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	return c.data.catalog()
+}
+
+// catalog returns a plain copy of cache data, items sorted by ID
+func (c *cacheData) catalog() Catalog {
+	cat := Catalog{
+		Categories: make([]*Category, len(c.categories)),
+		Items:      make([]*Item, 0, len(c.items)),
+	}
+	copy(cat.Categories, c.categories)
+	for _, item := range c.items {
+		cat.Items = append(cat.Items, item)
+	}
+	sort.Slice(cat.Items, func(i, j int) bool {
+		return cat.Items[i].ID < cat.Items[j].ID
+	})
+
+	return cat
+}
+
+// LoadCatalog reads catalog from the source as is, without validation.
+// Malformed documents are skipped and returned as DocumentErrors
+// along with the rest of the catalog.
+func LoadCatalog(ctx context.Context, src Source) (Catalog, error) {
+	cat := Catalog{}
+
+	var docErrs, errs DocumentErrors
+	cats, err := src.GetCategories(ctx)
+	if errors.As(err, &errs) {
+		docErrs = append(docErrs, errs...)
+	} else if err != nil {
+		return cat, err
+	}
+
+	items, err := src.GetItems(ctx)
+	if errors.As(err, &errs) {
+		docErrs = append(docErrs, errs...)
+	} else if err != nil {
+		return cat, err
+	}
+
+	data := cacheData{categories: cats, items: items}
+	cat = data.catalog()
+	if len(docErrs) > 0 {
+		return cat, docErrs
+	}
+	return cat, nil
+}
+
+// LoadValidCatalog reads catalog from the source validating it the
+// way the cache does, invalid items are quarantined and reported.
+// Nothing is indexed, watched or written, so maintenance commands
+// use it to read the catalog customers get.
+func LoadValidCatalog(ctx context.Context, src Source) (Catalog, ValidationReport, error) {
+	data := new(cacheData)
+	report, err := data.load(ctx, src)
+	if err != nil {
+		return Catalog{}, report, err
+	}
+	return data.catalog(), report, nil
+}
@@ -0,0 +1,59 @@
+package store
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+)
+
+func TestRefreshAndStats(t *testing.T) {
+	c, cleanup := newTempCache(t)
+	defer cleanup()
+
+	st := c.Stats()
+	if st.Categories != 6 || st.Items != 10 || st.LastError != "" || st.UpdatedAt.IsZero() {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+
+	// broken catalog file keeps old data and reports the error
+	path := c.src.(*FileSource).path
+	if err := ioutil.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatalf("failed to break catalog: %v", err)
+	}
+	if err := c.Refresh(context.Background()); err == nil {
+		t.Fatal("expected refresh error")
+	}
+	st = c.Stats()
+	if st.LastError == "" || st.LastErrorAt == nil || st.Items != 10 {
+		t.Errorf("unexpected stats after failed refresh: %+v", st)
+	}
+
+	b, err := ioutil.ReadFile(testCatalog)
+	if err != nil {
+		t.Fatalf("failed to read catalog: %v", err)
+	}
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		t.Fatalf("failed to restore catalog: %v", err)
+	}
+	if err := c.Refresh(context.Background()); err != nil {
+		t.Fatalf("unexpected error in Refresh: %v", err)
+	}
+	if st := c.Stats(); st.LastError != "" {
+		t.Errorf("expected last error to be cleared, got %q", st.LastError)
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	c := newTestCache(t)
+
+	cat := c.Catalog()
+	if len(cat.Categories) != 6 || len(cat.Items) != 10 {
+		t.Fatalf("unexpected catalog size: %d categories, %d items",
+			len(cat.Categories), len(cat.Items))
+	}
+	for i := 1; i < len(cat.Items); i++ {
+		if cat.Items[i-1].ID > cat.Items[i].ID {
+			t.Errorf("items are not sorted by ID")
+		}
+	}
+}
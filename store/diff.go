@@ -0,0 +1,207 @@
+package store
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// maxDiffValue is the longest field value printed in diff
+const maxDiffValue = 40
+
+// DiffOp is a kind of catalog document change
+type DiffOp string
+
+// Diff operations
+const (
+	DiffAdded   DiffOp = "+"
+	DiffRemoved DiffOp = "-"
+	DiffChanged DiffOp = "~"
+)
+
+// DiffEntry is a single added, removed or changed catalog document
+type DiffEntry struct {
+	Op DiffOp `json:"op"`
+	// Collection is "categories" or "products"
+	Collection string `json:"collection"`
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	// Changes describe changed fields like "price: 150 руб. → 170 руб."
+	Changes []string `json:"changes,omitempty"`
+}
+
+func (e DiffEntry) String() string {
+	s := fmt.Sprintf("%s %s %d %s", e.Op, e.Collection, e.ID, e.Name)
+	if len(e.Changes) > 0 {
+		s += ": " + strings.Join(e.Changes, ", ")
+	}
+	return s
+}
+
+// DiffCatalogs returns changes turning old catalog into new one,
+// documents are matched by ID. Categories come first, then products,
+// both ordered by ID.
+func DiffCatalogs(old, new Catalog) []DiffEntry {
+	res := []DiffEntry{}
+
+	ids := []int{}
+	oldCats := map[int]*Category{}
+	for _, cat := range old.Categories {
+		oldCats[cat.ID] = cat
+		ids = append(ids, cat.ID)
+	}
+	newCats := map[int]*Category{}
+	for _, cat := range new.Categories {
+		newCats[cat.ID] = cat
+		ids = append(ids, cat.ID)
+	}
+	for _, id := range uniqueIDs(ids) {
+		o, n := oldCats[id], newCats[id]
+		switch {
+		case o == nil:
+			res = append(res, DiffEntry{Op: DiffAdded, Collection: "categories", ID: id, Name: n.Name})
+		case n == nil:
+			res = append(res, DiffEntry{Op: DiffRemoved, Collection: "categories", ID: id, Name: o.Name})
+		default:
+			if changes := categoryChanges(o, n); len(changes) > 0 {
+				res = append(res, DiffEntry{
+					Op:         DiffChanged,
+					Collection: "categories",
+					ID:         id,
+					Name:       n.Name,
+					Changes:    changes,
+				})
+			}
+		}
+	}
+
+	ids = ids[:0]
+	oldItems := map[int]*Item{}
+	for _, item := range old.Items {
+		oldItems[item.ID] = item
+		ids = append(ids, item.ID)
+	}
+	newItems := map[int]*Item{}
+	for _, item := range new.Items {
+		newItems[item.ID] = item
+		ids = append(ids, item.ID)
+	}
+	for _, id := range uniqueIDs(ids) {
+		o, n := oldItems[id], newItems[id]
+		switch {
+		case o == nil:
+			res = append(res, DiffEntry{Op: DiffAdded, Collection: "products", ID: id, Name: n.Name})
+		case n == nil:
+			res = append(res, DiffEntry{Op: DiffRemoved, Collection: "products", ID: id, Name: o.Name})
+		default:
+			if changes := itemChanges(o, n); len(changes) > 0 {
+				res = append(res, DiffEntry{
+					Op:         DiffChanged,
+					Collection: "products",
+					ID:         id,
+					Name:       n.Name,
+					Changes:    changes,
+				})
+			}
+		}
+	}
+
+	return res
+}
+
+// uniqueIDs returns sorted IDs without duplicates
+func uniqueIDs(ids []int) []int {
+	sort.Ints(ids)
+	res := []int{}
+	for i, id := range ids {
+		if i == 0 || id != ids[i-1] {
+			res = append(res, id)
+		}
+	}
+	return res
+}
+
+// fieldChange describes changed field, long texts are not printed
+func fieldChange(name string, o, n interface{}) string {
+	ov, nv := diffValue(o), diffValue(n)
+	if len([]rune(ov)) > maxDiffValue || len([]rune(nv)) > maxDiffValue {
+		return name
+	}
+	return fmt.Sprintf("%s: %s → %s", name, ov, nv)
+}
+
+// diffValue prints field value, empty strings are quoted
+func diffValue(v interface{}) string {
+	s := fmt.Sprint(v)
+	if s == "" {
+		return `""`
+	}
+	return s
+}
+
+func categoryChanges(o, n *Category) []string {
+	res := []string{}
+	if o.Name != n.Name {
+		res = append(res, fieldChange("name", o.Name, n.Name))
+	}
+	if o.ParentID != n.ParentID {
+		res = append(res, fieldChange("parent_id", o.ParentID, n.ParentID))
+	}
+	if o.Icon != n.Icon {
+		res = append(res, fieldChange("icon", o.Icon, n.Icon))
+	}
+	if !equalStrings(o.Synonyms, n.Synonyms) {
+		res = append(res, fieldChange("synonyms", o.Synonyms, n.Synonyms))
+	}
+	if !reflect.DeepEqual(o.Products, n.Products) {
+		res = append(res, fieldChange("products", o.Products, n.Products))
+	}
+	if !reflect.DeepEqual(o.Schedule, n.Schedule) {
+		res = append(res, "schedule")
+	}
+	return res
+}
+
+func itemChanges(o, n *Item) []string {
+	res := []string{}
+	if o.Name != n.Name {
+		res = append(res, fieldChange("name", o.Name, n.Name))
+	}
+	if o.Price != n.Price {
+		res = append(res, fieldChange("price", o.Price, n.Price))
+	}
+	if o.Image != n.Image {
+		res = append(res, fieldChange("image", o.Image, n.Image))
+	}
+	if o.Composition != n.Composition {
+		res = append(res, fieldChange("composition", o.Composition, n.Composition))
+	}
+	if o.Description != n.Description {
+		res = append(res, fieldChange("description", o.Description, n.Description))
+	}
+	if !equalStrings(o.Synonyms, n.Synonyms) {
+		res = append(res, fieldChange("synonyms", o.Synonyms, n.Synonyms))
+	}
+	if !reflect.DeepEqual(o.OptionGroups, n.OptionGroups) {
+		res = append(res, "options")
+	}
+	if !reflect.DeepEqual(o.Schedule, n.Schedule) {
+		res = append(res, "schedule")
+	}
+	return res
+}
+
+// equalStrings reports whether a and b have the same strings,
+// nil and empty are equal
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
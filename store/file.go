@@ -0,0 +1,316 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileSource loads menu catalog from a local JSON or YAML file.
+// File holds the same documents Firestore "categories" and "products"
+// collections do, so they are mapped with the same code:
+//
+//	categories:
+//	  - category_id: "1"
+//	    parent_id: "0"
+//	    name: Блины
+//	    icon: ""
+//	    products:
+//	      - product_id: "10"
+//	products:
+//	  - product_id: "10"
+//	    name: Блинчики
+//	    ...
+//
+// Stop list is kept in a JSON file next to the catalog:
+// catalog.yaml stop list is catalog.stoplist.json.
+type FileSource struct {
+	path string
+	// schema renames catalog document fields
+	schema Schema
+	// mux guards stop list file read-modify-write
+	mux sync.Mutex
+}
+
+// catalogFile is a FileSource file contents
+type catalogFile struct {
+	Categories []map[string]interface{} `json:"categories" yaml:"categories"`
+	Products   []map[string]interface{} `json:"products" yaml:"products"`
+}
+
+// NewFileSource returns a new FileSource reading catalog from path.
+// Format is chosen by file extension: .yaml/.yml or .json.
+func NewFileSource(path string) *FileSource {
+	return &FileSource{path: path}
+}
+
+// WithSchema returns FileSource reading the same file
+// with document fields renamed by schema
+func (fs *FileSource) WithSchema(s Schema) *FileSource {
+	return &FileSource{path: fs.path, schema: s}
+}
+
+// read reads and decodes catalog file
+// file is read on every call so refreshes pick up changes
+func (fs *FileSource) read() (catalogFile, error) {
+	f := catalogFile{}
+
+	b, err := ioutil.ReadFile(fs.path)
+	if err != nil {
+		return f, fmt.Errorf("failed to read catalog file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(fs.path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &f)
+	default:
+		err = json.Unmarshal(b, &f)
+	}
+	if err != nil {
+		return f, fmt.Errorf("failed to decode catalog file %s: %w", fs.path, err)
+	}
+
+	return f, nil
+}
+
+// GetCategories returns categories from catalog file
+func (fs *FileSource) GetCategories(ctx context.Context) ([]*Category, error) {
+	f, err := fs.read()
+	if err != nil {
+		return nil, err
+	}
+
+	cats := []*Category{}
+	var docErrs DocumentErrors
+	for i, m := range f.Categories {
+		cat, err := mapToCategory(m, fs.schema)
+		if err != nil {
+			docErrs = append(docErrs, DocumentError{"categories", docID(m, fs.schema.key("category_id"), i), err})
+			continue
+		}
+
+		cats = append(cats, &cat)
+	}
+	if len(docErrs) > 0 {
+		return cats, docErrs
+	}
+	return cats, nil
+}
+
+// GetItems returns menu items from catalog file
+func (fs *FileSource) GetItems(ctx context.Context) (map[int]*Item, error) {
+	f, err := fs.read()
+	if err != nil {
+		return nil, err
+	}
+
+	items := make(map[int]*Item)
+	var docErrs DocumentErrors
+	for i, m := range f.Products {
+		item, err := mapToItem(m, fs.schema)
+		if err != nil {
+			docErrs = append(docErrs, DocumentError{"products", docID(m, fs.schema.key("product_id"), i), err})
+			continue
+		}
+
+		items[item.ID] = &item
+	}
+	if len(docErrs) > 0 {
+		return items, docErrs
+	}
+	return items, nil
+}
+
+// stopListPath returns stop list file path next to the catalog file
+func (fs *FileSource) stopListPath() string {
+	return strings.TrimSuffix(fs.path, filepath.Ext(fs.path)) + ".stoplist.json"
+}
+
+// readStopList reads stop list file, missing file is an empty stop list
+func (fs *FileSource) readStopList() ([]StopEntry, error) {
+	entries := []StopEntry{}
+
+	b, err := ioutil.ReadFile(fs.stopListPath())
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stop list file: %w", err)
+	}
+
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode stop list file: %w", err)
+	}
+
+	return entries, nil
+}
+
+// writeStopList replaces stop list file contents
+func (fs *FileSource) writeStopList(entries []StopEntry) error {
+	b, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write to temp file first, so readers never see partial file
+	tmp := fs.stopListPath() + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return fmt.Errorf("failed to write stop list file: %w", err)
+	}
+
+	return os.Rename(tmp, fs.stopListPath())
+}
+
+// GetStopList returns stop list entries from the stop list file
+func (fs *FileSource) GetStopList(ctx context.Context) ([]StopEntry, error) {
+	fs.mux.Lock()
+	defer fs.mux.Unlock()
+
+	return fs.readStopList()
+}
+
+// PutStopEntry adds or replaces item stop list entry in the file
+func (fs *FileSource) PutStopEntry(ctx context.Context, e StopEntry) error {
+	fs.mux.Lock()
+	defer fs.mux.Unlock()
+
+	entries, err := fs.readStopList()
+	if err != nil {
+		return err
+	}
+
+	for i := range entries {
+		if entries[i].ItemID == e.ItemID {
+			entries[i] = e
+			return fs.writeStopList(entries)
+		}
+	}
+
+	return fs.writeStopList(append(entries, e))
+}
+
+// DeleteStopEntry removes item from the stop list file
+func (fs *FileSource) DeleteStopEntry(ctx context.Context, itemID int) error {
+	fs.mux.Lock()
+	defer fs.mux.Unlock()
+
+	entries, err := fs.readStopList()
+	if err != nil {
+		return err
+	}
+
+	res := entries[:0]
+	for _, e := range entries {
+		if e.ItemID != itemID {
+			res = append(res, e)
+		}
+	}
+
+	return fs.writeStopList(res)
+}
+
+// pinsPath returns pinned items file path next to the catalog file
+func (fs *FileSource) pinsPath() string {
+	return strings.TrimSuffix(fs.path, filepath.Ext(fs.path)) + ".pins.json"
+}
+
+// GetPins returns pinned item IDs from the pins file,
+// missing file means nothing is pinned
+func (fs *FileSource) GetPins(ctx context.Context) ([]int, error) {
+	fs.mux.Lock()
+	defer fs.mux.Unlock()
+
+	ids := []int{}
+	b, err := ioutil.ReadFile(fs.pinsPath())
+	if os.IsNotExist(err) {
+		return ids, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pins file: %w", err)
+	}
+
+	if err := json.Unmarshal(b, &ids); err != nil {
+		return nil, fmt.Errorf("failed to decode pins file: %w", err)
+	}
+	return ids, nil
+}
+
+// SetPins replaces pinned items file contents
+func (fs *FileSource) SetPins(ctx context.Context, ids []int) error {
+	fs.mux.Lock()
+	defer fs.mux.Unlock()
+
+	b, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+
+	tmp := fs.pinsPath() + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return fmt.Errorf("failed to write pins file: %w", err)
+	}
+
+	return os.Rename(tmp, fs.pinsPath())
+}
+
+// newCatalogFile returns file contents holding documents the way
+// mapToCategory and mapToItem read them
+func newCatalogFile(c Catalog, s Schema) catalogFile {
+	f := catalogFile{
+		Categories: make([]map[string]interface{}, len(c.Categories)),
+		Products:   make([]map[string]interface{}, len(c.Items)),
+	}
+	for i, cat := range c.Categories {
+		f.Categories[i] = categoryToMap(cat, s)
+	}
+	for i, item := range c.Items {
+		f.Products[i] = itemToMap(item, s)
+	}
+	return f
+}
+
+// EncodeCatalogJSON writes c as a JSON catalog file FileSource reads
+func EncodeCatalogJSON(w io.Writer, c Catalog) error {
+	f := newCatalogFile(c, nil)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(&f)
+}
+
+// WriteCatalog replaces catalog file with c keeping its format
+func (fs *FileSource) WriteCatalog(ctx context.Context, c Catalog) error {
+	f := newCatalogFile(c, fs.schema)
+
+	var (
+		b   []byte
+		err error
+	)
+	switch strings.ToLower(filepath.Ext(fs.path)) {
+	case ".yaml", ".yml":
+		b, err = yaml.Marshal(&f)
+	default:
+		b, err = json.MarshalIndent(&f, "", "  ")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode catalog: %w", err)
+	}
+
+	fs.mux.Lock()
+	defer fs.mux.Unlock()
+
+	tmp := fs.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return fmt.Errorf("failed to write catalog file: %w", err)
+	}
+
+	return os.Rename(tmp, fs.path)
+}
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"context"
+	"io/ioutil"
+	"mania/money"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFileSourceJSONAndYAML(t *testing.T) {
+	ctx := context.Background()
+	js := NewFileSource("testdata/catalog.json")
+	ys := NewFileSource("testdata/catalog.yaml")
+
+	jcats, err := js.GetCategories(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error in GetCategories(json): %v", err)
+	}
+	ycats, err := ys.GetCategories(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error in GetCategories(yaml): %v", err)
+	}
+	if diff := cmp.Diff(jcats, ycats); diff != "" {
+		t.Errorf("categories do differ:\n%s", diff)
+	}
+
+	jitems, err := js.GetItems(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error in GetItems(json): %v", err)
+	}
+	yitems, err := ys.GetItems(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error in GetItems(yaml): %v", err)
+	}
+	if diff := cmp.Diff(jitems, yitems); diff != "" {
+		t.Errorf("items do differ:\n%s", diff)
+	}
+
+	item, ok := jitems[101]
+	if !ok {
+		t.Fatal("expected item 101 to be loaded")
+	}
+	if item.Price != money.FromRubles(150) {
+		t.Errorf("unexpected item.Price=%v (expected 150)", item.Price)
+	}
+	if item.Description != "Тонкие блинчики на молоке" {
+		t.Errorf("expected description to be cleaned up, got %q", item.Description)
+	}
+	if item.Image != "https://example.com/img/101.jpg" {
+		t.Errorf("unexpected item.Image=%q", item.Image)
+	}
+}
+
+func TestFileSourceMissingFile(t *testing.T) {
+	fs := NewFileSource("testdata/does_not_exist.json")
+	if _, err := fs.GetCategories(context.Background()); err == nil {
+		t.Error("expected error for missing catalog file")
+	}
+}
+
+func TestFileSourceWriteCatalog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mania")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	for _, name := range []string{"catalog.json", "catalog.yaml"} {
+		path := filepath.Join(dir, name)
+		copyFile(t, filepath.Join("testdata", name), path)
+		fs := NewFileSource(path)
+
+		before, err := LoadCatalog(ctx, fs)
+		if err != nil {
+			t.Fatalf("unexpected error in LoadCatalog: %v", err)
+		}
+		before.Items[0].Price = money.FromRubles(999)
+		before.Categories = before.Categories[1:]
+		if err := fs.WriteCatalog(ctx, before); err != nil {
+			t.Fatalf("unexpected error in WriteCatalog: %v", err)
+		}
+
+		after, err := LoadCatalog(ctx, fs)
+		if err != nil {
+			t.Fatalf("unexpected error in LoadCatalog after write: %v", err)
+		}
+		if diff := DiffCatalogs(before, after); len(diff) != 0 {
+			t.Errorf("%s: written catalog differs: %v", name, diff)
+		}
+		if diff := cmp.Diff(before, after); diff != "" {
+			t.Errorf("%s: written catalog differs:\n%s", name, diff)
+		}
+	}
+}
@@ -4,33 +4,51 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"os"
 	"regexp"
 	"strconv"
+	"time"
+
+	"mania/money"
 
 	"cloud.google.com/go/firestore"
 	firebase "firebase.google.com/go"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // DB core object for firebase storage interface
 type DB struct {
 	app *firebase.App
 	cl  *firestore.Client
+	// tenant keeps catalog under tenants/{tenant} document,
+	// empty uses root collections
+	tenant string
+	// schema renames catalog document fields
+	schema Schema
 }
 
 // New creates new DB object
 func New(ctx context.Context) (*DB, error) {
+	// firestore client connects to the emulator without credentials,
+	// firebase app would fail looking for them
+	if os.Getenv("FIRESTORE_EMULATOR_HOST") != "" {
+		cl, err := firestore.NewClient(ctx, os.Getenv("GOOGLE_CLOUD_PROJECT"))
+		if err != nil {
+			return nil, fmt.Errorf("error connecting to emulator: %w", err)
+		}
+		return &DB{cl: cl}, nil
+	}
+
 	app, err := firebase.NewApp(ctx, nil)
 	if err != nil {
-		log.Fatalf("error initializing app: %v\n", err)
-		return nil, err
+		return nil, fmt.Errorf("error initializing app: %w", err)
 	}
 
 	cl, err := app.Firestore(ctx)
 	if err != nil {
-		log.Fatalf("error connecting to db: %v\n", err)
-		return nil, err
+		return nil, fmt.Errorf("error connecting to db: %w", err)
 	}
 
 	d := DB{
@@ -41,14 +59,44 @@ func New(ctx context.Context) (*DB, error) {
 	return &d, nil
 }
 
+// ForTenant returns DB sharing the client which reads and writes
+// tenant collections: tenants/{id}/categories and so on
+func (db *DB) ForTenant(id string) *DB {
+	return &DB{app: db.app, cl: db.cl, tenant: id, schema: db.schema}
+}
+
+// WithSchema returns DB sharing the client which reads catalog
+// documents with fields renamed by schema
+func (db *DB) WithSchema(s Schema) *DB {
+	return &DB{app: db.app, cl: db.cl, tenant: db.tenant, schema: s}
+}
+
+// collection returns collection reference for the DB tenant
+func (db *DB) collection(name string) *firestore.CollectionRef {
+	if db.tenant == "" {
+		return db.cl.Collection(name)
+	}
+	return db.cl.Collection("tenants").Doc(db.tenant).Collection(name)
+}
+
 // Item holds menu item data
 type Item struct {
 	ID          int `json:"product_id"`
 	Name        string
 	Image       string
-	Price       float64
+	Price       money.Money
 	Composition string
 	Description string
+	// Synonyms are other names customers call the item by
+	Synonyms []string `json:"synonyms,omitempty"`
+	// Nutrition is nil when composition lacks nutrients info
+	Nutrition *Nutrition
+	// Ingredients are lowercased composition entries
+	Ingredients []string
+	// OptionGroups are modifiers customer can choose
+	OptionGroups []OptionGroup `json:"option_groups"`
+	// Schedule limits when item is offered, nil is always
+	Schedule *Schedule `json:"schedule,omitempty"`
 }
 
 // Category is a menu category description
@@ -58,12 +106,17 @@ type Category struct {
 	Name     string
 	ParentID int `json:"parent_id"`
 	Products []int
+	// Synonyms are other names customers call the category by
+	Synonyms []string `json:"synonyms,omitempty"`
+	// Schedule limits when category is offered, nil is always
+	Schedule *Schedule `json:"schedule,omitempty"`
 }
 
 // GetCategories returns categories from Firestore
 func (db *DB) GetCategories(ctx context.Context) ([]*Category, error) {
 	cats := []*Category{}
-	iter := db.cl.Collection("categories").Documents(ctx)
+	var docErrs DocumentErrors
+	iter := db.collection("categories").Documents(ctx)
 	for {
 		doc, err := iter.Next()
 		if err == iterator.Done {
@@ -72,83 +125,25 @@ func (db *DB) GetCategories(ctx context.Context) ([]*Category, error) {
 		if err != nil {
 			return cats, err
 		}
-		cat, err := mapToCategory(doc.Data())
+		cat, err := mapToCategory(doc.Data(), db.schema)
 		if err != nil {
-			return cats, err
+			docErrs = append(docErrs, DocumentError{"categories", doc.Ref.ID, err})
+			continue
 		}
 
 		cats = append(cats, &cat)
 	}
-	return cats, nil
-}
-
-// mapToCategory deals with mess of an interfaces
-// firebase admin api returns
-// doc.DataAs does not work well out of the box, I checked :)
-func mapToCategory(m map[string]interface{}) (Category, error) {
-	cat := Category{}
-
-	s, ok := m["category_id"].(string)
-	if !ok {
-		return cat, errors.New("bad category id")
-	}
-	id, err := strconv.Atoi(s)
-	if err != nil {
-		return cat, fmt.Errorf("failed to convert category_id: %w", err)
-	}
-
-	cat.ID = id
-
-	s, ok = m["parent_id"].(string)
-	if !ok {
-		return cat, errors.New("bad category parent_id")
-	}
-
-	id, err = strconv.Atoi(s)
-	if err != nil {
-		return cat, err
-	}
-
-	cat.ParentID = id
-
-	cat.Name, ok = m["name"].(string)
-	if !ok {
-		return cat, errors.New("bad category name")
-	}
-	cat.Icon = m["icon"].(string)
-	if !ok {
-		return cat, errors.New("bad category icon")
-	}
-
-	products, ok := m["products"].([]interface{})
-	if !ok {
-		return cat, errors.New("bad products list")
-	}
-	for _, pi := range products {
-		p, ok := pi.(map[string]interface{})
-		if !ok {
-			return cat, errors.New("bad product value")
-		}
-		s, ok := p["product_id"].(string)
-		if !ok {
-			return cat, errors.New("bad product id")
-		}
-		id, err := strconv.Atoi(s)
-		if err != nil {
-			return cat, fmt.Errorf("failed to convert product_id: %w", err)
-		}
-
-		cat.Products = append(cat.Products, id)
-
+	if len(docErrs) > 0 {
+		return cats, docErrs
 	}
-
-	return cat, nil
+	return cats, nil
 }
 
 // GetItems returns menu items from Firestore
 func (db *DB) GetItems(ctx context.Context) (map[int]*Item, error) {
 	items := make(map[int]*Item)
-	iter := db.cl.Collection("products").Documents(ctx)
+	var docErrs DocumentErrors
+	iter := db.collection("products").Documents(ctx)
 	for {
 		doc, err := iter.Next()
 		if err == iterator.Done {
@@ -157,64 +152,107 @@ func (db *DB) GetItems(ctx context.Context) (map[int]*Item, error) {
 		if err != nil {
 			return items, err
 		}
-		item, err := mapToItem(doc.Data())
+		item, err := mapToItem(doc.Data(), db.schema)
 		if err != nil {
-			return items, err
+			docErrs = append(docErrs, DocumentError{"products", doc.Ref.ID, err})
+			continue
 		}
 
 		items[item.ID] = &item
 	}
+	if len(docErrs) > 0 {
+		return items, docErrs
+	}
 	return items, nil
 }
 
-// mapToItem deals with mess of an interfaces
-// firebase admin api returns
-// doc.DataAs does not work well out of the box, I checked :)
-func mapToItem(m map[string]interface{}) (Item, error) {
-	item := Item{}
+// batchSize is Firestore limit of writes in a batch
+const batchSize = 500
 
-	s, ok := m["product_id"].(string)
-	if !ok {
-		return item, errors.New("bad product id")
+// WriteCatalog replaces categories and products collections with c.
+// Existing documents are overwritten in place, documents missing
+// from c are deleted.
+func (db *DB) WriteCatalog(ctx context.Context, c Catalog) error {
+	cats := make(map[string]map[string]interface{}, len(c.Categories))
+	for _, cat := range c.Categories {
+		cats[strconv.Itoa(cat.ID)] = categoryToMap(cat, db.schema)
 	}
-	id, err := strconv.Atoi(s)
-	if err != nil {
-		return item, fmt.Errorf("failed to convert product_id: %w", err)
+	if err := db.replaceCollection(ctx, "categories", db.schema.key("category_id"), cats); err != nil {
+		return err
 	}
 
-	item.ID = id
-
-	item.Name, ok = m["name"].(string)
-	if !ok {
-		return item, errors.New("bad product name")
+	items := make(map[string]map[string]interface{}, len(c.Items))
+	for _, item := range c.Items {
+		items[strconv.Itoa(item.ID)] = itemToMap(item, db.schema)
 	}
-	item.Name = cleanupString(item.Name)
+	return db.replaceCollection(ctx, "products", db.schema.key("product_id"), items)
+}
 
-	item.Composition, ok = m["composition"].(string)
-	if !ok {
-		return item, errors.New("bad product composition")
+// replaceCollection sets docs by their ID field value and deletes
+// the rest of collection documents. New documents are named by ID.
+func (db *DB) replaceCollection(
+	ctx context.Context,
+	name string,
+	idKey string,
+	docs map[string]map[string]interface{},
+) error {
+	col := db.collection(name)
+	refs := make(map[string]*firestore.DocumentRef, len(docs))
+	stale := []*firestore.DocumentRef{}
+
+	iter := col.Documents(ctx)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		id := docID(doc.Data(), idKey, -1)
+		if _, ok := docs[id]; ok && refs[id] == nil {
+			refs[id] = doc.Ref
+			continue
+		}
+		stale = append(stale, doc.Ref)
 	}
-	item.Composition = fixNutrients(cleanupString(item.Composition))
 
-	item.Description, ok = m["description"].(string)
-	if !ok {
-		return item, errors.New("bad product description")
+	batch := db.cl.Batch()
+	writes := 0
+	commit := func() error {
+		if writes == 0 {
+			return nil
+		}
+		if _, err := batch.Commit(ctx); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		batch = db.cl.Batch()
+		writes = 0
+		return nil
 	}
-	item.Description = cleanupString(item.Description)
 
-	s, ok = m["price"].(string)
-	if !ok {
-		return item, errors.New("bad product price")
+	for id, m := range docs {
+		ref, ok := refs[id]
+		if !ok {
+			ref = col.Doc(id)
+		}
+		batch.Set(ref, m)
+		if writes++; writes == batchSize {
+			if err := commit(); err != nil {
+				return err
+			}
+		}
 	}
-
-	f, err := strconv.ParseFloat(s, 64)
-	if err != nil {
-		return item, fmt.Errorf("failed to convert product price: %w", err)
+	for _, ref := range stale {
+		batch.Delete(ref)
+		if writes++; writes == batchSize {
+			if err := commit(); err != nil {
+				return err
+			}
+		}
 	}
 
-	item.Price = f
-
-	return item, nil
+	return commit()
 }
 
 var (
@@ -238,3 +276,100 @@ func cleanupString(s string) string {
 func fixNutrients(s string) string {
 	return nutrientsRe.ReplaceAllString(s, " $1%, $2%, $3%, пищевая ценность: $4")
 }
+
+// GetStopList returns stop list entries from Firestore
+func (db *DB) GetStopList(ctx context.Context) ([]StopEntry, error) {
+	entries := []StopEntry{}
+	iter := db.collection("stoplist").Documents(ctx)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return entries, err
+		}
+		e, err := mapToStopEntry(doc.Data())
+		if err != nil {
+			return entries, err
+		}
+
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// PutStopEntry adds or replaces item stop list entry in Firestore
+func (db *DB) PutStopEntry(ctx context.Context, e StopEntry) error {
+	m := map[string]interface{}{
+		"product_id": strconv.Itoa(e.ItemID),
+	}
+	if !e.Until.IsZero() {
+		m["until"] = e.Until
+	}
+
+	_, err := db.collection("stoplist").Doc(strconv.Itoa(e.ItemID)).Set(ctx, m)
+	return err
+}
+
+// DeleteStopEntry removes item from the stop list in Firestore
+func (db *DB) DeleteStopEntry(ctx context.Context, itemID int) error {
+	_, err := db.collection("stoplist").Doc(strconv.Itoa(itemID)).Delete(ctx)
+	return err
+}
+
+// mapToStopEntry maps stoplist document to StopEntry
+func mapToStopEntry(m map[string]interface{}) (StopEntry, error) {
+	e := StopEntry{}
+
+	err := decodeFields(m, nil, []field{
+		{name: "product_id", required: true, decode: intField(&e.ItemID)},
+	})
+	if err != nil {
+		return e, fmt.Errorf("bad stoplist entry: %w", err)
+	}
+
+	if until, ok := m["until"].(time.Time); ok {
+		e.Until = until
+	}
+
+	return e, nil
+}
+
+// GetPins returns pinned item IDs from settings/pins document
+func (db *DB) GetPins(ctx context.Context) ([]int, error) {
+	ids := []int{}
+	doc, err := db.collection("settings").Doc("pins").Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return ids, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	list, ok := doc.Data()["product_ids"].([]interface{})
+	if !ok {
+		return nil, errors.New("bad pinned product_ids")
+	}
+	for _, v := range list {
+		id, err := toInt(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert pinned product_id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// SetPins replaces pinned item IDs in settings/pins document
+func (db *DB) SetPins(ctx context.Context, ids []int) error {
+	list := make([]string, len(ids))
+	for i, id := range ids {
+		list[i] = strconv.Itoa(id)
+	}
+
+	_, err := db.collection("settings").Doc("pins").Set(ctx, map[string]interface{}{
+		"product_ids": list,
+	})
+	return err
+}
@@ -0,0 +1,141 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FirestoreSessionStore keeps sessions in sessions collection of
+// the DB tenant, so all instances share them. Session is stored
+// JSON encoded in "data" field, "expires_at" suits Firestore TTL
+// policy as well.
+type FirestoreSessionStore struct {
+	db *DB
+}
+
+// NewFirestoreSessionStore returns store of db sessions collection
+func NewFirestoreSessionStore(db *DB) *FirestoreSessionStore {
+	return &FirestoreSessionStore{db: db}
+}
+
+// doc returns session document, Dialogflow session IDs
+// are paths and can not be document IDs as they are
+func (st *FirestoreSessionStore) doc(id string) *firestore.DocumentRef {
+	return st.db.collection("sessions").Doc(url.PathEscape(id))
+}
+
+// Get reads session document
+func (st *FirestoreSessionStore) Get(ctx context.Context, id string) (Session, int64, error) {
+	doc, err := st.doc(id).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return Session{}, 0, ErrNoSession
+	}
+	if err != nil {
+		return Session{}, 0, err
+	}
+
+	r, err := mapToSessionRecord(doc.Data())
+	if err != nil {
+		return Session{}, 0, err
+	}
+	if r.expired(time.Now()) {
+		return Session{}, 0, ErrNoSession
+	}
+	return r.Session, r.Version, nil
+}
+
+// Put writes session document in a transaction checking its version
+func (st *FirestoreSessionStore) Put(ctx context.Context, id string, s Session, version int64, expiresAt time.Time) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+
+	ref := st.doc(id)
+	return st.db.cl.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		current := int64(0)
+		doc, err := tx.Get(ref)
+		switch {
+		case status.Code(err) == codes.NotFound:
+		case err != nil:
+			return err
+		default:
+			r, err := mapToSessionRecord(doc.Data())
+			if err != nil {
+				return err
+			}
+			if !r.expired(time.Now()) {
+				current = r.Version
+			}
+		}
+		if current != version {
+			return ErrSessionConflict
+		}
+
+		return tx.Set(ref, map[string]interface{}{
+			"version":    version + 1,
+			"expires_at": expiresAt,
+			"data":       string(data),
+		})
+	})
+}
+
+// Expire deletes expired session documents
+func (st *FirestoreSessionStore) Expire(ctx context.Context, now time.Time) error {
+	iter := st.db.collection("sessions").Where("expires_at", "<=", now).Documents(ctx)
+	defer iter.Stop()
+
+	batch := st.db.cl.Batch()
+	writes := 0
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read expired sessions: %w", err)
+		}
+		batch.Delete(doc.Ref)
+		if writes++; writes == batchSize {
+			if _, err := batch.Commit(ctx); err != nil {
+				return fmt.Errorf("failed to delete expired sessions: %w", err)
+			}
+			batch = st.db.cl.Batch()
+			writes = 0
+		}
+	}
+	if writes == 0 {
+		return nil
+	}
+	if _, err := batch.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+	return nil
+}
+
+// mapToSessionRecord maps sessions document to sessionRecord
+func mapToSessionRecord(m map[string]interface{}) (sessionRecord, error) {
+	r := sessionRecord{}
+	version, data := 0, ""
+	err := decodeFields(m, nil, []field{
+		{name: "version", required: true, decode: intField(&version)},
+		{name: "expires_at", required: true, decode: timeField(&r.ExpiresAt)},
+		{name: "data", required: true, decode: stringField(&data)},
+	})
+	if err != nil {
+		return r, fmt.Errorf("bad session document: %w", err)
+	}
+	r.Version = int64(version)
+	if err := json.Unmarshal([]byte(data), &r.Session); err != nil {
+		return r, fmt.Errorf("failed to decode session: %w", err)
+	}
+	return r, nil
+}
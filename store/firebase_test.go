@@ -1,12 +1,46 @@
 package store
 
 import (
+	"os"
 	"testing"
 
+	"cloud.google.com/go/firestore"
 	"golang.org/x/net/context"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
 )
 
+// skipWithoutFirestore skips tests which need Firestore access
+// (credentials or emulator)
+func skipWithoutFirestore(t *testing.T) {
+	t.Helper()
+	if os.Getenv("GOOGLE_APPLICATION_CREDENTIALS") == "" &&
+		os.Getenv("FIRESTORE_EMULATOR_HOST") == "" {
+		t.Skip("no Firestore credentials or emulator configured")
+	}
+}
+
+func TestCollection(t *testing.T) {
+	// client connects lazily, references are built without Firestore
+	cl, err := firestore.NewClient(context.Background(), "test",
+		option.WithoutAuthentication(), option.WithGRPCDialOption(grpc.WithInsecure()))
+	if err != nil {
+		t.Fatalf("Unexpected error in NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	root := "projects/test/databases/(default)/documents/"
+	db := &DB{cl: cl}
+	if got := db.collection("categories").Path; got != root+"categories" {
+		t.Errorf("expected root collection, got %s", got)
+	}
+	if got := db.ForTenant("cafe").collection("categories").Path; got != root+"tenants/cafe/categories" {
+		t.Errorf("expected tenant collection, got %s", got)
+	}
+}
+
 func TestNew(t *testing.T) {
+	skipWithoutFirestore(t)
 	ctx := context.Background()
 	_, err := New(ctx)
 	if err != nil {
@@ -15,6 +49,7 @@ func TestNew(t *testing.T) {
 }
 
 func TestGetCategories(t *testing.T) {
+	skipWithoutFirestore(t)
 	ctx := context.Background()
 	s, err := New(ctx)
 	if err != nil {
@@ -36,6 +71,7 @@ func TestGetCategories(t *testing.T) {
 }
 
 func TestGetItems(t *testing.T) {
+	skipWithoutFirestore(t)
 	ctx := context.Background()
 	s, err := New(ctx)
 	if err != nil {
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"strings"
+)
+
+// allergens maps allergen names to stems of ingredients containing them
+var allergens = map[string][]string{
+	"орехи":        {"орех", "миндал", "фундук", "кешью", "арахис", "фисташк", "пекан", "кедров"},
+	"арахис":       {"арахис"},
+	"молоко":       {"молок", "сливк", "сливочн", "сыр", "творог", "сметан", "кефир", "йогурт", "моцарелл", "пармезан"},
+	"лактоза":      {"молок", "сливк", "сливочн", "сыр", "творог", "сметан", "кефир", "йогурт", "моцарелл", "пармезан"},
+	"глютен":       {"мук", "пшенич", "лапш", "хлеб", "сухарик", "тест", "блин", "макарон"},
+	"яйца":         {"яйц", "яичн", "майонез"},
+	"рыба":         {"рыб", "семг", "лосос", "форел", "тунц", "тунец", "сельд"},
+	"морепродукты": {"кревет", "кальмар", "миди", "краб", "икр"},
+	"мед":          {"мед", "медов"},
+	"соя":          {"со", "соев"},
+	"кунжут":       {"кунжут"},
+	"горчица":      {"горчиц"},
+}
+
+// wholeStems are markers matching the whole stem only,
+// "мед" starts "медальоны" as well
+var wholeStems = map[string]bool{"мед": true}
+
+// parseIngredients returns lowercased ingredients listed in composition
+// before nutrients info
+func parseIngredients(composition string) []string {
+	if loc := proteinRe.FindStringIndex(composition); loc != nil {
+		composition = composition[:loc[0]]
+	}
+
+	res := []string{}
+	for _, part := range strings.FieldsFunc(composition, func(r rune) bool {
+		return r == ',' || r == ';' || r == '(' || r == ')'
+	}) {
+		part = strings.TrimSpace(strings.ToLower(part))
+		if part != "" {
+			res = append(res, part)
+		}
+	}
+
+	return res
+}
+
+// ingredientMarkers returns stems identifying ingredient or allergen word
+// customer mentioned, like "орехов" or "лука"
+func ingredientMarkers(word string) []string {
+	ws := searchTerms(word)
+	if len(ws) == 0 {
+		return nil
+	}
+
+	for name, markers := range allergens {
+		if Stem(name) == ws[0] && len(ws) == 1 {
+			return markers
+		}
+	}
+
+	return ws
+}
+
+// stemMatches reports whether ingredient word stem is identified by marker
+func stemMatches(stem, marker string) bool {
+	if len([]rune(marker)) < 3 || wholeStems[marker] {
+		return stem == marker
+	}
+	return strings.HasPrefix(stem, marker) || termsMatch(stem, marker)
+}
+
+// Contains reports whether item contains ingredient or allergen
+// customer named
+func (item *Item) Contains(word string) bool {
+	markers := ingredientMarkers(word)
+	if len(markers) == 0 {
+		return false
+	}
+
+	for _, ingredient := range item.Ingredients {
+		for _, stem := range searchTerms(ingredient) {
+			for _, m := range markers {
+				if stemMatches(stem, m) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// ExcludeIngredients selects items which contain none of ingredients
+// or allergens named
+func ExcludeIngredients(words ...string) ItemFilter {
+	return func(item *Item) bool {
+		for _, w := range words {
+			if item.Contains(w) {
+				return false
+			}
+		}
+		return true
+	}
+}
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseIngredients(t *testing.T) {
+	cases := []struct {
+		composition string
+		expected    []string
+	}{
+		{
+			"блин, куриное филе, шампиньоны, лук, сливки, сыр российскийБ-20,5Ж-27,4У-6,3 Ккал-257",
+			[]string{"блин", "куриное филе", "шампиньоны", "лук", "сливки", "сыр российский"},
+		},
+		{
+			"Мука; молоко (пастеризованное), яйцо",
+			[]string{"мука", "молоко", "пастеризованное", "яйцо"},
+		},
+		{"Б-0Ж-0У-0 Ккал-2", []string{}},
+		{"", []string{}},
+	}
+
+	for _, c := range cases {
+		if diff := cmp.Diff(c.expected, parseIngredients(c.composition)); diff != "" {
+			t.Errorf("parseIngredients(%q) differs:\n%s", c.composition, diff)
+		}
+	}
+}
+
+func TestItemContains(t *testing.T) {
+	salad := &Item{Ingredients: []string{"микс салата", "груша", "грецкий орех", "сыр дор блю", "мед"}}
+	soup := &Item{Ingredients: []string{"куриное филе", "лапша", "морковь", "лук", "зелень"}}
+	medallions := &Item{Ingredients: []string{"медальоны из говядины", "соус"}}
+	cake := &Item{Ingredients: []string{"медовые коржи", "сметана"}}
+
+	cases := []struct {
+		item     *Item
+		word     string
+		expected bool
+	}{
+		{salad, "орехов", true},
+		{salad, "орехи", true},
+		{salad, "молоко", true},
+		{salad, "мёда", true},
+		{salad, "лука", false},
+		{soup, "лука", true},
+		{soup, "глютен", true},
+		{soup, "орехов", false},
+		{soup, "рыба", false},
+		{soup, "", false},
+		{medallions, "мед", false},
+		{cake, "мёд", true},
+	}
+
+	for _, c := range cases {
+		if got := c.item.Contains(c.word); got != c.expected {
+			t.Errorf("%v.Contains(%q) = %v, expected %v", c.item.Ingredients, c.word, got, c.expected)
+		}
+	}
+}
+
+func TestExcludeIngredients(t *testing.T) {
+	c := newTestCache(t)
+
+	items, err := c.GetItemsPage("Салаты", 0, 10, ExcludeIngredients("орехов"))
+	if err != nil {
+		t.Fatalf("unexpected error in GetItemsPage: %v", err)
+	}
+	if len(items) != 1 || items[0].Name != "Цезарь с курицей" {
+		t.Errorf("unexpected salads without nuts: %v", items)
+	}
+
+	items = c.SearchItems("суп без лука", 0, 10)
+	if len(items) != 0 {
+		t.Errorf("expected no soups without onion, got %v", items)
+	}
+
+	items = c.SearchItems("блины без рыбы", 0, 10)
+	for _, item := range items {
+		if item.Name == "Блины с семгой" {
+			t.Errorf("expected fish to be excluded, got %v", items)
+		}
+	}
+	if len(items) != 3 {
+		t.Errorf("unexpected len=%d of blini without fish (expected 3)", len(items))
+	}
+}
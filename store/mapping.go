@@ -0,0 +1,439 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"mania/money"
+)
+
+// ErrMissingField is returned for required document fields
+// which are absent or null
+var ErrMissingField = errors.New("missing required field")
+
+// Schema renames catalog document fields when upstream schema
+// differs from ours, {"price": "cost"} reads item price from "cost".
+// Fields not in the schema are read under their own names.
+type Schema map[string]string
+
+// schemaFields are catalog document fields Schema can rename
+var schemaFields = []string{
+	"category_id",
+	"parent_id",
+	"name",
+	"icon",
+	"products",
+	"product_id",
+	"image",
+	"composition",
+	"description",
+	"price",
+	"options",
+	"schedule",
+	"synonyms",
+}
+
+// ParseSchema parses schema like "price=cost,name=title"
+func ParseSchema(s string) (Schema, error) {
+	schema := Schema{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("bad schema entry %q, expected field=key", pair)
+		}
+		schema[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return schema, schema.Validate()
+}
+
+// Validate checks that schema renames known fields to non-empty keys
+func (s Schema) Validate() error {
+	for field, key := range s {
+		if !containsString(schemaFields, field) {
+			return fmt.Errorf("unknown schema field %q", field)
+		}
+		if key == "" {
+			return fmt.Errorf("empty document key for schema field %q", field)
+		}
+	}
+	return nil
+}
+
+// key returns document key of the field
+func (s Schema) key(field string) string {
+	if key, ok := s[field]; ok {
+		return key
+	}
+	return field
+}
+
+// FieldError describes bad document field
+type FieldError struct {
+	// Field is our field name
+	Field string
+	// Key is the document key field was read from
+	Key string
+	Err error
+}
+
+func (e *FieldError) Error() string {
+	if e.Key != e.Field {
+		return fmt.Sprintf("bad %s field %q: %v", e.Field, e.Key, e.Err)
+	}
+	return fmt.Sprintf("bad field %q: %v", e.Key, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// field declares how a document field is decoded
+type field struct {
+	name     string
+	required bool
+	// def is decoded instead of absent optional field,
+	// nil leaves destination zero
+	def interface{}
+	// decode stores field value into its destination
+	decode func(v interface{}) error
+}
+
+// decodeFields decodes document fields in declaration order,
+// the first bad field fails the whole document
+func decodeFields(m map[string]interface{}, s Schema, fields []field) error {
+	for _, f := range fields {
+		key := s.key(f.name)
+		v := m[key]
+		if v == nil {
+			if f.required {
+				return &FieldError{Field: f.name, Key: key, Err: ErrMissingField}
+			}
+			if v = f.def; v == nil {
+				continue
+			}
+		}
+		if err := f.decode(v); err != nil {
+			return &FieldError{Field: f.name, Key: key, Err: err}
+		}
+	}
+	return nil
+}
+
+// toInt converts ID value importers write as string, integer
+// or float number
+func toInt(v interface{}) (int, error) {
+	switch n := v.(type) {
+	case string:
+		return strconv.Atoi(strings.TrimSpace(n))
+	case int64:
+		return int(n), nil
+	case int:
+		return n, nil
+	case float64:
+		if n != math.Trunc(n) || math.IsInf(n, 0) {
+			return 0, fmt.Errorf("not an integer: %v", n)
+		}
+		return int(n), nil
+	default:
+		return 0, fmt.Errorf("unexpected type %T", v)
+	}
+}
+
+// toMoney converts amount in rubles written as string,
+// integer or float number, exact Money importers pass is kept
+func toMoney(v interface{}) (money.Money, error) {
+	switch n := v.(type) {
+	case money.Money:
+		return n, nil
+	case string:
+		return money.Parse(n)
+	case int64:
+		return money.FromRubles(n), nil
+	case int:
+		return money.FromRubles(int64(n)), nil
+	case float64:
+		return money.FromFloat(n), nil
+	default:
+		return 0, fmt.Errorf("unexpected type %T", v)
+	}
+}
+
+func intField(dst *int) func(interface{}) error {
+	return func(v interface{}) (err error) {
+		*dst, err = toInt(v)
+		return err
+	}
+}
+
+func moneyField(dst *money.Money) func(interface{}) error {
+	return func(v interface{}) (err error) {
+		*dst, err = toMoney(v)
+		return err
+	}
+}
+
+func stringField(dst *string) func(interface{}) error {
+	return func(v interface{}) error {
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T", v)
+		}
+		*dst = s
+		return nil
+	}
+}
+
+// synonymsField decodes synonyms list or comma separated string
+// spreadsheet imports produce
+func synonymsField(dst *[]string) func(interface{}) error {
+	return func(v interface{}) error {
+		var list []string
+		switch v := v.(type) {
+		case string:
+			list = strings.Split(v, ",")
+		case []interface{}:
+			for i, si := range v {
+				s, ok := si.(string)
+				if !ok {
+					return fmt.Errorf("bad synonym #%d: unexpected type %T", i, si)
+				}
+				list = append(list, s)
+			}
+		default:
+			return fmt.Errorf("unexpected type %T", v)
+		}
+
+		*dst = nil
+		for _, s := range list {
+			if s = strings.TrimSpace(cleanupString(s)); s != "" && !containsString(*dst, s) {
+				*dst = append(*dst, s)
+			}
+		}
+		return nil
+	}
+}
+
+func boolField(dst *bool) func(interface{}) error {
+	return func(v interface{}) error {
+		b, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("unexpected type %T", v)
+		}
+		*dst = b
+		return nil
+	}
+}
+
+func timeField(dst *time.Time) func(interface{}) error {
+	return func(v interface{}) error {
+		t, ok := v.(time.Time)
+		if !ok {
+			return fmt.Errorf("unexpected type %T", v)
+		}
+		*dst = t
+		return nil
+	}
+}
+
+func scheduleField(dst **Schedule) func(interface{}) error {
+	return func(v interface{}) (err error) {
+		*dst, err = mapToSchedule(v)
+		return err
+	}
+}
+
+// productIDs maps category products list, entries are either
+// {product_id: "10"} maps or bare IDs
+func productIDs(v interface{}, s Schema) ([]int, error) {
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected type %T", v)
+	}
+
+	ids := make([]int, 0, len(list))
+	for i, pi := range list {
+		if p, ok := pi.(map[string]interface{}); ok {
+			pi = p[s.key("product_id")]
+		}
+		id, err := toInt(pi)
+		if err != nil {
+			return nil, fmt.Errorf("bad product #%d: %w", i, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// mapToCategory maps categories document to Category.
+// doc.DataAs does not work well out of the box, I checked :)
+func mapToCategory(m map[string]interface{}, s Schema) (Category, error) {
+	cat := Category{}
+	err := decodeFields(m, s, []field{
+		{name: "category_id", required: true, decode: intField(&cat.ID)},
+		{name: "parent_id", def: "0", decode: intField(&cat.ParentID)},
+		{name: "name", required: true, decode: stringField(&cat.Name)},
+		{name: "icon", def: "", decode: stringField(&cat.Icon)},
+		{name: "synonyms", decode: synonymsField(&cat.Synonyms)},
+		{name: "products", required: true, decode: func(v interface{}) (err error) {
+			cat.Products, err = productIDs(v, s)
+			return err
+		}},
+		{name: "schedule", decode: scheduleField(&cat.Schedule)},
+	})
+	return cat, err
+}
+
+// mapToItem maps products document to Item cleaning up
+// its texts and parsing nutrition out of composition
+func mapToItem(m map[string]interface{}, s Schema) (Item, error) {
+	item := Item{}
+	err := decodeFields(m, s, []field{
+		{name: "product_id", required: true, decode: intField(&item.ID)},
+		{name: "name", required: true, decode: stringField(&item.Name)},
+		{name: "image", def: "", decode: stringField(&item.Image)},
+		{name: "composition", def: "", decode: stringField(&item.Composition)},
+		{name: "description", def: "", decode: stringField(&item.Description)},
+		{name: "synonyms", decode: synonymsField(&item.Synonyms)},
+		{name: "price", required: true, decode: moneyField(&item.Price)},
+		{name: "options", decode: func(v interface{}) (err error) {
+			item.OptionGroups, err = mapToOptionGroups(v)
+			return err
+		}},
+		{name: "schedule", decode: scheduleField(&item.Schedule)},
+	})
+	if err != nil {
+		return item, err
+	}
+
+	item.Name = cleanupString(item.Name)
+	item.Composition = cleanupString(item.Composition)
+	item.Nutrition = parseNutrition(item.Composition)
+	item.Ingredients = parseIngredients(item.Composition)
+	// reformatting leaves double spaces, so documents written back
+	// map to the same composition
+	item.Composition = cleanupString(fixNutrients(item.Composition))
+	item.Description = cleanupString(item.Description)
+
+	return item, nil
+}
+
+// MapCategory maps category document in our own schema,
+// importers build documents and map them like sources do
+func MapCategory(m map[string]interface{}) (Category, error) {
+	return mapToCategory(m, nil)
+}
+
+// MapItem maps products document in our own schema cleaning up
+// texts and parsing nutrition just like sources do
+func MapItem(m map[string]interface{}) (Item, error) {
+	return mapToItem(m, nil)
+}
+
+// categoryToMap returns category document the way our importer
+// writes it, keys are renamed by schema
+func categoryToMap(cat *Category, s Schema) map[string]interface{} {
+	products := make([]interface{}, len(cat.Products))
+	for i, id := range cat.Products {
+		products[i] = map[string]interface{}{s.key("product_id"): strconv.Itoa(id)}
+	}
+
+	m := map[string]interface{}{
+		s.key("category_id"): strconv.Itoa(cat.ID),
+		s.key("parent_id"):   strconv.Itoa(cat.ParentID),
+		s.key("name"):        cat.Name,
+		s.key("icon"):        cat.Icon,
+		s.key("products"):    products,
+	}
+	if len(cat.Synonyms) > 0 {
+		m[s.key("synonyms")] = stringsToList(cat.Synonyms)
+	}
+	if cat.Schedule != nil {
+		m[s.key("schedule")] = scheduleToMap(cat.Schedule)
+	}
+	return m
+}
+
+// itemToMap returns products document the way our importer
+// writes it, keys are renamed by schema
+func itemToMap(item *Item, s Schema) map[string]interface{} {
+	m := map[string]interface{}{
+		s.key("product_id"):  strconv.Itoa(item.ID),
+		s.key("name"):        item.Name,
+		s.key("composition"): item.Composition,
+		s.key("description"): item.Description,
+		s.key("price"):       item.Price.Decimal(),
+	}
+	if item.Image != "" {
+		m[s.key("image")] = item.Image
+	}
+	if len(item.Synonyms) > 0 {
+		m[s.key("synonyms")] = stringsToList(item.Synonyms)
+	}
+	if len(item.OptionGroups) > 0 {
+		m[s.key("options")] = optionGroupsToList(item.OptionGroups)
+	}
+	if item.Schedule != nil {
+		m[s.key("schedule")] = scheduleToMap(item.Schedule)
+	}
+	return m
+}
+
+// stringsToList returns ss the way Firestore returns arrays
+func stringsToList(ss []string) []interface{} {
+	list := make([]interface{}, len(ss))
+	for i, s := range ss {
+		list[i] = s
+	}
+	return list
+}
+
+// optionGroupsToList returns option groups document value
+func optionGroupsToList(groups []OptionGroup) []interface{} {
+	list := make([]interface{}, len(groups))
+	for i, g := range groups {
+		options := make([]interface{}, len(g.Options))
+		for j, o := range g.Options {
+			om := map[string]interface{}{
+				"option_id": strconv.Itoa(o.ID),
+				"name":      o.Name,
+			}
+			if o.PriceDelta != 0 {
+				om["price_delta"] = o.PriceDelta.Decimal()
+			}
+			options[j] = om
+		}
+		list[i] = map[string]interface{}{
+			"group_id": strconv.Itoa(g.ID),
+			"name":     g.Name,
+			"required": g.Required,
+			"multiple": g.Multiple,
+			"options":  options,
+		}
+	}
+	return list
+}
+
+// scheduleToMap returns schedule document value
+func scheduleToMap(s *Schedule) map[string]interface{} {
+	m := map[string]interface{}{
+		"from": s.From.String(),
+		"to":   s.To.String(),
+	}
+	if len(s.Days) > 0 {
+		days := make([]interface{}, len(s.Days))
+		for i, d := range s.Days {
+			days[i] = weekdayNames[d]
+		}
+		m["days"] = days
+	}
+	return m
+}
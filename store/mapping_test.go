@@ -0,0 +1,217 @@
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"mania/money"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// recorded documents as Firestore client returns them: integers
+// are int64, numbers with fraction are float64
+var (
+	recordedCategories = []map[string]interface{}{
+		// written by the old importer, everything is a string
+		{
+			"category_id": "1",
+			"parent_id":   "0",
+			"name":        "Блины",
+			"icon":        "🥞",
+			"products": []interface{}{
+				map[string]interface{}{"product_id": "101"},
+				map[string]interface{}{"product_id": "102"},
+			},
+		},
+		// written by the new importer, no icon and parent
+		{
+			"category_id": int64(2),
+			"name":        "Завтраки",
+			"synonyms":    "завтрак, утреннее меню",
+			"products":    []interface{}{int64(201), float64(202)},
+			"schedule": map[string]interface{}{
+				"days": []interface{}{"sat", "sun"},
+				"from": "9:00",
+				"to":   "12:00",
+			},
+		},
+	}
+
+	recordedItems = []map[string]interface{}{
+		{
+			"product_id":  "101",
+			"name":        "Блинчики",
+			"composition": "Мука, молоко, яйца",
+			"description": "Тонкие\n  блинчики&nbsp;",
+			"price":       "150",
+			"image":       "https://example.com/101.jpg",
+			"synonyms":    []interface{}{"блины", " панкейки ", "блины"},
+		},
+		{
+			"product_id": int64(102),
+			"name":       "Блины с курицей",
+			"price":      float64(249.9),
+			"options": []interface{}{
+				map[string]interface{}{
+					"group_id": int64(1),
+					"name":     "Соус",
+					"required": true,
+					"options": []interface{}{
+						map[string]interface{}{"option_id": int64(1), "name": "сметана"},
+						map[string]interface{}{"option_id": "2", "name": "сырный", "price_delta": int64(30)},
+					},
+				},
+			},
+		},
+		{
+			"product_id":  float64(201),
+			"name":        "Сырники",
+			"composition": "Творог, мукаБ-20,5Ж-27,4У-6,3 Ккал-257",
+			"description": "",
+			"price":       int64(190),
+			"schedule":    map[string]interface{}{"from": "9:00", "to": "12:00"},
+		},
+	}
+)
+
+func TestMapToCategoryTolerant(t *testing.T) {
+	cat, err := mapToCategory(recordedCategories[1], nil)
+	if err != nil {
+		t.Fatalf("unexpected error in mapToCategory: %v", err)
+	}
+	expected := Category{
+		ID:       2,
+		Name:     "Завтраки",
+		Synonyms: []string{"завтрак", "утреннее меню"},
+		Products: []int{201, 202},
+		Schedule: &Schedule{
+			Days: []time.Weekday{time.Saturday, time.Sunday},
+			From: 9 * 60,
+			To:   12 * 60,
+		},
+	}
+	if diff := cmp.Diff(expected, cat); diff != "" {
+		t.Errorf("category differs:\n%s", diff)
+	}
+}
+
+func TestMapToItemTolerant(t *testing.T) {
+	item, err := mapToItem(recordedItems[1], nil)
+	if err != nil {
+		t.Fatalf("unexpected error in mapToItem: %v", err)
+	}
+	if item.ID != 102 || item.Price != money.Money(24990) || item.Composition != "" {
+		t.Errorf("unexpected item %+v", item)
+	}
+	if len(item.OptionGroups) != 1 || !item.OptionGroups[0].Required ||
+		item.OptionGroups[0].Options[1].PriceDelta != money.FromRubles(30) {
+		t.Errorf("unexpected option groups %+v", item.OptionGroups)
+	}
+
+	item, err = mapToItem(recordedItems[0], nil)
+	if err != nil {
+		t.Fatalf("unexpected error in mapToItem: %v", err)
+	}
+	if item.Description != "Тонкие блинчики" {
+		t.Errorf("expected description to be cleaned up, got %q", item.Description)
+	}
+	if !cmp.Equal(item.Synonyms, []string{"блины", "панкейки"}) {
+		t.Errorf("expected synonyms to be cleaned up, got %q", item.Synonyms)
+	}
+
+	// importers pass exact amounts as is
+	price := money.Money(1<<53 + 1)
+	item, err = MapItem(map[string]interface{}{"product_id": "1", "name": "Блины", "price": price})
+	if err != nil {
+		t.Fatalf("unexpected error in MapItem: %v", err)
+	}
+	if item.Price != price {
+		t.Errorf("expected exact price %d, got %d", price, item.Price)
+	}
+}
+
+func TestMappingErrors(t *testing.T) {
+	cases := []struct {
+		doc   map[string]interface{}
+		field string
+	}{
+		{map[string]interface{}{"name": "Блины", "price": "100"}, "product_id"},
+		{map[string]interface{}{"product_id": 1.5, "name": "Блины", "price": "100"}, "product_id"},
+		{map[string]interface{}{"product_id": "1", "name": int64(1), "price": "100"}, "name"},
+		{map[string]interface{}{"product_id": "1", "name": "Блины", "price": "сто"}, "price"},
+		{map[string]interface{}{"product_id": "1", "name": "Блины", "price": true}, "price"},
+		{map[string]interface{}{"product_id": "1", "name": "Блины", "price": "100", "synonyms": int64(1)}, "synonyms"},
+	}
+	for _, c := range cases {
+		_, err := mapToItem(c.doc, nil)
+		fe := &FieldError{}
+		if !errors.As(err, &fe) || fe.Field != c.field {
+			t.Errorf("expected %s field error for %v, got %v", c.field, c.doc, err)
+		}
+	}
+
+	_, err := mapToCategory(map[string]interface{}{"category_id": "1", "products": []interface{}{}}, nil)
+	if !errors.Is(err, ErrMissingField) {
+		t.Errorf("expected missing name error, got %v", err)
+	}
+}
+
+func TestMappingRoundTrip(t *testing.T) {
+	renamed := Schema{"price": "cost", "name": "title", "product_id": "sku"}
+
+	for _, schema := range []Schema{nil, renamed} {
+		for _, doc := range recordedCategories {
+			cat, err := mapToCategory(doc, nil)
+			if err != nil {
+				t.Fatalf("unexpected error in mapToCategory: %v", err)
+			}
+			got, err := mapToCategory(categoryToMap(&cat, schema), schema)
+			if err != nil {
+				t.Fatalf("unexpected error in mapToCategory: %v", err)
+			}
+			if diff := cmp.Diff(cat, got); diff != "" {
+				t.Errorf("category differs after round trip with %v:\n%s", schema, diff)
+			}
+		}
+
+		for _, doc := range recordedItems {
+			item, err := mapToItem(doc, nil)
+			if err != nil {
+				t.Fatalf("unexpected error in mapToItem: %v", err)
+			}
+			got, err := mapToItem(itemToMap(&item, schema), schema)
+			if err != nil {
+				t.Fatalf("unexpected error in mapToItem: %v", err)
+			}
+			if diff := cmp.Diff(item, got); diff != "" {
+				t.Errorf("item differs after round trip with %v:\n%s", schema, diff)
+			}
+		}
+	}
+
+	m := itemToMap(&Item{ID: 1, Name: "Блины", Price: money.FromRubles(100)}, renamed)
+	if m["sku"] != "1" || m["title"] != "Блины" || m["cost"] != "100.00" {
+		t.Errorf("expected renamed keys, got %v", m)
+	}
+}
+
+func TestParseSchema(t *testing.T) {
+	s, err := ParseSchema(" price=cost, name=title ")
+	if err != nil {
+		t.Fatalf("unexpected error in ParseSchema: %v", err)
+	}
+	if s.key("price") != "cost" || s.key("name") != "title" || s.key("icon") != "icon" {
+		t.Errorf("unexpected schema %v", s)
+	}
+
+	for _, bad := range []string{"price", "cost=price", "price="} {
+		if _, err := ParseSchema(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+	if s, err := ParseSchema(""); err != nil || len(s) != 0 {
+		t.Errorf("expected empty schema, got %v, %v", s, err)
+	}
+}
@@ -0,0 +1,211 @@
+package store
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// MinMatchScore is the lowest score of a fuzzy match candidate
+// worth offering to the customer
+const MinMatchScore = 0.5
+
+// stopWords are skipped when matching names
+var stopWords = map[string]bool{
+	"с": true, "со": true, "и": true, "в": true, "во": true,
+	"на": true, "из": true, "по": true, "без": true,
+}
+
+// ItemMatch is a fuzzy matched menu item with its score in [0, 1]
+type ItemMatch struct {
+	Item  *Item
+	Score float64
+}
+
+// CategoryMatch is a fuzzy matched category with its score in [0, 1]
+type CategoryMatch struct {
+	Category *Category
+	Score    float64
+}
+
+// matchEntry is a precomputed name representation
+type matchEntry struct {
+	norm     string
+	stems    []string
+	trigrams map[string]bool
+}
+
+// nameMatcher scores names against free-form queries
+type nameMatcher struct {
+	entries []matchEntry
+}
+
+// scored is an index of a matched entry with its score
+type scored struct {
+	idx   int
+	score float64
+}
+
+// normalize lowercases s, replaces ё and drops punctuation
+func normalize(s string) string {
+	s = strings.ReplaceAll(strings.ToLower(s), "ё", "е")
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// stems returns stems of normalized string words skipping stop words
+func stems(norm string) []string {
+	words := strings.Fields(norm)
+	res := make([]string, 0, len(words))
+	for _, w := range words {
+		if stopWords[w] {
+			continue
+		}
+		res = append(res, Stem(w))
+	}
+	return res
+}
+
+// trigrams returns a set of word trigrams of normalized string
+// words are padded with spaces, so short words still produce trigrams
+func trigrams(norm string) map[string]bool {
+	res := make(map[string]bool)
+	for _, w := range strings.Fields(norm) {
+		r := []rune("  " + w + " ")
+		for i := 0; i+3 <= len(r); i++ {
+			res[string(r[i:i+3])] = true
+		}
+	}
+	return res
+}
+
+// newMatchEntry precomputes name representation
+func newMatchEntry(name string) matchEntry {
+	norm := normalize(name)
+	return matchEntry{
+		norm:     norm,
+		stems:    stems(norm),
+		trigrams: trigrams(norm),
+	}
+}
+
+// levenshtein returns edit distance between a and b
+func levenshtein(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	cur := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		cur[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(br)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// editSimilarity returns 1 - normalized edit distance
+func editSimilarity(a, b string) float64 {
+	la, lb := len([]rune(a)), len([]rune(b))
+	if la < lb {
+		la = lb
+	}
+	if la == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(a, b))/float64(la)
+}
+
+// trigramSimilarity returns Jaccard index of trigram sets
+func trigramSimilarity(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	common := 0
+	for t := range a {
+		if b[t] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
+
+// coverage returns average best similarity of every stem in a
+// to stems in b
+func coverage(a, b []string) float64 {
+	if len(a) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, sa := range a {
+		best := 0.0
+		for _, sb := range b {
+			if s := editSimilarity(sa, sb); s > best {
+				best = s
+			}
+		}
+		sum += best
+	}
+	return sum / float64(len(a))
+}
+
+// score returns similarity of query to the entry in [0, 1]
+func (e matchEntry) score(q matchEntry) float64 {
+	if q.norm == e.norm {
+		return 1
+	}
+	words := 0.7*coverage(q.stems, e.stems) + 0.3*coverage(e.stems, q.stems)
+	return 0.5*words +
+		0.3*trigramSimilarity(q.trigrams, e.trigrams) +
+		0.2*editSimilarity(q.norm, e.norm)
+}
+
+// newNameMatcher builds matcher over names
+func newNameMatcher(names []string) *nameMatcher {
+	m := &nameMatcher{entries: make([]matchEntry, len(names))}
+	for i, n := range names {
+		m.entries[i] = newMatchEntry(n)
+	}
+	return m
+}
+
+// match returns up to limit entries scoring at least MinMatchScore
+// best first
+func (m *nameMatcher) match(query string, limit int) []scored {
+	q := newMatchEntry(query)
+	res := []scored{}
+	for i, e := range m.entries {
+		if s := e.score(q); s >= MinMatchScore {
+			res = append(res, scored{idx: i, score: s})
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].score > res[j].score
+	})
+	if limit > 0 && len(res) > limit {
+		res = res[:limit]
+	}
+
+	return res
+}
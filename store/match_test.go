@@ -0,0 +1,78 @@
+package store
+
+import "testing"
+
+func TestLevenshtein(t *testing.T) {
+	cases := []struct {
+		a, b string
+		dist int
+	}{
+		{"", "", 0},
+		{"блин", "", 4},
+		{"блинчики", "блинчеки", 1},
+		{"борщ", "борщик", 2},
+		{"суп", "сыр", 2},
+	}
+
+	for _, c := range cases {
+		if got := levenshtein(c.a, c.b); got != c.dist {
+			t.Errorf("levenshtein(%q, %q) = %d, expected %d", c.a, c.b, got, c.dist)
+		}
+	}
+}
+
+func TestFindItems(t *testing.T) {
+	c := newTestCache(t)
+
+	cases := []struct {
+		query string
+		best  string
+	}{
+		{"блинчеки", "Блинчики"},
+		{"Блинчики", "Блинчики"},
+		{"блины с курицей", "Блины с курицей и грибами"},
+		{"борщик", "Борщ"},
+		{"цезарь", "Цезарь с курицей"},
+	}
+
+	for _, cs := range cases {
+		matches := c.FindItems(cs.query, 3)
+		if len(matches) == 0 {
+			t.Errorf("no matches for %q", cs.query)
+			continue
+		}
+		if matches[0].Item.Name != cs.best {
+			t.Errorf("best match for %q is %q, expected %q",
+				cs.query, matches[0].Item.Name, cs.best)
+		}
+	}
+
+	if matches := c.FindItems("пицца", 3); len(matches) != 0 {
+		t.Errorf("expected no matches for unknown dish, got %v", matches)
+	}
+
+	// ambiguous query gives several close candidates
+	matches := c.FindItems("блина", 3)
+	if len(matches) < 2 {
+		t.Fatalf("expected several candidates for ambiguous query, got %d", len(matches))
+	}
+	for i := 1; i < len(matches); i++ {
+		if matches[i].Score > matches[i-1].Score {
+			t.Errorf("matches are not sorted by score: %v", matches)
+		}
+	}
+}
+
+func TestFindCategories(t *testing.T) {
+	c := newTestCache(t)
+
+	matches := c.FindCategories("блин", 1)
+	if len(matches) != 1 || matches[0].Category.Name != "Блины" {
+		t.Errorf("unexpected matches for блин: %v", matches)
+	}
+
+	matches = c.FindCategories("напиток", 1)
+	if len(matches) != 1 || matches[0].Category.Name != "Напитки" {
+		t.Errorf("unexpected matches for напиток: %v", matches)
+	}
+}
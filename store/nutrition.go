@@ -0,0 +1,81 @@
+package store
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Nutrition holds item nutrition facts as written in composition:
+// proteins, fats and carbohydrates in grams and energy in kcal
+type Nutrition struct {
+	Protein float64
+	Fat     float64
+	Carbs   float64
+	Kcal    float64
+}
+
+var (
+	// composition strings contain nutrients like
+	// "соусБ-20,5Ж-27,4У-6,3 Ккал-257"
+	proteinRe = regexp.MustCompile(`Б\s*-\s*([0-9]+(?:[,.][0-9]+)?)`)
+	fatRe     = regexp.MustCompile(`Ж\s*-\s*([0-9]+(?:[,.][0-9]+)?)`)
+	carbsRe   = regexp.MustCompile(`У\s*-\s*([0-9]+(?:[,.][0-9]+)?)`)
+	kcalRe    = regexp.MustCompile(`(?i)ккал\s*-?\s*([0-9]+(?:[,.][0-9]+)?)`)
+)
+
+// parseDecimal parses numbers with comma or dot decimal separator
+func parseDecimal(s string) (float64, error) {
+	return strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+}
+
+// findDecimal returns the first number captured by re in s
+func findDecimal(re *regexp.Regexp, s string) (float64, bool) {
+	m := re.FindStringSubmatch(s)
+	if m == nil {
+		return 0, false
+	}
+	f, err := parseDecimal(m[1])
+	if err != nil {
+		return 0, false
+	}
+	return f, true
+}
+
+// parseNutrition extracts nutrition facts from composition string.
+// Returns nil if composition has no proteins, fats and carbohydrates info.
+func parseNutrition(composition string) *Nutrition {
+	n := Nutrition{}
+	var okP, okF, okC bool
+	n.Protein, okP = findDecimal(proteinRe, composition)
+	n.Fat, okF = findDecimal(fatRe, composition)
+	n.Carbs, okC = findDecimal(carbsRe, composition)
+	if !okP || !okF || !okC {
+		return nil
+	}
+	n.Kcal, _ = findDecimal(kcalRe, composition)
+
+	return &n
+}
+
+// ItemFilter selects items for listings
+type ItemFilter func(*Item) bool
+
+// MaxKcal selects items with known energy not exceeding kcal
+func MaxKcal(kcal float64) ItemFilter {
+	return func(item *Item) bool {
+		return item.Nutrition != nil &&
+			item.Nutrition.Kcal > 0 &&
+			item.Nutrition.Kcal <= kcal
+	}
+}
+
+// matchFilters reports whether item passes every filter
+func matchFilters(item *Item, filters []ItemFilter) bool {
+	for _, f := range filters {
+		if !f(item) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseNutrition(t *testing.T) {
+	cases := []struct {
+		composition string
+		expected    *Nutrition
+	}{
+		{
+			"фирменный соусБ-20,5Ж-27,4У-6,3 Ккал-257",
+			&Nutrition{Protein: 20.5, Fat: 27.4, Carbs: 6.3, Kcal: 257},
+		},
+		{
+			"мука, молоко, яйцо, сливочное маслоБ-6,2Ж-9,1У-28,4 Ккал-221",
+			&Nutrition{Protein: 6.2, Fat: 9.1, Carbs: 28.4, Kcal: 221},
+		},
+		{
+			"клюква, сахар, водаБ-0,1Ж-0У-11,2 Ккал-45",
+			&Nutrition{Protein: 0.1, Fat: 0, Carbs: 11.2, Kcal: 45},
+		},
+		{
+			"чай черныйБ-0Ж-0У-0 Ккал-2",
+			&Nutrition{Kcal: 2},
+		},
+		{
+			// already fixed for speech by fixNutrients
+			"соус Б-20,5%, Ж-27,4%, У-6,3%, пищевая ценность:  Ккал-257",
+			&Nutrition{Protein: 20.5, Fat: 27.4, Carbs: 6.3, Kcal: 257},
+		},
+		{
+			"тесто, сырБ-12.5 Ж-10 У-30.25 ккал 265.5",
+			&Nutrition{Protein: 12.5, Fat: 10, Carbs: 30.25, Kcal: 265.5},
+		},
+		{
+			// no kcal
+			"говядина, лукБ-15Ж-12У-3",
+			&Nutrition{Protein: 15, Fat: 12, Carbs: 3},
+		},
+		{"мука, молоко, яйцо", nil},
+		{"Б-20,5Ж-27,4", nil},
+		{"", nil},
+	}
+
+	for _, c := range cases {
+		got := parseNutrition(c.composition)
+		if diff := cmp.Diff(c.expected, got); diff != "" {
+			t.Errorf("parseNutrition(%q) differs:\n%s", c.composition, diff)
+		}
+	}
+}
+
+func TestMapToItemNutrition(t *testing.T) {
+	item, err := mapToItem(map[string]interface{}{
+		"product_id":  "1",
+		"name":        "Блины с курицей",
+		"composition": "блин, курица, фирменный соусБ-20,5Ж-27,4У-6,3 Ккал-257",
+		"description": "",
+		"price":       "250",
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error in mapToItem: %v", err)
+	}
+
+	expected := &Nutrition{Protein: 20.5, Fat: 27.4, Carbs: 6.3, Kcal: 257}
+	if diff := cmp.Diff(expected, item.Nutrition); diff != "" {
+		t.Errorf("item nutrition differs:\n%s", diff)
+	}
+}
+
+func TestGetItemsPageMaxKcal(t *testing.T) {
+	c := newTestCache(t)
+
+	items, err := c.GetItemsPage("Блины", 0, 10, MaxKcal(250))
+	if err != nil {
+		t.Fatalf("unexpected error in GetItemsPage: %v", err)
+	}
+	if len(items) != 1 || items[0].Name != "Блинчики" {
+		t.Errorf("unexpected items up to 250 kcal: %v", items)
+	}
+
+	all := c.GetAllItemsPage(0, 100, MaxKcal(150))
+	if len(all) != 4 {
+		t.Errorf("unexpected len=%d of items up to 150 kcal (expected 4)", len(all))
+	}
+	for _, item := range all {
+		if item.Nutrition.Kcal > 150 {
+			t.Errorf("item %s has %v kcal", item.Name, item.Nutrition.Kcal)
+		}
+	}
+}
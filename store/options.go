@@ -0,0 +1,212 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"mania/money"
+)
+
+// Option is a single item modifier like portion size or extra topping
+type Option struct {
+	ID int `json:"option_id"`
+	// GroupID is the option group ID, option IDs are only
+	// unique within a group
+	GroupID    int `json:"group_id"`
+	Name       string
+	PriceDelta money.Money `json:"price_delta"`
+}
+
+// OptionGroup is a set of options customer chooses from
+type OptionGroup struct {
+	ID       int `json:"group_id"`
+	Name     string
+	Required bool
+	// Multiple allows several options of the group, like extras
+	Multiple bool
+	Options  []Option
+}
+
+// FindOption returns item option best matching the name
+func (item *Item) FindOption(name string) (OptionGroup, Option, bool) {
+	q := newMatchEntry(name)
+	var (
+		bestGroup  OptionGroup
+		bestOption Option
+		best       float64
+	)
+	for _, g := range item.OptionGroups {
+		for _, o := range g.Options {
+			if s := newMatchEntry(o.Name).score(q); s > best {
+				best, bestGroup, bestOption = s, g, o
+			}
+		}
+	}
+
+	return bestGroup, bestOption, best >= MinMatchScore
+}
+
+// MissingGroups returns required option groups having
+// none of the selected options
+func (item *Item) MissingGroups(selected []Option) []OptionGroup {
+	chosen := make(map[optionID]bool, len(selected))
+	for _, o := range selected {
+		chosen[optionID{o.GroupID, o.ID}] = true
+	}
+
+	missing := []OptionGroup{}
+	for _, g := range item.OptionGroups {
+		if !g.Required {
+			continue
+		}
+		found := false
+		for _, o := range g.Options {
+			if chosen[optionID{g.ID, o.ID}] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, g)
+		}
+	}
+
+	return missing
+}
+
+// optionID identifies option within an item
+type optionID struct {
+	group, option int
+}
+
+// option returns item option by group and option ID
+func (item *Item) option(groupID, id int) (Option, bool) {
+	for _, g := range item.OptionGroups {
+		if g.ID != groupID {
+			continue
+		}
+		for _, o := range g.Options {
+			if o.ID == id {
+				o.GroupID = g.ID
+				return o, true
+			}
+		}
+	}
+	return Option{}, false
+}
+
+// SelectOption adds option to the selection replacing other
+// option of the same group unless the group allows multiple choices
+func (item *Item) SelectOption(selected []Option, group OptionGroup, option Option) []Option {
+	option.GroupID = group.ID
+	res := make([]Option, 0, len(selected)+1)
+	for _, o := range selected {
+		if o.GroupID != group.ID {
+			res = append(res, o)
+			continue
+		}
+		if o.ID == option.ID || !group.Multiple {
+			continue
+		}
+		res = append(res, o)
+	}
+
+	return append(res, option)
+}
+
+// mapToOptionGroups maps product "options" field, which is optional:
+//
+//	[{"group_id": "1", "name": "Размер", "required": true, "multiple": false,
+//	  "options": [{"option_id": "1", "name": "большая", "price_delta": "60"}]}]
+func mapToOptionGroups(v interface{}) ([]OptionGroup, error) {
+	if v == nil {
+		return nil, nil
+	}
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, errors.New("bad product options")
+	}
+
+	groups := make([]OptionGroup, 0, len(list))
+	for i, gi := range list {
+		m, ok := gi.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("bad option group #%d value", i)
+		}
+		g := OptionGroup{}
+		err := decodeFields(m, nil, []field{
+			{name: "group_id", required: true, decode: intField(&g.ID)},
+			{name: "name", required: true, decode: stringField(&g.Name)},
+			{name: "required", def: false, decode: boolField(&g.Required)},
+			{name: "multiple", def: false, decode: boolField(&g.Multiple)},
+			{name: "options", required: true, decode: func(v interface{}) error {
+				options, ok := v.([]interface{})
+				if !ok {
+					return fmt.Errorf("unexpected type %T", v)
+				}
+				for _, oi := range options {
+					o, err := mapToOption(oi)
+					if err != nil {
+						return err
+					}
+					o.GroupID = g.ID
+					g.Options = append(g.Options, o)
+				}
+				return nil
+			}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("bad option group #%d: %w", i, err)
+		}
+
+		groups = append(groups, g)
+	}
+
+	return groups, nil
+}
+
+// mapToOption maps a single option value
+func mapToOption(v interface{}) (Option, error) {
+	o := Option{}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return o, errors.New("bad option value")
+	}
+
+	// price delta is optional, free options have none
+	err := decodeFields(m, nil, []field{
+		{name: "option_id", required: true, decode: intField(&o.ID)},
+		{name: "name", required: true, decode: stringField(&o.Name)},
+		{name: "price_delta", decode: moneyField(&o.PriceDelta)},
+	})
+	if err != nil {
+		return o, err
+	}
+	o.Name = cleanupString(o.Name)
+
+	return o, nil
+}
+
+// optionsKey returns options group and option IDs as a sorted string
+func optionsKey(options []Option) string {
+	ids := make([]optionID, len(options))
+	for i, o := range options {
+		ids[i] = optionID{o.GroupID, o.ID}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if ids[i].group != ids[j].group {
+			return ids[i].group < ids[j].group
+		}
+		return ids[i].option < ids[j].option
+	})
+
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id.group) + "." + strconv.Itoa(id.option)
+	}
+
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,110 @@
+package store
+
+import (
+	"testing"
+
+	"mania/money"
+)
+
+func TestMapToOptionGroups(t *testing.T) {
+	c := newTestCache(t)
+
+	item, err := c.GetItem("Блинчики")
+	if err != nil {
+		t.Fatalf("unexpected error in GetItem: %v", err)
+	}
+	if len(item.OptionGroups) != 2 {
+		t.Fatalf("unexpected len=%d of option groups (expected 2)", len(item.OptionGroups))
+	}
+
+	size := item.OptionGroups[0]
+	if !size.Required || size.Multiple || len(size.Options) != 2 {
+		t.Errorf("unexpected size group: %+v", size)
+	}
+	if size.Options[1].PriceDelta != money.FromRubles(60) {
+		t.Errorf("unexpected price delta %s", size.Options[1].PriceDelta)
+	}
+
+	if _, err := mapToOptionGroups("bad"); err == nil {
+		t.Error("expected error for malformed options")
+	}
+	if groups, err := mapToOptionGroups(nil); err != nil || groups != nil {
+		t.Errorf("expected no options for missing field, got %v, %v", groups, err)
+	}
+}
+
+func TestSelectOptions(t *testing.T) {
+	c := newTestCache(t)
+	item, err := c.GetItem("Блинчики")
+	if err != nil {
+		t.Fatalf("unexpected error in GetItem: %v", err)
+	}
+
+	pos := Position{Item: *item, Quantity: 2}
+	if missing := item.MissingGroups(pos.Options); len(missing) != 1 || missing[0].ID != 1 {
+		t.Fatalf("expected size group to be missing, got %v", missing)
+	}
+
+	for _, name := range []string{"со сгущенкой", "обычную", "большую", "сметану"} {
+		g, o, ok := item.FindOption(name)
+		if !ok {
+			t.Fatalf("option %q not found", name)
+		}
+		pos.Options = item.SelectOption(pos.Options, g, o)
+	}
+
+	if missing := item.MissingGroups(pos.Options); len(missing) != 0 {
+		t.Errorf("expected no missing groups, got %v", missing)
+	}
+
+	// "большая" replaced "обычная", extras are multiple
+	if pos.Name() != "Блинчики (сгущенка, большая, сметана)" {
+		t.Errorf("unexpected position name %q", pos.Name())
+	}
+	if pos.Key() != "101:1.12,2.21,2.22" {
+		t.Errorf("unexpected position key %q", pos.Key())
+	}
+	// (150 + 30 + 60 + 25.50) x 2
+	if pos.Total() != 53100 {
+		t.Errorf("unexpected position total %s", pos.Total())
+	}
+
+	if _, _, ok := item.FindOption("пепперони"); ok {
+		t.Error("expected unknown option not to be found")
+	}
+}
+
+func TestSelectOptionsSameIDs(t *testing.T) {
+	// option IDs are only unique within a group
+	item := &Item{ID: 101, Name: "Блинчики", OptionGroups: []OptionGroup{
+		{ID: 1, Name: "Размер", Required: true, Options: []Option{
+			{ID: 1, Name: "обычная"},
+			{ID: 2, Name: "большая"},
+		}},
+		{ID: 2, Name: "Добавки", Multiple: true, Options: []Option{
+			{ID: 1, Name: "сгущенка"},
+			{ID: 2, Name: "сметана"},
+		}},
+	}}
+
+	pos := Position{Item: *item, Quantity: 1}
+	for _, name := range []string{"сгущенка", "сметана"} {
+		g, o, _ := item.FindOption(name)
+		pos.Options = item.SelectOption(pos.Options, g, o)
+	}
+	if missing := item.MissingGroups(pos.Options); len(missing) != 1 || missing[0].ID != 1 {
+		t.Fatalf("expected toppings not to fill size group, got missing %v", missing)
+	}
+
+	g, o, _ := item.FindOption("обычная")
+	pos.Options = item.SelectOption(pos.Options, g, o)
+	if pos.Name() != "Блинчики (сгущенка, сметана, обычная)" {
+		t.Errorf("expected size not to replace topping, got %q", pos.Name())
+	}
+	if pos.Key() != "101:1.1,2.1,2.2" {
+		t.Errorf("unexpected position key %q", pos.Key())
+	}
+	if o, ok := item.option(2, 1); !ok || o.Name != "сгущенка" {
+		t.Errorf("expected topping by group and option ID, got %+v", o)
+	}
+}
@@ -0,0 +1,133 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Order is a completed order record, the only thing
+// popularity and recommendations are learned from
+type Order struct {
+	Time time.Time `json:"time"`
+	// Items are ordered quantities by item ID
+	Items map[int]uint `json:"items"`
+}
+
+// OrderLog keeps completed orders
+type OrderLog interface {
+	Record(ctx context.Context, o Order) error
+	// Orders returns orders made at or after since, oldest first
+	Orders(ctx context.Context, since time.Time) ([]Order, error)
+}
+
+// MemoryOrderLog keeps orders in memory, they are lost on restart
+type MemoryOrderLog struct {
+	mux    sync.RWMutex
+	orders []Order
+}
+
+// NewMemoryOrderLog returns an empty MemoryOrderLog
+func NewMemoryOrderLog() *MemoryOrderLog {
+	return &MemoryOrderLog{}
+}
+
+// Record appends order to the log
+func (l *MemoryOrderLog) Record(ctx context.Context, o Order) error {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	l.orders = append(l.orders, o)
+	return nil
+}
+
+// Orders returns orders made at or after since
+func (l *MemoryOrderLog) Orders(ctx context.Context, since time.Time) ([]Order, error) {
+	l.mux.RLock()
+	defer l.mux.RUnlock()
+
+	res := []Order{}
+	for _, o := range l.orders {
+		if !o.Time.Before(since) {
+			res = append(res, o)
+		}
+	}
+	return res, nil
+}
+
+// FileOrderLog appends orders to a local JSON lines file,
+// one order per line
+type FileOrderLog struct {
+	mux  sync.Mutex
+	path string
+}
+
+// NewFileOrderLog returns order log stored in path,
+// file and its directory are created on the first order
+func NewFileOrderLog(path string) *FileOrderLog {
+	return &FileOrderLog{path: path}
+}
+
+// Record appends order line to the file
+func (l *FileOrderLog) Record(ctx context.Context, o Order) error {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create order log dir: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open order log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("failed to write order log: %w", err)
+	}
+	return f.Sync()
+}
+
+// Orders reads orders made at or after since from the file.
+// Missing file is an empty log, broken lines are skipped.
+func (l *FileOrderLog) Orders(ctx context.Context, since time.Time) ([]Order, error) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	res := []Order{}
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return res, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open order log: %w", err)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		o := Order{}
+		if err := json.Unmarshal(sc.Bytes(), &o); err != nil {
+			// partially written last line after a crash
+			continue
+		}
+		if !o.Time.Before(since) {
+			res = append(res, o)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read order log: %w", err)
+	}
+
+	return res, nil
+}
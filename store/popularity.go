@@ -0,0 +1,271 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// defaultHalfLife is how long it takes an order to weigh half
+	defaultHalfLife = time.Hour * 24 * 14
+	// popularityHorizon is how many half-lives of orders are read,
+	// older orders weigh less than 1/1000
+	popularityHorizon = 10
+)
+
+// PinSource is implemented by catalog sources which store items
+// pinned by admins to the top of listings, in pin order
+type PinSource interface {
+	GetPins(ctx context.Context) ([]int, error)
+	SetPins(ctx context.Context, ids []int) error
+}
+
+// WithOrderLog makes cache rank listings by popularity
+// learned from orders in l and record new orders there
+func WithOrderLog(l OrderLog) CacheOption {
+	return func(c *Cache) {
+		c.orders = l
+	}
+}
+
+// WithHalfLife sets how fast order weight decays,
+// an order made halfLife ago weighs half of the new one
+func WithHalfLife(halfLife time.Duration) CacheOption {
+	return func(c *Cache) {
+		if halfLife > 0 {
+			c.halfLife = halfLife
+		}
+	}
+}
+
+// decay returns weight of an order made age ago
+func decay(age, halfLife time.Duration) float64 {
+	if age < 0 {
+		age = 0
+	}
+	return math.Exp2(-float64(age) / float64(halfLife))
+}
+
+// orderStats are decayed order statistics
+type orderStats struct {
+	// popularity is ordered quantity by item ID
+	popularity map[int]float64
+	// pairs is how often items are ordered together
+	pairs map[int]map[int]float64
+}
+
+func newOrderStats() *orderStats {
+	return &orderStats{
+		popularity: make(map[int]float64),
+		pairs:      make(map[int]map[int]float64),
+	}
+}
+
+// add counts order of weight w
+func (s *orderStats) add(o Order, w float64) {
+	for id, qty := range o.Items {
+		s.popularity[id] += w * float64(qty)
+
+		for other := range o.Items {
+			if other == id {
+				continue
+			}
+			if s.pairs[id] == nil {
+				s.pairs[id] = make(map[int]float64)
+			}
+			s.pairs[id][other] += w
+		}
+	}
+}
+
+// refreshPopularity recomputes item scores from the order log
+func (c *Cache) refreshPopularity() error {
+	if c.orders == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx, firebaseTimeout)
+	defer cancel()
+
+	now := c.now()
+	orders, err := c.orders.Orders(ctx, now.Add(-popularityHorizon*c.halfLife))
+	if err != nil {
+		return fmt.Errorf("failed to read orders: %w", err)
+	}
+
+	stats := newOrderStats()
+	for _, o := range orders {
+		stats.add(o, decay(now.Sub(o.Time), c.halfLife))
+	}
+
+	c.mux.Lock()
+	c.stats = stats
+	c.mux.Unlock()
+
+	return nil
+}
+
+// RecordOrder stores completed order in the order log
+// and counts it in popularity and recommendations right away
+func (c *Cache) RecordOrder(ctx context.Context, o Order) error {
+	if c.orders == nil {
+		return nil
+	}
+	if err := c.orders.Record(ctx, o); err != nil {
+		return err
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	// stats maps are only read under mux, so updated in place
+	c.stats.add(o, decay(c.now().Sub(o.Time), c.halfLife))
+
+	return nil
+}
+
+// refreshPins loads pinned items from the source
+func (c *Cache) refreshPins(ps PinSource) error {
+	ctx, cancel := context.WithTimeout(c.ctx, firebaseTimeout)
+	defer cancel()
+
+	ids, err := ps.GetPins(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.mux.Lock()
+	c.pins = pinIndex(ids)
+	c.mux.Unlock()
+
+	return nil
+}
+
+// pinIndex maps pinned item IDs to their positions
+func pinIndex(ids []int) map[int]int {
+	pins := make(map[int]int, len(ids))
+	for i, id := range ids {
+		if _, ok := pins[id]; !ok {
+			pins[id] = i
+		}
+	}
+	return pins
+}
+
+// GetPins returns pinned item IDs in pin order
+func (c *Cache) GetPins() []int {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	ids := make([]int, 0, len(c.pins))
+	for id := range c.pins {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return c.pins[ids[i]] < c.pins[ids[j]]
+	})
+	return ids
+}
+
+// SetPins stores pinned items in the source and applies them
+// to the cache immediately. Pinned items come first in listings
+// in the given order, empty ids unpin all.
+func (c *Cache) SetPins(ctx context.Context, ids []int) error {
+	ps, ok := c.src.(PinSource)
+	if !ok {
+		return errors.New("catalog source does not support pinned items")
+	}
+
+	// drop duplicates so positions stay dense
+	pins := pinIndex(ids)
+	uniq := make([]int, 0, len(pins))
+	for i, id := range ids {
+		if pins[id] == i {
+			uniq = append(uniq, id)
+		}
+	}
+
+	if err := ps.SetPins(ctx, uniq); err != nil {
+		return fmt.Errorf("failed to update pinned items: %w", err)
+	}
+
+	c.mux.Lock()
+	c.pins = pinIndex(uniq)
+	c.mux.Unlock()
+
+	return nil
+}
+
+// rank is a listing position key: pinned first in pin order,
+// then more popular
+type rank struct {
+	pin   int
+	score float64
+}
+
+func (r rank) less(o rank) bool {
+	if r.pin != o.pin {
+		return r.pin < o.pin
+	}
+	return r.score > o.score
+}
+
+// itemRank returns item listing rank. Must be called with mux held.
+func (c *Cache) itemRank(itemID int) rank {
+	pin, ok := c.pins[itemID]
+	if !ok {
+		pin = math.MaxInt32
+	}
+	return rank{pin: pin, score: c.stats.popularity[itemID]}
+}
+
+// categoryRank returns category listing rank made of its and its
+// subcategories items: the first pinned item and total popularity.
+// Must be called with mux held.
+func (c *Cache) categoryRank(cat *Category) rank {
+	r := rank{pin: math.MaxInt32}
+	seen := map[int]bool{}
+	queue := []*Category{cat}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if seen[cur.ID] {
+			continue
+		}
+		seen[cur.ID] = true
+
+		for _, id := range cur.Products {
+			ir := c.itemRank(id)
+			if ir.pin < r.pin {
+				r.pin = ir.pin
+			}
+			r.score += ir.score
+		}
+		queue = append(queue, c.data.children[cur.ID]...)
+	}
+	return r
+}
+
+// rankItems sorts items by rank, equal ones keep catalog order.
+// Must be called with mux held.
+func (c *Cache) rankItems(items []*Item) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return c.itemRank(items[i].ID).less(c.itemRank(items[j].ID))
+	})
+}
+
+// rankCategories sorts categories by rank, equal ones keep catalog
+// order. Must be called with mux held.
+func (c *Cache) rankCategories(cats []*Category) {
+	ranks := make(map[int]rank, len(cats))
+	for _, cat := range cats {
+		ranks[cat.ID] = c.categoryRank(cat)
+	}
+	sort.SliceStable(cats, func(i, j int) bool {
+		return ranks[cats[i].ID].less(ranks[cats[j].ID])
+	})
+}
@@ -0,0 +1,143 @@
+package store
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// itemIDs returns IDs of items
+func itemIDs(items []*Item) []int {
+	ids := make([]int, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	return ids
+}
+
+// equalInts reports whether int slices are equal
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestFileOrderLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mania")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	l := NewFileOrderLog(filepath.Join(dir, "orders", "default.jsonl"))
+
+	orders, err := l.Orders(ctx, time.Time{})
+	if err != nil || len(orders) != 0 {
+		t.Fatalf("expected empty log, got %v, %v", orders, err)
+	}
+
+	start := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		o := Order{Time: start.Add(time.Duration(i) * time.Hour), Items: map[int]uint{101: uint(i + 1)}}
+		if err := l.Record(ctx, o); err != nil {
+			t.Fatalf("unexpected error in Record: %v", err)
+		}
+	}
+
+	// torn last line is skipped
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("failed to open log: %v", err)
+	}
+	f.WriteString(`{"time": "2020-01`)
+	f.Close()
+
+	orders, err = l.Orders(ctx, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error in Orders: %v", err)
+	}
+	if len(orders) != 2 || orders[0].Items[101] != 2 || !orders[1].Time.Equal(start.Add(2*time.Hour)) {
+		t.Errorf("unexpected orders: %+v", orders)
+	}
+}
+
+func TestPopularityRanking(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mania")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "catalog.json")
+	copyFile(t, testCatalog, path)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Date(2020, 1, 10, 12, 0, 0, 0, time.UTC)
+	halfLife := 24 * time.Hour
+	orders := NewMemoryOrderLog()
+	// 10 pieces 4 half-lives ago weigh as 0.625 of a fresh one
+	if err := orders.Record(ctx, Order{Time: now.Add(-4 * halfLife), Items: map[int]uint{102: 10}}); err != nil {
+		t.Fatalf("unexpected error in Record: %v", err)
+	}
+
+	c, err := NewCache(
+		ctx,
+		NewFileSource(path),
+		WithOrderLog(orders),
+		WithHalfLife(halfLife),
+		WithClock(func() time.Time { return now }),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error in NewCache: %v", err)
+	}
+
+	items, _ := c.GetItemsPage("Блины", 0, 10)
+	if ids := itemIDs(items); !equalInts(ids, []int{102, 101, 103, 104}) {
+		t.Errorf("expected old order to rank 102 first, got %v", ids)
+	}
+
+	if err := c.RecordOrder(ctx, Order{Time: now, Items: map[int]uint{103: 1, 401: 5}}); err != nil {
+		t.Fatalf("unexpected error in RecordOrder: %v", err)
+	}
+	items, _ = c.GetItemsPage("Блины", 0, 10)
+	if ids := itemIDs(items); !equalInts(ids, []int{103, 102, 101, 104}) {
+		t.Errorf("expected fresh order to outweigh decayed one, got %v", ids)
+	}
+	cats, _ := c.GetSubcategoriesPage("", 0, 10)
+	if len(cats) != 4 || cats[0].Name != "Напитки" || cats[1].Name != "Блины" {
+		t.Errorf("expected popular categories first, got %v", cats)
+	}
+
+	// pinned items come first whatever their popularity
+	if err := c.SetPins(ctx, []int{104, 104, 201}); err != nil {
+		t.Fatalf("unexpected error in SetPins: %v", err)
+	}
+	if pins := c.GetPins(); !equalInts(pins, []int{104, 201}) {
+		t.Errorf("expected deduplicated pins, got %v", pins)
+	}
+	items, _ = c.GetItemsPage("Блины", 0, 10)
+	if ids := itemIDs(items); !equalInts(ids, []int{104, 103, 102, 101}) {
+		t.Errorf("expected pinned item first, got %v", ids)
+	}
+	cats, _ = c.GetSubcategoriesPage("", 0, 10)
+	if len(cats) != 4 || cats[0].Name != "Блины" || cats[1].Name != "Супы" {
+		t.Errorf("expected categories of pinned items first, got %v", cats)
+	}
+
+	pins, err := NewFileSource(path).GetPins(ctx)
+	if err != nil || !equalInts(pins, []int{104, 201}) {
+		t.Errorf("expected pins stored in the source, got %v, %v", pins, err)
+	}
+}
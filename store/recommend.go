@@ -0,0 +1,94 @@
+package store
+
+import (
+	"sort"
+)
+
+// FrequentlyBoughtWith returns up to limit available items offered now
+// which were most often ordered together with basket items.
+// Basket items are never returned, no co-occurring orders give
+// an empty result.
+func (c *Cache) FrequentlyBoughtWith(basket []int, limit int) []*Item {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	return c.frequentlyBoughtWith(basket, limit)
+}
+
+// frequentlyBoughtWith ranks items by co-occurrence with basket items
+// summed over the basket. Must be called with mux held.
+func (c *Cache) frequentlyBoughtWith(basket []int, limit int) []*Item {
+	scores := map[int]float64{}
+	for _, id := range basket {
+		for other, w := range c.stats.pairs[id] {
+			scores[other] += w
+		}
+	}
+
+	now := c.now()
+	res := []*Item{}
+	for id := range scores {
+		item, ok := c.data.items[id]
+		if !ok || containsInt(basket, id) || !c.isAvailable(id) || !c.isOffered(id, now) {
+			continue
+		}
+		res = append(res, item)
+	}
+
+	// map order is random, ties are broken by rank and then ID
+	sort.Slice(res, func(i, j int) bool {
+		si, sj := scores[res[i].ID], scores[res[j].ID]
+		if si != sj {
+			return si > sj
+		}
+		ri, rj := c.itemRank(res[i].ID), c.itemRank(res[j].ID)
+		if ri != rj {
+			return ri.less(rj)
+		}
+		return res[i].ID < res[j].ID
+	})
+
+	if len(res) > limit {
+		res = res[:limit]
+	}
+	return res
+}
+
+// Recommend returns up to limit available items offered now to suggest
+// for the basket: ones frequently bought with it first, then pinned
+// and popular ones, then in catalog order. Empty basket gives
+// pinned and popular items.
+func (c *Cache) Recommend(basket []int, limit int) []*Item {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	res := c.frequentlyBoughtWith(basket, limit)
+	if len(res) >= limit {
+		return res
+	}
+
+	seen := map[int]bool{}
+	for _, id := range basket {
+		seen[id] = true
+	}
+	for _, item := range res {
+		seen[item.ID] = true
+	}
+
+	now := c.now()
+	rest := []*Item{}
+	for _, item := range c.data.itemsList {
+		if !seen[item.ID] && c.isAvailable(item.ID) && c.isOffered(item.ID, now) {
+			rest = append(rest, item)
+		}
+	}
+	c.rankItems(rest)
+
+	for _, item := range rest {
+		if len(res) == limit {
+			break
+		}
+		res = append(res, item)
+	}
+	return res
+}
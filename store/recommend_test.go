@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecommend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mania")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "catalog.json")
+	copyFile(t, testCatalog, path)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Date(2020, 1, 10, 12, 0, 0, 0, time.UTC)
+	orders := NewMemoryOrderLog()
+	for _, items := range []map[int]uint{
+		{101: 1, 401: 1},
+		{101: 1, 401: 1},
+		{101: 1, 201: 1},
+		{102: 1, 401: 1},
+		{402: 5},
+	} {
+		if err := orders.Record(ctx, Order{Time: now, Items: items}); err != nil {
+			t.Fatalf("unexpected error in Record: %v", err)
+		}
+	}
+
+	c, err := NewCache(
+		ctx,
+		NewFileSource(path),
+		WithOrderLog(orders),
+		WithClock(func() time.Time { return now }),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error in NewCache: %v", err)
+	}
+
+	if ids := itemIDs(c.FrequentlyBoughtWith([]int{101}, 10)); !equalInts(ids, []int{401, 201}) {
+		t.Errorf("expected items ordered with 101, got %v", ids)
+	}
+	if ids := itemIDs(c.FrequentlyBoughtWith([]int{101, 401}, 10)); !equalInts(ids, []int{102, 201}) {
+		t.Errorf("expected basket items excluded, got %v", ids)
+	}
+	if ids := itemIDs(c.FrequentlyBoughtWith([]int{302}, 10)); len(ids) != 0 {
+		t.Errorf("expected nothing for never ordered item, got %v", ids)
+	}
+
+	// popular items fill up co-occurring ones
+	if ids := itemIDs(c.Recommend([]int{101}, 4)); !equalInts(ids, []int{401, 201, 402, 102}) {
+		t.Errorf("unexpected recommendations %v", ids)
+	}
+	if ids := itemIDs(c.Recommend(nil, 3)); !equalInts(ids, []int{402, 101, 401}) {
+		t.Errorf("expected popular items for empty basket, got %v", ids)
+	}
+
+	if err := c.SetAvailability(ctx, 401, false, time.Time{}); err != nil {
+		t.Fatalf("unexpected error in SetAvailability: %v", err)
+	}
+	if ids := itemIDs(c.FrequentlyBoughtWith([]int{101}, 10)); !equalInts(ids, []int{201}) {
+		t.Errorf("expected stop listed item skipped, got %v", ids)
+	}
+
+	// fresh orders are counted right away
+	if err := c.RecordOrder(ctx, Order{Time: now, Items: map[int]uint{302: 1, 202: 1}}); err != nil {
+		t.Fatalf("unexpected error in RecordOrder: %v", err)
+	}
+	if ids := itemIDs(c.FrequentlyBoughtWith([]int{302}, 10)); !equalInts(ids, []int{202}) {
+		t.Errorf("expected recorded order to be counted, got %v", ids)
+	}
+}
@@ -0,0 +1,66 @@
+package store
+
+// CartChange is a cart position the catalog changed since
+// the position was added
+type CartChange struct {
+	// Position is the position as it was added
+	Position Position
+	// Current is the position priced by the current catalog,
+	// nil if the item or a chosen option is gone
+	Current *Position
+}
+
+// Removed reports whether position can not be ordered anymore
+func (ch CartChange) Removed() bool {
+	return ch.Current == nil
+}
+
+// CheckCart returns positions which items were removed or repriced
+// since they were added. Every position is checked: versions are
+// counted by each process from scratch, so positions restored from
+// a shared or persistent session store may carry the current
+// version number read from another catalog.
+func (c *Cache) CheckCart(positions []Position) []CartChange {
+	c.mux.RLock()
+	data := c.data
+	c.mux.RUnlock()
+
+	res := []CartChange{}
+	for _, pos := range positions {
+		cur, ok := data.current(pos)
+		if !ok {
+			res = append(res, CartChange{Position: pos})
+			continue
+		}
+		if cur.Price != pos.Price {
+			res = append(res, CartChange{Position: pos, Current: &cur})
+		}
+	}
+	return res
+}
+
+// current returns position with the current item and options
+func (c *cacheData) current(pos Position) (Position, bool) {
+	item, ok := c.items[pos.Item.ID]
+	if !ok {
+		return Position{}, false
+	}
+
+	options := make([]Option, 0, len(pos.Options))
+	for _, o := range pos.Options {
+		cur, ok := item.option(o.GroupID, o.ID)
+		if !ok {
+			return Position{}, false
+		}
+		options = append(options, cur)
+	}
+
+	res := Position{
+		Item:     *item,
+		Options:  options,
+		Quantity: pos.Quantity,
+		Version:  c.version,
+	}
+	res.Price = res.UnitPrice()
+	return res, true
+}
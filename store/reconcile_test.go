@@ -0,0 +1,110 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"mania/money"
+)
+
+func TestCheckCart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir, err := ioutil.TempDir("", "mania")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "catalog.json")
+	copyFile(t, testCatalog, path)
+
+	c, err := NewCache(ctx, NewFileSource(path))
+	if err != nil {
+		t.Fatalf("unexpected error in NewCache: %v", err)
+	}
+	version := c.Version()
+
+	ss := NewSessions(ctx)
+	for _, name := range []string{"Блинчики", "Блины с творогом", "Борщ"} {
+		item, err := c.GetItem(name)
+		if err != nil {
+			t.Fatalf("unexpected error in GetItem: %v", err)
+		}
+		pos := Position{Item: *item, Quantity: 2, Version: version}
+		if group, option, ok := item.FindOption("большая"); ok {
+			pos.Options = item.SelectOption(pos.Options, group, option)
+		}
+		pos.Price = pos.UnitPrice()
+		ss.AddPosition("123", pos)
+	}
+
+	if changes := c.CheckCart(ss.GetSession("123").Positions()); len(changes) != 0 {
+		t.Errorf("expected no changes at the same version, got %v", changes)
+	}
+
+	// position from another process' catalog may have the same version
+	item, err := c.GetItem("Цезарь с курицей")
+	if err != nil {
+		t.Fatalf("unexpected error in GetItem: %v", err)
+	}
+	stale := Position{Item: *item, Quantity: 1, Version: version, Price: item.Price.Add(money.FromRubles(10))}
+	if changes := c.CheckCart([]Position{stale}); len(changes) != 1 || changes[0].Current.Price != item.Price {
+		t.Errorf("expected position priced by another catalog to be repriced, got %+v", changes)
+	}
+
+	// big portion gets pricier and borscht is gone
+	f := catalogFile{}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read catalog: %v", err)
+	}
+	if err := json.Unmarshal(b, &f); err != nil {
+		t.Fatalf("failed to decode catalog: %v", err)
+	}
+	options := f.Products[0]["options"].([]interface{})
+	sizes := options[0].(map[string]interface{})["options"].([]interface{})
+	sizes[1].(map[string]interface{})["price_delta"] = "80"
+	f.Products = append(f.Products[:4], f.Products[5:]...)
+	if b, err = json.Marshal(&f); err != nil {
+		t.Fatalf("failed to encode catalog: %v", err)
+	}
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		t.Fatalf("failed to write catalog: %v", err)
+	}
+
+	if err := c.Refresh(ctx); err != nil {
+		t.Fatalf("unexpected error in Refresh: %v", err)
+	}
+	if c.Version() == version {
+		t.Fatal("expected refresh to change catalog version")
+	}
+
+	changes := c.CheckCart(ss.GetSession("123").Positions())
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %+v", changes)
+	}
+	if ch := changes[0]; ch.Removed() || ch.Position.Item.ID != 101 || ch.Current.Price != money.FromRubles(230) {
+		t.Errorf("expected Блинчики to be repriced, got %+v", ch)
+	}
+	if ch := changes[1]; !ch.Removed() || ch.Position.Item.ID != 201 {
+		t.Errorf("expected Борщ to be removed, got %+v", ch)
+	}
+
+	ss.ApplyCartChanges("123", changes)
+	sess := ss.GetSession("123")
+	if len(sess.Cart) != 2 {
+		t.Errorf("expected 2 positions left, got %v", sess.Cart)
+	}
+	if _, amount := sess.CartTotal(); amount != money.FromRubles(230*2).Add(money.Money(19050*2)) {
+		t.Errorf("unexpected new total %s", amount)
+	}
+	if changes := c.CheckCart(sess.Positions()); len(changes) != 0 {
+		t.Errorf("expected no changes after applying them, got %v", changes)
+	}
+}
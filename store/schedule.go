@@ -0,0 +1,327 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNotOffered is returned for categories which schedule
+// is closed at the moment
+var ErrNotOffered = errors.New("not offered at the moment")
+
+// scheduleDays is how far ahead next opening time is looked for
+const scheduleDays = 7
+
+// Clock is a time of day in minutes since midnight,
+// encoded as "07:30"
+type Clock int
+
+// ParseClock parses "HH:MM" time of day
+func ParseClock(s string) (Clock, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil {
+		return 0, fmt.Errorf("bad time of day %q: %w", s, err)
+	}
+	// 24:00 is the end of the day
+	if h < 0 || h > 24 || m < 0 || m > 59 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("bad time of day %q", s)
+	}
+	return Clock(h*60 + m), nil
+}
+
+func (c Clock) String() string {
+	return fmt.Sprintf("%d:%02d", c/60, c%60)
+}
+
+// MarshalText encodes clock as "7:30"
+func (c Clock) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText decodes "7:30" clock
+func (c *Clock) UnmarshalText(b []byte) error {
+	v, err := ParseClock(string(b))
+	if err != nil {
+		return err
+	}
+	*c = v
+	return nil
+}
+
+// Schedule limits when category or item is offered.
+// Empty Days means every day, From equal to To means all day.
+// From later than To spans midnight, days are checked
+// against the current day then.
+type Schedule struct {
+	Days []time.Weekday `json:"days,omitempty"`
+	From Clock          `json:"from"`
+	To   Clock          `json:"to"`
+}
+
+// Open reports whether schedule allows t, nil schedule always does
+func (s *Schedule) Open(t time.Time) bool {
+	if s == nil {
+		return true
+	}
+
+	if len(s.Days) > 0 && !containsWeekday(s.Days, t.Weekday()) {
+		return false
+	}
+
+	m := Clock(t.Hour()*60 + t.Minute())
+	switch {
+	case s.From == s.To:
+		return true
+	case s.From < s.To:
+		return m >= s.From && m < s.To
+	default:
+		return m >= s.From || m < s.To
+	}
+}
+
+// starts returns schedule opening times during days after t
+// including the day of t, t location is used
+func (s *Schedule) starts(t time.Time, days int) []time.Time {
+	res := []time.Time{}
+	if s == nil {
+		return res
+	}
+
+	y, mon, d := t.Date()
+	for i := 0; i <= days; i++ {
+		res = append(res, time.Date(y, mon, d+i, 0, int(s.From), 0, 0, t.Location()))
+	}
+	return res
+}
+
+func containsWeekday(days []time.Weekday, d time.Weekday) bool {
+	for _, v := range days {
+		if v == d {
+			return true
+		}
+	}
+	return false
+}
+
+// weekdays maps schedule document day names
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+	"вс":  time.Sunday,
+	"пн":  time.Monday,
+	"вт":  time.Tuesday,
+	"ср":  time.Wednesday,
+	"чт":  time.Thursday,
+	"пт":  time.Friday,
+	"сб":  time.Saturday,
+}
+
+// weekdayNames are schedule document day names by weekday
+var weekdayNames = [...]string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// mapToSchedule maps optional schedule document field:
+//
+//	schedule:
+//	  days: [sat, sun]
+//	  from: "10:00"
+//	  to: "16:00"
+//
+// nil value is no schedule
+func mapToSchedule(v interface{}) (*Schedule, error) {
+	if v == nil {
+		return nil, nil
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("bad schedule")
+	}
+
+	s := Schedule{}
+	if days, ok := m["days"]; ok {
+		list, ok := days.([]interface{})
+		if !ok {
+			return nil, errors.New("bad schedule days")
+		}
+		for _, di := range list {
+			name, _ := di.(string)
+			d, ok := weekdays[strings.ToLower(name)]
+			if !ok {
+				return nil, fmt.Errorf("bad schedule day %v", di)
+			}
+			s.Days = append(s.Days, d)
+		}
+	}
+
+	for key, dst := range map[string]*Clock{"from": &s.From, "to": &s.To} {
+		str, ok := m[key].(string)
+		if !ok {
+			continue
+		}
+		c, err := ParseClock(str)
+		if err != nil {
+			return nil, fmt.Errorf("bad schedule %s: %w", key, err)
+		}
+		*dst = c
+	}
+	if s.To == 24*60 {
+		s.To = 0
+	}
+
+	return &s, nil
+}
+
+// nextOpen returns the earliest time after t open is true at,
+// candidates are opening times of schedules
+func nextOpen(t time.Time, schedules []*Schedule, open func(time.Time) bool) (time.Time, bool) {
+	y, mon, d := t.Date()
+	candidates := []time.Time{}
+	// day change may open schedules limited by days
+	for i := 1; i <= scheduleDays; i++ {
+		candidates = append(candidates, time.Date(y, mon, d+i, 0, 0, 0, 0, t.Location()))
+	}
+	for _, s := range schedules {
+		candidates = append(candidates, s.starts(t, scheduleDays)...)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Before(candidates[j])
+	})
+
+	for _, c := range candidates {
+		if c.After(t) && open(c) {
+			return c, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// now returns restaurant local time
+func (c *Cache) now() time.Time {
+	return c.clock().In(c.loc)
+}
+
+// Now returns restaurant local time schedules are checked at
+func (c *Cache) Now() time.Time {
+	return c.now()
+}
+
+// categoryOpen reports whether category and all its parents
+// schedules are open at t. Must be called with mux held.
+func (c *Cache) categoryOpen(cat *Category, t time.Time) bool {
+	// depth limit guards against parent cycles
+	for depth := 0; depth <= len(c.data.categories); depth++ {
+		if !cat.Schedule.Open(t) {
+			return false
+		}
+		parent, ok := c.data.categoriesByID[cat.ParentID]
+		if !ok || parent == cat {
+			break
+		}
+		cat = parent
+	}
+	return true
+}
+
+// categorySchedules returns schedules of category and its parents.
+// Must be called with mux held.
+func (c *Cache) categorySchedules(cat *Category) []*Schedule {
+	res := []*Schedule{}
+	for depth := 0; depth <= len(c.data.categories); depth++ {
+		if cat.Schedule != nil {
+			res = append(res, cat.Schedule)
+		}
+		parent, ok := c.data.categoriesByID[cat.ParentID]
+		if !ok || parent == cat {
+			break
+		}
+		cat = parent
+	}
+	return res
+}
+
+// itemCategories returns categories listing the item.
+// Must be called with mux held.
+func (c *Cache) itemCategories(itemID int) []*Category {
+	res := []*Category{}
+	for _, cat := range c.data.categories {
+		if containsInt(cat.Products, itemID) {
+			res = append(res, cat)
+		}
+	}
+	return res
+}
+
+// isOffered reports whether item schedule is open at t and at least
+// one of its categories is open. Must be called with mux held.
+func (c *Cache) isOffered(itemID int, t time.Time) bool {
+	item, ok := c.data.items[itemID]
+	if !ok {
+		return false
+	}
+	if !item.Schedule.Open(t) {
+		return false
+	}
+
+	cats := c.itemCategories(itemID)
+	if len(cats) == 0 {
+		return true
+	}
+	for _, cat := range cats {
+		if c.categoryOpen(cat, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOffered reports whether item is on the menu by schedules now
+func (c *Cache) IsOffered(itemID int) bool {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	return c.isOffered(itemID, c.now())
+}
+
+// NextOffered returns restaurant local time item is offered from.
+// ok is false if item is not offered during the next week.
+func (c *Cache) NextOffered(itemID int) (time.Time, bool) {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	item, ok := c.data.items[itemID]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	schedules := []*Schedule{item.Schedule}
+	for _, cat := range c.itemCategories(itemID) {
+		schedules = append(schedules, c.categorySchedules(cat)...)
+	}
+
+	return nextOpen(c.now(), schedules, func(t time.Time) bool {
+		return c.isOffered(itemID, t)
+	})
+}
+
+// NextCategoryOpen returns restaurant local time category is offered
+// from. ok is false if category is unknown or closed during the next week.
+func (c *Cache) NextCategoryOpen(categoryName string) (time.Time, bool) {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	cat, ok := c.data.categoriesByName[strings.ToLower(categoryName)]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return nextOpen(c.now(), c.categorySchedules(cat), func(t time.Time) bool {
+		return c.categoryOpen(cat, t)
+	})
+}
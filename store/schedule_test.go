@@ -0,0 +1,164 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+const scheduleCatalog = "testdata/schedule.json"
+
+// newScheduleCache returns cache of schedule catalog in loc
+// which clock is pointed to by now
+func newScheduleCache(t *testing.T, loc *time.Location, now *time.Time) *Cache {
+	t.Helper()
+
+	c, err := NewCache(
+		context.Background(),
+		NewFileSource(scheduleCatalog),
+		WithLocation(loc),
+		WithClock(func() time.Time { return *now }),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error in NewCache: %v", err)
+	}
+
+	return c
+}
+
+func TestParseClock(t *testing.T) {
+	cases := map[string]Clock{"07:00": 420, "7:05": 425, "24:00": 1440, "0:00": 0}
+	for s, expected := range cases {
+		c, err := ParseClock(s)
+		if err != nil || c != expected {
+			t.Errorf("ParseClock(%q) = %v, %v, expected %v", s, c, err, expected)
+		}
+	}
+
+	for _, s := range []string{"", "7", "25:00", "10:60", "24:30"} {
+		if _, err := ParseClock(s); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+}
+
+func TestScheduleOpen(t *testing.T) {
+	// 2020-01-04 is a Saturday
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2020, 1, day, hour, min, 0, 0, time.UTC)
+	}
+
+	breakfast := &Schedule{From: 7 * 60, To: 11 * 60}
+	night := &Schedule{From: 22 * 60, To: 2 * 60}
+	weekend := &Schedule{Days: []time.Weekday{time.Saturday, time.Sunday}}
+
+	cases := []struct {
+		s        *Schedule
+		t        time.Time
+		expected bool
+	}{
+		{nil, at(1, 3, 0), true},
+		{breakfast, at(1, 7, 0), true},
+		{breakfast, at(1, 10, 59), true},
+		{breakfast, at(1, 11, 0), false},
+		{breakfast, at(1, 6, 59), false},
+		{night, at(1, 23, 0), true},
+		{night, at(1, 1, 0), true},
+		{night, at(1, 12, 0), false},
+		{weekend, at(4, 12, 0), true},
+		{weekend, at(3, 12, 0), false},
+	}
+	for _, c := range cases {
+		if open := c.s.Open(c.t); open != c.expected {
+			t.Errorf("%+v open at %v = %v, expected %v", c.s, c.t, open, c.expected)
+		}
+	}
+}
+
+func TestMapToSchedule(t *testing.T) {
+	s, err := mapToSchedule(map[string]interface{}{
+		"days": []interface{}{"sat", "Вс"},
+		"from": "12:00",
+		"to":   "24:00",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error in mapToSchedule: %v", err)
+	}
+	if len(s.Days) != 2 || s.Days[0] != time.Saturday || s.Days[1] != time.Sunday ||
+		s.From != 12*60 || s.To != 0 {
+		t.Errorf("unexpected schedule: %+v", s)
+	}
+
+	if s, err := mapToSchedule(nil); s != nil || err != nil {
+		t.Errorf("expected no schedule, got %v, %v", s, err)
+	}
+	if _, err := mapToSchedule(map[string]interface{}{"days": []interface{}{"someday"}}); err == nil {
+		t.Error("expected error for bad day")
+	}
+	if _, err := mapToSchedule(map[string]interface{}{"from": "9 утра"}); err == nil {
+		t.Error("expected error for bad time")
+	}
+}
+
+func TestCacheSchedules(t *testing.T) {
+	loc := time.FixedZone("MSK", 3*60*60)
+	// Friday 2020-01-03 9:00 in restaurant time zone, 6:00 UTC
+	now := time.Date(2020, 1, 3, 6, 0, 0, 0, time.UTC)
+	c := newScheduleCache(t, loc, &now)
+
+	if cats := c.GetCategoriesPage(0, 10); len(cats) != 3 {
+		t.Errorf("expected all categories open in the morning, got %d", len(cats))
+	}
+	if !c.IsOffered(101) || !c.IsOffered(102) {
+		t.Error("expected breakfast items offered in the morning")
+	}
+	if c.IsOffered(202) {
+		t.Error("expected weekend item not offered on Friday")
+	}
+	items, err := c.GetItemsPage("Горячее", 0, 10)
+	if err != nil || len(items) != 1 || items[0].ID != 201 {
+		t.Errorf("expected only item 201, got %v, %v", items, err)
+	}
+
+	at, ok := c.NextOffered(202)
+	if expected := time.Date(2020, 1, 4, 12, 0, 0, 0, loc); !ok || !at.Equal(expected) {
+		t.Errorf("expected 202 offered from %v, got %v, %v", expected, at, ok)
+	}
+
+	// Friday 12:00
+	now = now.Add(3 * time.Hour)
+	if cats, _ := c.GetSubcategoriesPage("", 0, 10); len(cats) != 1 || cats[0].Name != "Горячее" {
+		t.Errorf("expected breakfast closed at noon, got %v", cats)
+	}
+	if _, err := c.GetItemsPage("Завтраки", 0, 10); err != ErrNotOffered {
+		t.Errorf("expected ErrNotOffered for closed category, got %v", err)
+	}
+	// subcategory closes with its parent
+	if _, err := c.GetItemsPage("Каши", 0, 10); err != ErrNotOffered {
+		t.Errorf("expected ErrNotOffered for closed parent, got %v", err)
+	}
+	if c.IsOffered(102) {
+		t.Error("expected subcategory item not offered at noon")
+	}
+	if matches := c.SearchItems("сырники", 0, 10); len(matches) != 0 {
+		t.Errorf("expected closed items not found, got %v", matches)
+	}
+
+	at, ok = c.NextOffered(102)
+	if expected := time.Date(2020, 1, 4, 7, 0, 0, 0, loc); !ok || !at.Equal(expected) {
+		t.Errorf("expected 102 offered from %v, got %v, %v", expected, at, ok)
+	}
+	at, ok = c.NextCategoryOpen("завтраки")
+	if expected := time.Date(2020, 1, 4, 7, 0, 0, 0, loc); !ok || !at.Equal(expected) {
+		t.Errorf("expected breakfast open from %v, got %v, %v", expected, at, ok)
+	}
+
+	// Saturday 13:00
+	now = time.Date(2020, 1, 4, 10, 0, 0, 0, time.UTC)
+	if !c.IsOffered(202) {
+		t.Error("expected weekend item offered on Saturday")
+	}
+	if c.Now().Location() != loc {
+		t.Errorf("expected restaurant location, got %v", c.Now().Location())
+	}
+}
@@ -0,0 +1,174 @@
+package store
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// item fields weights in search results ranking
+const (
+	nameWeight        = 3
+	compositionWeight = 2
+	descriptionWeight = 1
+)
+
+// searchStopWords are query words carrying no meaning for search
+var searchStopWords = map[string]bool{
+	"что": true, "нибудь": true, "у": true, "вас": true, "есть": true,
+	"какие": true, "какой": true, "какое": true, "хочу": true, "мне": true,
+	"блюда": true, "блюдо": true, "что-то": true, "то": true, "чтонибудь": true,
+}
+
+// minPrefix is the shortest common stem prefix considered a match.
+// Stemmer leaves different suffixes for word forms like
+// "курицей" ("куриц") and "куриное" ("курин").
+const minPrefix = 4
+
+// searchIndex is an inverted index of item text fields
+// mapping word stems to item IDs with field weights
+type searchIndex map[string]map[int]int
+
+// searchTerms returns stems of meaningful words of s
+func searchTerms(s string) []string {
+	terms := []string{}
+	for _, w := range strings.Fields(normalize(s)) {
+		if stopWords[w] || searchStopWords[w] || !isWord(w) {
+			continue
+		}
+		terms = append(terms, Stem(w))
+	}
+	return terms
+}
+
+// isWord filters out numbers and single letters, like nutrients values
+func isWord(w string) bool {
+	r := []rune(w)
+	if len(r) < 2 {
+		return false
+	}
+	for _, c := range r {
+		if !unicode.IsLetter(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// SplitExclusions splits words following "без" out of the query,
+// so "салат без орехов" searches "салат" excluding "орехов"
+func SplitExclusions(query string) (string, []string) {
+	words := strings.Fields(normalize(query))
+	rest := make([]string, 0, len(words))
+	excluded := []string{}
+	for i := 0; i < len(words); i++ {
+		if words[i] == "без" && i+1 < len(words) {
+			i++
+			excluded = append(excluded, words[i])
+			continue
+		}
+		rest = append(rest, words[i])
+	}
+
+	return strings.Join(rest, " "), excluded
+}
+
+// newSearchIndex builds index over items names, descriptions
+// and compositions
+func newSearchIndex(items []*Item) searchIndex {
+	idx := make(searchIndex)
+	add := func(text string, id, weight int) {
+		for _, t := range searchTerms(text) {
+			if idx[t] == nil {
+				idx[t] = make(map[int]int)
+			}
+			if idx[t][id] < weight {
+				idx[t][id] = weight
+			}
+		}
+	}
+
+	for _, item := range items {
+		add(item.Name, item.ID, nameWeight)
+		add(item.Composition, item.ID, compositionWeight)
+		add(item.Description, item.ID, descriptionWeight)
+	}
+
+	return idx
+}
+
+// termsMatch reports whether index term matches query term
+func termsMatch(term, q string) bool {
+	if term == q {
+		return true
+	}
+
+	tr, qr := []rune(term), []rune(q)
+	n := len(tr)
+	if len(qr) < n {
+		n = len(qr)
+	}
+	if n < minPrefix {
+		return false
+	}
+
+	common := 0
+	for common < n && tr[common] == qr[common] {
+		common++
+	}
+
+	return common >= minPrefix && common >= n-1
+}
+
+// search returns IDs of items matching every meaningful query word,
+// most relevant first
+func (idx searchIndex) search(query string) []int {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	scores := map[int]int{}
+	for i, q := range terms {
+		// best weight of this query term per item
+		found := map[int]int{}
+		for term, postings := range idx {
+			if !termsMatch(term, q) {
+				continue
+			}
+			for id, w := range postings {
+				if found[id] < w {
+					found[id] = w
+				}
+			}
+		}
+
+		for id, w := range found {
+			// every query term must match
+			if i > 0 {
+				if _, ok := scores[id]; !ok {
+					continue
+				}
+			}
+			scores[id] += w
+		}
+		for id := range scores {
+			if _, ok := found[id]; !ok {
+				delete(scores, id)
+			}
+		}
+	}
+
+	ids := make([]int, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+
+	return ids
+}
@@ -0,0 +1,77 @@
+package store
+
+import "testing"
+
+func TestSearchItems(t *testing.T) {
+	c := newTestCache(t)
+
+	names := func(items []*Item) []string {
+		res := make([]string, len(items))
+		for i := range items {
+			res[i] = items[i].Name
+		}
+		return res
+	}
+
+	cases := []struct {
+		query    string
+		expected []string
+	}{
+		{"что у вас есть с курицей", []string{
+			"Блины с курицей и грибами",
+			"Куриный суп с лапшой",
+			"Цезарь с курицей",
+		}},
+		{"с грибами", []string{"Блины с курицей и грибами"}},
+		{"что-нибудь с грецкими орехами", []string{"Салат с орехами и грушей"}},
+		{"блины с сыром", []string{"Блины с курицей и грибами", "Блины с семгой"}},
+		{"пицца", []string{}},
+		{"что у вас есть", []string{}},
+	}
+
+	for _, cs := range cases {
+		got := names(c.SearchItems(cs.query, 0, 10))
+		if len(got) != len(cs.expected) {
+			t.Errorf("SearchItems(%q) = %v, expected %v", cs.query, got, cs.expected)
+			continue
+		}
+		for i := range got {
+			if got[i] != cs.expected[i] {
+				t.Errorf("SearchItems(%q) = %v, expected %v", cs.query, got, cs.expected)
+				break
+			}
+		}
+	}
+}
+
+func TestSearchItemsExclusionsOnly(t *testing.T) {
+	c := newTestCache(t)
+
+	items := c.SearchItems("что-нибудь без орехов", 0, 100)
+	if len(items) != len(c.GetAllItemsPage(0, 100))-1 {
+		t.Fatalf("expected all items but the nut salad, got %d items", len(items))
+	}
+	for _, item := range items {
+		if item.Name == "Салат с орехами и грушей" {
+			t.Errorf("expected items with nuts to be excluded, got %v", item.Name)
+		}
+	}
+}
+
+func TestSearchItemsPaging(t *testing.T) {
+	c := newTestCache(t)
+
+	all := c.SearchItems("блин", 0, 10)
+	if len(all) != 4 {
+		t.Fatalf("unexpected len=%d from SearchItems (expected 4)", len(all))
+	}
+
+	page := c.SearchItems("блин", 1, 3)
+	if len(page) != 1 || page[0] != all[3] {
+		t.Errorf("unexpected second page: %v", page)
+	}
+
+	if page := c.SearchItems("блин", 2, 3); len(page) != 0 {
+		t.Errorf("expected empty page past the end, got %v", page)
+	}
+}
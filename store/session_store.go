@@ -0,0 +1,97 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNoSession is returned for missing and expired sessions
+	ErrNoSession = errors.New("no session")
+	// ErrSessionConflict is returned by SessionStore.Put when the
+	// session was written by someone else since it was read
+	ErrSessionConflict = errors.New("session changed concurrently")
+)
+
+// SessionStore keeps customer sessions. Sessions are versioned, so
+// instances sharing a store never overwrite each other's changes:
+// session read at a version is written only if it is still current.
+type SessionStore interface {
+	// Get returns session and its version,
+	// ErrNoSession if it is missing or expired
+	Get(ctx context.Context, id string) (Session, int64, error)
+	// Put writes session read at version, 0 for a new one, which
+	// expires at expiresAt. ErrSessionConflict is returned
+	// if the stored version is another one.
+	Put(ctx context.Context, id string, s Session, version int64, expiresAt time.Time) error
+	// Expire deletes sessions expired by now
+	Expire(ctx context.Context, now time.Time) error
+}
+
+// sessionRecord is a stored session with its version and expiry
+type sessionRecord struct {
+	Version   int64     `json:"version"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Session   Session   `json:"session"`
+}
+
+// expired reports whether record is gone by now
+func (r sessionRecord) expired(now time.Time) bool {
+	return !r.ExpiresAt.After(now)
+}
+
+// MemorySessionStore keeps sessions in memory, they are lost
+// on restart and not shared between instances
+type MemorySessionStore struct {
+	mux      sync.RWMutex
+	sessions map[string]sessionRecord
+}
+
+// NewMemorySessionStore returns an empty MemorySessionStore
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: make(map[string]sessionRecord)}
+}
+
+// Get returns session copy
+func (st *MemorySessionStore) Get(ctx context.Context, id string) (Session, int64, error) {
+	st.mux.RLock()
+	defer st.mux.RUnlock()
+
+	r, ok := st.sessions[id]
+	if !ok || r.expired(time.Now()) {
+		return Session{}, 0, ErrNoSession
+	}
+	return r.Session.clone(), r.Version, nil
+}
+
+// Put stores session copy
+func (st *MemorySessionStore) Put(ctx context.Context, id string, s Session, version int64, expiresAt time.Time) error {
+	st.mux.Lock()
+	defer st.mux.Unlock()
+
+	current := int64(0)
+	if r, ok := st.sessions[id]; ok && !r.expired(time.Now()) {
+		current = r.Version
+	}
+	if current != version {
+		return ErrSessionConflict
+	}
+
+	st.sessions[id] = sessionRecord{Version: version + 1, ExpiresAt: expiresAt, Session: s.clone()}
+	return nil
+}
+
+// Expire deletes expired sessions
+func (st *MemorySessionStore) Expire(ctx context.Context, now time.Time) error {
+	st.mux.Lock()
+	defer st.mux.Unlock()
+
+	for id, r := range st.sessions {
+		if r.expired(now) {
+			delete(st.sessions, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package store
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testSessionStore checks versioning and expiry every store must have
+func testSessionStore(t *testing.T, st SessionStore) {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Now()
+
+	if _, _, err := st.Get(ctx, "test-1"); err != ErrNoSession {
+		t.Fatalf("expected ErrNoSession for missing session, got %v", err)
+	}
+
+	p := Position{Item: Item{ID: 101, Name: "Блинчики"}, Quantity: 2}
+	s := Session{CurrentCategory: "Блины", Cart: map[string]Position{p.Key(): p}}
+	if err := st.Put(ctx, "test-1", s, 0, now.Add(time.Hour)); err != nil {
+		t.Fatalf("unexpected error in Put: %v", err)
+	}
+	got, version, err := st.Get(ctx, "test-1")
+	if err != nil {
+		t.Fatalf("unexpected error in Get: %v", err)
+	}
+	if version != 1 || got.CurrentCategory != "Блины" || got.Cart[p.Key()].Quantity != 2 {
+		t.Errorf("unexpected session %+v at version %d", got, version)
+	}
+
+	if err := st.Put(ctx, "test-1", s, 0, now.Add(time.Hour)); err != ErrSessionConflict {
+		t.Errorf("expected ErrSessionConflict creating existing session, got %v", err)
+	}
+	got.CurrentPage = 1
+	if err := st.Put(ctx, "test-1", got, version, now.Add(time.Hour)); err != nil {
+		t.Fatalf("unexpected error in Put: %v", err)
+	}
+	if err := st.Put(ctx, "test-1", got, version, now.Add(time.Hour)); err != ErrSessionConflict {
+		t.Errorf("expected ErrSessionConflict writing stale version, got %v", err)
+	}
+
+	if err := st.Put(ctx, "test-2", s, 0, now.Add(-time.Second)); err != nil {
+		t.Fatalf("unexpected error in Put: %v", err)
+	}
+	if _, _, err := st.Get(ctx, "test-2"); err != ErrNoSession {
+		t.Errorf("expected ErrNoSession for expired session, got %v", err)
+	}
+	// expired session is replaced as a new one
+	if err := st.Put(ctx, "test-2", s, 0, now.Add(-time.Second)); err != nil {
+		t.Errorf("unexpected error replacing expired session: %v", err)
+	}
+
+	if err := st.Expire(ctx, now.Add(2*time.Hour)); err != nil {
+		t.Fatalf("unexpected error in Expire: %v", err)
+	}
+	if _, _, err := st.Get(ctx, "test-1"); err != ErrNoSession {
+		t.Errorf("expected session to expire, got %v", err)
+	}
+}
+
+func TestMemorySessionStore(t *testing.T) {
+	testSessionStore(t, NewMemorySessionStore())
+}
+
+func TestBoltSessionStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sessions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "sessions.db")
+
+	st, err := OpenBoltSessionStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error in OpenBoltSessionStore: %v", err)
+	}
+	testSessionStore(t, st)
+	testSessionStore(t, st.ForTenant("cafe"))
+
+	ctx := context.Background()
+	if err := st.Put(ctx, "kept", Session{CurrentPage: 2}, 0, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("unexpected error in Put: %v", err)
+	}
+	if err := st.Close(); err != nil {
+		t.Fatalf("unexpected error in Close: %v", err)
+	}
+
+	st, err = OpenBoltSessionStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error reopening sessions file: %v", err)
+	}
+	defer st.Close()
+	if s, version, err := st.Get(ctx, "kept"); err != nil || version != 1 || s.CurrentPage != 2 {
+		t.Errorf("expected session to survive reopening, got %+v at version %d, error %v", s, version, err)
+	}
+	if _, _, err := st.ForTenant("cafe").Get(ctx, "kept"); err != ErrNoSession {
+		t.Errorf("expected tenant sessions to be separate, got %v", err)
+	}
+}
+
+func TestFirestoreSessionStore(t *testing.T) {
+	skipWithoutFirestore(t)
+	ctx := context.Background()
+	db, err := New(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error in New: %v", err)
+	}
+	testSessionStore(t, NewFirestoreSessionStore(db.ForTenant("test-sessions")))
+}
+
+// racingStore writes the session behind Sessions' back once,
+// the way another instance would
+type racingStore struct {
+	SessionStore
+	raced bool
+}
+
+func (st *racingStore) Put(ctx context.Context, id string, s Session, version int64, expiresAt time.Time) error {
+	if !st.raced {
+		st.raced = true
+		other := newSession()
+		other.Excluded = []string{"лук"}
+		if err := st.SessionStore.Put(ctx, id, *other, version, expiresAt); err != nil {
+			return err
+		}
+	}
+	return st.SessionStore.Put(ctx, id, s, version, expiresAt)
+}
+
+func TestSessionsRetryConflict(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s := NewSessions(ctx, WithSessionStore(&racingStore{SessionStore: NewMemorySessionStore()}))
+
+	s.AddPosition("123", Position{Item: Item{ID: 101, Name: "Блинчики"}, Quantity: 1})
+	got := s.GetSession("123")
+	if len(got.Cart) != 1 {
+		t.Errorf("expected position to be added after conflict, got cart %v", got.Cart)
+	}
+	if !containsString(got.Excluded, "лук") {
+		t.Errorf("expected concurrent change to be kept, got %v", got.Excluded)
+	}
+}
+
+func TestSessionsTTL(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	st := NewMemorySessionStore()
+	s := NewSessions(ctx, WithSessionStore(st), WithSessionTTL(time.Minute))
+
+	s.NextPage("123")
+	r := st.sessions["123"]
+	if d := time.Until(r.ExpiresAt); d <= 0 || d > time.Minute {
+		t.Errorf("expected session to expire in a minute, got %v", d)
+	}
+	if err := st.Expire(ctx, time.Now().Add(2*time.Minute)); err != nil {
+		t.Fatalf("unexpected error in Expire: %v", err)
+	}
+	if got := s.GetSession("123"); got.CurrentPage != 0 {
+		t.Errorf("expected expired session to start over, got page %d", got.CurrentPage)
+	}
+}
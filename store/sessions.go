@@ -2,47 +2,184 @@ package store
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
-	"sync"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
+
+	"mania/money"
 )
 
 const (
 	maxTTL         = time.Minute * 30
 	expireInterval = time.Minute * 5
+	// maxUpdateTries limits session rewrites when
+	// other instances keep changing it
+	maxUpdateTries = 5
 )
 
 // Position holds invoice line for users' cart
 type Position struct {
 	Item     Item
+	Options  []Option
 	Quantity uint
+	// Version is catalog version the item was read at
+	Version uint64
+	// Price is unit price with options customer was told
+	Price money.Money
+}
+
+// Key returns cart key of the position: same item with
+// different options makes different cart lines
+func (p Position) Key() string {
+	if len(p.Options) == 0 {
+		return strconv.Itoa(p.Item.ID)
+	}
+	return fmt.Sprintf("%d:%s", p.Item.ID, optionsKey(p.Options))
+}
+
+// Name returns item name with chosen options:
+// "Блинчики (большая, сгущенка)"
+func (p Position) Name() string {
+	if len(p.Options) == 0 {
+		return p.Item.Name
+	}
+
+	names := make([]string, len(p.Options))
+	for i, o := range p.Options {
+		names[i] = o.Name
+	}
+	return fmt.Sprintf("%s (%s)", p.Item.Name, strings.Join(names, ", "))
+}
+
+// UnitPrice returns item price with options
+func (p Position) UnitPrice() money.Money {
+	price := p.Item.Price
+	for _, o := range p.Options {
+		price = price.Add(o.PriceDelta)
+	}
+	return price
+}
+
+// Total returns position amount
+func (p Position) Total() money.Money {
+	return p.UnitPrice().Mul(p.Quantity)
 }
 
 // Session holds user conversation context
 type Session struct {
 	CurrentPage int
-	Cart        map[int]Position
-	created     time.Time
+	// CurrentCategory is a category customer navigates now,
+	// empty for the menu root
+	CurrentCategory string
+	// CurrentQuery is a search or filter listing customer pages
+	// through now, empty while browsing categories
+	CurrentQuery string
+	// Excluded holds ingredients and allergens customer asked to avoid
+	Excluded []string
+	// Pending is a position waiting for required options
+	Pending *Position
+	Cart    map[string]Position
+}
+
+// CartTotal returns cart items count and amount
+func (s Session) CartTotal() (uint, money.Money) {
+	cnt := uint(0)
+	amount := money.Money(0)
+	for _, pos := range s.Cart {
+		cnt += pos.Quantity
+		amount = amount.Add(pos.Total())
+	}
+
+	return cnt, amount
+}
+
+// Positions returns cart positions ordered by key
+func (s Session) Positions() []Position {
+	res := make([]Position, 0, len(s.Cart))
+	for _, pos := range s.Cart {
+		res = append(res, pos)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Key() < res[j].Key() })
+	return res
+}
+
+// ItemIDs returns IDs of items in the cart in ascending order
+func (s Session) ItemIDs() []int {
+	ids := make([]int, 0, len(s.Cart))
+	for _, pos := range s.Cart {
+		if !containsInt(ids, pos.Item.ID) {
+			ids = append(ids, pos.Item.ID)
+		}
+	}
+	sort.Ints(ids)
+	return ids
 }
 
 // newSession returns a new Session instance
 func newSession() *Session {
 	return &Session{
-		created: time.Now().UTC(),
-		Cart:    make(map[int]Position),
+		Cart: make(map[string]Position),
 	}
 }
 
+// clone returns session copy stores keep, so callers
+// never share carts and lists with them
+func (s Session) clone() Session {
+	res := s
+	res.Excluded = append([]string(nil), s.Excluded...)
+	if s.Pending != nil {
+		pending := *s.Pending
+		res.Pending = &pending
+	}
+	res.Cart = make(map[string]Position, len(s.Cart))
+	for k, pos := range s.Cart {
+		res.Cart[k] = pos
+	}
+	return res
+}
+
 // Sessions stores all active users' conversations' contexts
+// in a SessionStore, in memory unless configured otherwise
 type Sessions struct {
-	mux      sync.RWMutex
-	sessions map[string]*Session
+	ctx   context.Context
+	store SessionStore
+	ttl   time.Duration
+}
+
+// SessionsOption configures Sessions
+type SessionsOption func(*Sessions)
+
+// WithSessionStore keeps sessions in st
+func WithSessionStore(st SessionStore) SessionsOption {
+	return func(ss *Sessions) {
+		ss.store = st
+	}
+}
+
+// WithSessionTTL sets how long idle sessions are kept
+func WithSessionTTL(d time.Duration) SessionsOption {
+	return func(ss *Sessions) {
+		if d > 0 {
+			ss.ttl = d
+		}
+	}
 }
 
 // NewSessions returns a new Sessions store instance
-func NewSessions(ctx context.Context) *Sessions {
+func NewSessions(ctx context.Context, opts ...SessionsOption) *Sessions {
 	s := Sessions{
-		sessions: make(map[string]*Session),
+		ctx: ctx,
+		ttl: maxTTL,
+	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	if s.store == nil {
+		s.store = NewMemorySessionStore()
 	}
 
 	go s.cleanupLoop(ctx)
@@ -52,19 +189,15 @@ func NewSessions(ctx context.Context) *Sessions {
 
 // cleanupExpiredSessions removes expired sessions
 func (ss *Sessions) cleanupExpiredSessions() {
-	ss.mux.Lock()
-	defer ss.mux.Unlock()
-
-	for k := range ss.sessions {
-		if time.Now().UTC().Sub(ss.sessions[k].created) > maxTTL {
-			delete(ss.sessions, k)
-		}
+	if err := ss.store.Expire(ss.ctx, time.Now()); err != nil {
+		log.Printf("ERROR: failed to expire sessions: %v", err)
 	}
 }
 
 // cleanupLoop cleans expired sessions every expireInterval
 func (ss *Sessions) cleanupLoop(ctx context.Context) {
 	tick := time.NewTicker(expireInterval)
+	defer tick.Stop()
 
 	for {
 		select {
@@ -77,97 +210,194 @@ func (ss *Sessions) cleanupLoop(ctx context.Context) {
 	}
 }
 
+// update applies fn to the stored session logging failure,
+// sessions are best effort for most changes
+func (ss *Sessions) update(id string, create bool, fn func(s *Session)) {
+	if err := ss.tryUpdate(id, create, fn); err != nil {
+		log.Printf("ERROR: %v", err)
+	}
+}
+
+// tryUpdate applies fn to the stored session and writes it back
+// extending its TTL. Session changed by another instance meanwhile
+// is read again and fn is reapplied. Missing session is created
+// if create is set, fn is not called otherwise.
+func (ss *Sessions) tryUpdate(id string, create bool, fn func(s *Session)) error {
+	for try := 0; try < maxUpdateTries; try++ {
+		s, version, err := ss.store.Get(ss.ctx, id)
+		if errors.Is(err, ErrNoSession) {
+			if !create {
+				return nil
+			}
+			s, version, err = *newSession(), 0, nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read session %s: %w", id, err)
+		}
+		if s.Cart == nil {
+			s.Cart = make(map[string]Position)
+		}
+
+		fn(&s)
+
+		err = ss.store.Put(ss.ctx, id, s, version, time.Now().Add(ss.ttl))
+		if errors.Is(err, ErrSessionConflict) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write session %s: %w", id, err)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("failed to write session %s after %d tries: %w", id, maxUpdateTries, ErrSessionConflict)
+}
+
 // NewSession creates new session in the store
 func (ss *Sessions) NewSession(id string) {
-	ss.mux.Lock()
-	defer ss.mux.Unlock()
-
 	log.Printf("L0G: Creating new session %s", id)
 
-	ss.sessions[id] = newSession()
+	ss.update(id, true, func(s *Session) {
+		*s = *newSession()
+	})
 }
 
-// NextPage increments current page for paging operations in the session
-func (ss *Sessions) NextPage(id string) {
-	ss.mux.Lock()
-	defer ss.mux.Unlock()
+// NextPage increments current page for paging operations in the session.
+// Handlers page through listings by the stored page, so failure to store
+// it is returned.
+func (ss *Sessions) NextPage(id string) error {
+	return ss.tryUpdate(id, true, func(s *Session) {
+		s.CurrentPage++
+	})
+}
 
-	s, ok := ss.sessions[id]
-	if !ok {
-		s = newSession()
-	}
+// ResetPage sets current page for paging operations in the session to zero,
+// failure to store it is returned
+func (ss *Sessions) ResetPage(id string) error {
+	return ss.tryUpdate(id, false, func(s *Session) {
+		s.CurrentPage = 0
+	})
+}
+
+// SetCategory sets category customer navigates now.
+// Current page is reset when category changes or customer
+// comes back to categories from a query listing.
+func (ss *Sessions) SetCategory(id, categoryName string) {
+	ss.update(id, true, func(s *Session) {
+		if s.CurrentCategory != categoryName || s.CurrentQuery != "" {
+			s.CurrentCategory = categoryName
+			s.CurrentQuery = ""
+			s.CurrentPage = 0
+		}
+	})
+}
 
-	s.CurrentPage++
-	ss.sessions[id] = s
+// SetQuery sets search or filter listing customer pages through now.
+// Current page is reset when query changes.
+func (ss *Sessions) SetQuery(id, query string) {
+	ss.update(id, true, func(s *Session) {
+		if s.CurrentQuery != query {
+			s.CurrentQuery = query
+			s.CurrentPage = 0
+		}
+	})
 }
 
-// ResetPage sets current page for paging operations in the session to zero
-func (ss *Sessions) ResetPage(id string) {
-	ss.mux.Lock()
-	defer ss.mux.Unlock()
+// AddExcluded remembers ingredients and allergens customer asked to avoid
+func (ss *Sessions) AddExcluded(id string, words ...string) {
+	ss.update(id, true, func(s *Session) {
+		for _, w := range words {
+			w = strings.ToLower(strings.TrimSpace(w))
+			if w == "" || containsString(s.Excluded, w) {
+				continue
+			}
+			s.Excluded = append(s.Excluded, w)
+		}
+	})
+}
 
-	s, ok := ss.sessions[id]
-	if ok {
-		s.CurrentPage = 0
-		ss.sessions[id] = s
+// containsString reports whether slice contains s
+func containsString(slice []string, s string) bool {
+	for _, v := range slice {
+		if v == s {
+			return true
+		}
 	}
+	return false
 }
 
 // AddPosition adds position to user's cart
 func (ss *Sessions) AddPosition(id string, pos Position) {
-	ss.mux.Lock()
-	defer ss.mux.Unlock()
+	log.Printf("L0G: Adding position %v to session %s", pos, id)
 
-	s, ok := ss.sessions[id]
-	if !ok {
-		s = newSession()
-		ss.sessions[id] = s
-	}
+	ss.update(id, true, func(s *Session) {
+		s.Cart[pos.Key()] = pos
+	})
+}
 
-	log.Printf("L0G: Adding position %v to session %s", pos, id)
+// ApplyCartChanges updates user's cart to the current catalog:
+// removed positions are dropped, repriced ones replaced
+func (ss *Sessions) ApplyCartChanges(id string, changes []CartChange) {
+	ss.update(id, false, func(s *Session) {
+		for _, ch := range changes {
+			delete(s.Cart, ch.Position.Key())
+			if ch.Current != nil {
+				s.Cart[ch.Current.Key()] = *ch.Current
+			}
+		}
+	})
+}
 
-	s.Cart[pos.Item.ID] = pos
+// SetPending stores position waiting for required options,
+// nil clears it
+func (ss *Sessions) SetPending(id string, pos *Position) {
+	ss.update(id, true, func(s *Session) {
+		s.Pending = pos
+	})
 }
 
-// RemovePosition removes position to user's cart
-func (ss *Sessions) RemovePosition(id string, itemID int) {
-	ss.mux.Lock()
-	defer ss.mux.Unlock()
+// RemovePosition removes position from user's cart by position key
+func (ss *Sessions) RemovePosition(id string, key string) {
+	ss.update(id, false, func(s *Session) {
+		delete(s.Cart, key)
+	})
+}
 
-	s, ok := ss.sessions[id]
-	if ok {
-		delete(s.Cart, itemID)
-	}
+// RemoveOrdered removes ordered positions from user's cart,
+// positions added since the order was read stay
+func (ss *Sessions) RemoveOrdered(id string, ordered []Position) {
+	ss.update(id, false, func(s *Session) {
+		for _, pos := range ordered {
+			delete(s.Cart, pos.Key())
+		}
+	})
 }
 
 // RemoveCart removes user's cart
 func (ss *Sessions) RemoveCart(id string, itemID int) {
-	ss.mux.Lock()
-	defer ss.mux.Unlock()
-
-	s, ok := ss.sessions[id]
-	if ok {
-		s.Cart = make(map[int]Position)
-	}
+	ss.update(id, false, func(s *Session) {
+		s.Cart = make(map[string]Position)
+	})
 }
 
-// GetSession returns user's session object
+// GetSession returns user's session object,
+// an empty one if there is none
 func (ss *Sessions) GetSession(id string) Session {
-	ss.mux.RLock()
-	defer ss.mux.RUnlock()
-
 	log.Printf("L0G: Returning session %s", id)
 
-	s := ss.sessions[id]
-	if s != nil {
-
-		log.Printf("L0G: Session %s: %v", id, *s)
-		return *s
+	s, _, err := ss.store.Get(ss.ctx, id)
+	if errors.Is(err, ErrNoSession) {
+		log.Printf("L0G: Session %s is empty", id)
+		return *newSession()
+	}
+	if err != nil {
+		log.Printf("ERROR: failed to read session %s: %v", id, err)
+		return *newSession()
+	}
+	if s.Cart == nil {
+		s.Cart = make(map[string]Position)
 	}
 
-	log.Printf("L0G: Session %s is empty", id)
-	s = newSession()
-	ss.sessions[id] = s
-
-	return *s
+	log.Printf("L0G: Session %s: %v", id, s)
+	return s
 }
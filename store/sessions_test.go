@@ -19,8 +19,8 @@ func TestNewSession(t *testing.T) {
 	cancel()
 	s := NewSessions(ctx)
 	s.NewSession("123")
-	if len(s.sessions) != 1 {
-		t.Errorf("expected one session, got %d", len(s.sessions))
+	if n := len(s.store.(*MemorySessionStore).sessions); n != 1 {
+		t.Errorf("expected one session, got %d", n)
 	}
 }
 
@@ -32,8 +32,8 @@ func TestNextPage(t *testing.T) {
 	s.NextPage("123")
 	s.NextPage("123")
 	s.NextPage("123")
-	if len(s.sessions) != 1 {
-		t.Errorf("expected one session, got %d", len(s.sessions))
+	if n := len(s.store.(*MemorySessionStore).sessions); n != 1 {
+		t.Errorf("expected one session, got %d", n)
 	}
 	s2 := s.GetSession("123")
 	if s2.CurrentPage != 3 {
@@ -45,3 +45,69 @@ func TestNextPage(t *testing.T) {
 		t.Errorf("expected session to have current page = 0, but got %d", s3.CurrentPage)
 	}
 }
+
+func TestSetCategory(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s := NewSessions(ctx)
+	s.SetCategory("123", "Блины")
+	s.NextPage("123")
+	s.SetCategory("123", "Блины")
+	if s2 := s.GetSession("123"); s2.CurrentPage != 1 || s2.CurrentCategory != "Блины" {
+		t.Errorf("expected page 1 in Блины, got page %d in %q", s2.CurrentPage, s2.CurrentCategory)
+	}
+	s.SetCategory("123", "Сладкие блины")
+	if s3 := s.GetSession("123"); s3.CurrentPage != 0 || s3.CurrentCategory != "Сладкие блины" {
+		t.Errorf("expected page 0 in Сладкие блины, got page %d in %q", s3.CurrentPage, s3.CurrentCategory)
+	}
+}
+
+func TestSetQuery(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s := NewSessions(ctx)
+	s.SetCategory("123", "Блины")
+	s.NextPage("123")
+	s.SetQuery("123", "блины")
+	if s2 := s.GetSession("123"); s2.CurrentPage != 0 || s2.CurrentQuery != "блины" {
+		t.Errorf("expected page 0 of блины, got page %d of %q", s2.CurrentPage, s2.CurrentQuery)
+	}
+	s.NextPage("123")
+	s.SetQuery("123", "блины")
+	if s3 := s.GetSession("123"); s3.CurrentPage != 1 {
+		t.Errorf("expected the same query to keep page 1, got %d", s3.CurrentPage)
+	}
+	// back to the same category starts it over
+	s.SetCategory("123", "Блины")
+	if s4 := s.GetSession("123"); s4.CurrentPage != 0 || s4.CurrentQuery != "" {
+		t.Errorf("expected page 0 of Блины, got page %d of %q", s4.CurrentPage, s4.CurrentQuery)
+	}
+}
+
+func TestAddExcluded(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s := NewSessions(ctx)
+	s.AddExcluded("123", "Орехи", "лук")
+	s.AddExcluded("123", "орехи", " ")
+	s2 := s.GetSession("123")
+	if len(s2.Excluded) != 2 || s2.Excluded[0] != "орехи" || s2.Excluded[1] != "лук" {
+		t.Errorf("unexpected excluded list: %v", s2.Excluded)
+	}
+}
+
+func TestCartTotal(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s := NewSessions(ctx)
+	s.AddPosition("123", Position{Item: Item{ID: 1, Price: 19050}, Quantity: 3})
+	s.AddPosition("123", Position{Item: Item{ID: 2, Price: 10}, Quantity: 7})
+
+	cnt, amount := s.GetSession("123").CartTotal()
+	if cnt != 10 {
+		t.Errorf("expected 10 items in cart, got %d", cnt)
+	}
+	if amount != 57220 {
+		t.Errorf("expected 572,20 total, got %s", amount)
+	}
+}
@@ -0,0 +1,137 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// snapshotFormat is the version of snapshot file layout,
+	// snapshots of other versions are ignored
+	snapshotFormat = 1
+	// maxSnapshots is how many snapshot files we keep on disk
+	maxSnapshots   = 5
+	snapshotPrefix = "catalog-"
+	snapshotSuffix = ".json"
+)
+
+// ErrNoSnapshot is returned when no usable snapshot is found
+var ErrNoSnapshot = errors.New("no catalog snapshot found")
+
+// snapshot is a catalog copy stored on local disk
+type snapshot struct {
+	Format    int       `json:"format"`
+	CreatedAt time.Time `json:"created_at"`
+	Catalog
+}
+
+// snapshotName returns snapshot file name sorting by creation time
+func snapshotName(t time.Time) string {
+	return fmt.Sprintf("%s%020d%s", snapshotPrefix, t.UnixNano(), snapshotSuffix)
+}
+
+// writeSnapshot stores cache data as a new snapshot file in dir
+// and removes old snapshots
+func writeSnapshot(dir string, data *cacheData, t time.Time) error {
+	snap := snapshot{
+		Format:    snapshotFormat,
+		CreatedAt: t,
+		Catalog:   data.catalog(),
+	}
+
+	b, err := json.Marshal(&snap)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot dir: %w", err)
+	}
+
+	path := filepath.Join(dir, snapshotName(t))
+	// write to temp file first, so a crash never leaves partial snapshot
+	if err := ioutil.WriteFile(path+".tmp", b, 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	names, err := snapshotNames(dir)
+	if err != nil {
+		return err
+	}
+	for i := maxSnapshots; i < len(names); i++ {
+		if err := os.Remove(filepath.Join(dir, names[i])); err != nil {
+			log.Printf("ERROR: failed to remove old snapshot %s: %v", names[i], err)
+		}
+	}
+
+	return nil
+}
+
+// snapshotNames returns snapshot file names in dir, newest first
+func snapshotNames(dir string) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	names := []string{}
+	for _, f := range files {
+		if strings.HasPrefix(f.Name(), snapshotPrefix) && strings.HasSuffix(f.Name(), snapshotSuffix) {
+			names = append(names, f.Name())
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+
+	return names, nil
+}
+
+// loadSnapshot returns cache data from the newest readable snapshot in dir
+// along with the snapshot creation time
+func loadSnapshot(dir string) (*cacheData, time.Time, error) {
+	names, err := snapshotNames(dir)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	for _, name := range names {
+		b, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			log.Printf("ERROR: failed to read snapshot %s: %v", name, err)
+			continue
+		}
+
+		snap := snapshot{}
+		if err := json.Unmarshal(b, &snap); err != nil {
+			log.Printf("ERROR: failed to decode snapshot %s: %v", name, err)
+			continue
+		}
+		if snap.Format != snapshotFormat {
+			log.Printf("INFO: skipping snapshot %s of format %d", name, snap.Format)
+			continue
+		}
+
+		data := &cacheData{
+			categories: snap.Categories,
+			items:      make(map[int]*Item, len(snap.Items)),
+		}
+		for _, item := range snap.Items {
+			data.items[item.ID] = item
+		}
+		data.index()
+
+		return data, snap.CreatedAt, nil
+	}
+
+	return nil, time.Time{}, ErrNoSnapshot
+}
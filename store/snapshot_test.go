@@ -0,0 +1,107 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// failingSource is a source which is always unreachable
+type failingSource struct{}
+
+func (failingSource) GetCategories(ctx context.Context) ([]*Category, error) {
+	return nil, errors.New("source is down")
+}
+
+func (failingSource) GetItems(ctx context.Context) (map[int]*Item, error) {
+	return nil, errors.New("source is down")
+}
+
+func TestSnapshotWarmStart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mania-snapshots")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if _, err := NewCache(ctx, failingSource{}, WithSnapshotDir(dir)); err == nil {
+		t.Fatal("expected error without source and snapshots")
+	}
+
+	fresh, err := NewCache(ctx, NewFileSource(testCatalog), WithSnapshotDir(dir))
+	if err != nil {
+		t.Fatalf("unexpected error in NewCache: %v", err)
+	}
+	if fresh.Stats().Stale {
+		t.Error("fresh cache must not be stale")
+	}
+
+	warm, err := NewCache(ctx, failingSource{}, WithSnapshotDir(dir))
+	if err != nil {
+		t.Fatalf("unexpected error in NewCache from snapshot: %v", err)
+	}
+
+	st := warm.Stats()
+	if !st.Stale || st.LastError == "" {
+		t.Errorf("expected stale stats with error, got %+v", st)
+	}
+	if diff := cmp.Diff(fresh.Catalog(), warm.Catalog()); diff != "" {
+		t.Errorf("snapshot catalog differs:\n%s", diff)
+	}
+
+	item, err := warm.GetItem("Блинчики")
+	if err != nil || item.Price != 15000 {
+		t.Errorf("unexpected item from snapshot: %v, %v", item, err)
+	}
+	if matches := warm.FindItems("блинчеки", 1); len(matches) != 1 {
+		t.Error("expected snapshot data to be indexed")
+	}
+}
+
+func TestSnapshotRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mania-snapshots")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	data := &cacheData{
+		categories: []*Category{{ID: 1, Name: "Блины"}},
+		items:      map[int]*Item{},
+	}
+	start := time.Now()
+	for i := 0; i < maxSnapshots+3; i++ {
+		if err := writeSnapshot(dir, data, start.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatalf("unexpected error in writeSnapshot: %v", err)
+		}
+	}
+
+	names, err := snapshotNames(dir)
+	if err != nil {
+		t.Fatalf("unexpected error in snapshotNames: %v", err)
+	}
+	if len(names) != maxSnapshots {
+		t.Errorf("expected %d snapshots kept, got %d", maxSnapshots, len(names))
+	}
+
+	// corrupt newest snapshot is skipped
+	if err := ioutil.WriteFile(filepath.Join(dir, names[0]), []byte("{"), 0644); err != nil {
+		t.Fatalf("failed to corrupt snapshot: %v", err)
+	}
+	_, createdAt, err := loadSnapshot(dir)
+	if err != nil {
+		t.Fatalf("unexpected error in loadSnapshot: %v", err)
+	}
+	if expected := start.Add(time.Duration(maxSnapshots+1) * time.Second); !createdAt.Equal(expected) {
+		t.Errorf("expected snapshot of %v, got %v", expected, createdAt)
+	}
+}
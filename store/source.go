@@ -0,0 +1,77 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Source provides menu catalog data for the Cache.
+// DB (Firestore) and FileSource implement it.
+// Malformed documents are skipped and reported with DocumentErrors
+// returned along with the rest of documents.
+type Source interface {
+	GetCategories(ctx context.Context) ([]*Category, error)
+	GetItems(ctx context.Context) (map[int]*Item, error)
+}
+
+// Change is a single catalog document change.
+// Either Category or Item is set.
+type Change struct {
+	Category *Category
+	Item     *Item
+	Removed  bool
+}
+
+// Watcher is implemented by sources that can stream catalog changes.
+// Watch blocks calling apply with batches of changes until ctx is done
+// or the stream fails.
+type Watcher interface {
+	Watch(ctx context.Context, apply func([]Change)) error
+}
+
+// CatalogWriter is implemented by sources menu can be imported to
+type CatalogWriter interface {
+	// WriteCatalog replaces the whole catalog with c
+	WriteCatalog(ctx context.Context, c Catalog) error
+}
+
+// DocumentError is a source document which failed to map
+type DocumentError struct {
+	Collection string
+	DocID      string
+	Err        error
+}
+
+func (e DocumentError) Error() string {
+	return fmt.Sprintf("%s/%s: %v", e.Collection, e.DocID, e.Err)
+}
+
+func (e DocumentError) Unwrap() error {
+	return e.Err
+}
+
+// DocumentErrors is returned by sources along with documents mapped
+// successfully, so one malformed document does not fail the whole load
+type DocumentErrors []DocumentError
+
+func (e DocumentErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i := range e {
+		msgs[i] = e[i].Error()
+	}
+	return fmt.Sprintf("%d bad documents: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// docID returns document ID from document key field
+// or document position if the field is missing
+func docID(m map[string]interface{}, key string, pos int) string {
+	if id, err := toInt(m[key]); err == nil {
+		return strconv.Itoa(id)
+	}
+	if s, ok := m[key].(string); ok && s != "" {
+		return s
+	}
+	return fmt.Sprintf("#%d", pos)
+}
@@ -0,0 +1,139 @@
+package store
+
+import "strings"
+
+// Russian Snowball stemmer
+// see https://snowballstem.org/algorithms/russian/stemmer.html
+
+var (
+	perfectiveGerund1 = []string{"вшись", "вши", "в"}
+	perfectiveGerund2 = []string{"ившись", "ывшись", "ивши", "ывши", "ив", "ыв"}
+	adjective         = []string{
+		"ими", "ыми", "его", "ого", "ему", "ому",
+		"ее", "ие", "ые", "ое", "ей", "ий", "ый", "ой", "ем", "им", "ым", "ом",
+		"их", "ых", "ую", "юю", "ая", "яя", "ою", "ею",
+	}
+	participle1 = []string{"ем", "нн", "вш", "ющ", "щ"}
+	participle2 = []string{"ивш", "ывш", "ующ"}
+	reflexive   = []string{"ся", "сь"}
+	verb1       = []string{
+		"ете", "йте", "ешь", "нно",
+		"ла", "на", "ли", "ем", "ло", "но", "ет", "ют", "ны", "ть",
+		"й", "л", "н",
+	}
+	verb2 = []string{
+		"ейте", "уйте",
+		"ила", "ыла", "ена", "ите", "или", "ыли", "ило", "ыло", "ено",
+		"ует", "уют", "ены", "ить", "ыть", "ишь",
+		"ей", "уй", "ил", "ыл", "им", "ым", "ен", "ят", "ит", "ыт", "ую",
+		"ю",
+	}
+	noun = []string{
+		"иями", "ями", "ами", "ией", "иям", "ием", "иях",
+		"ев", "ов", "ие", "ье", "еи", "ии", "ей", "ой", "ий", "ям", "ем",
+		"ам", "ом", "ах", "ях", "ию", "ью", "ия", "ья",
+		"а", "е", "и", "й", "о", "у", "ы", "ь", "ю", "я",
+	}
+	superlative  = []string{"ейше", "ейш"}
+	derivational = []string{"ость", "ост"}
+)
+
+// isVowel reports whether r is a russian vowel
+func isVowel(r rune) bool {
+	return strings.ContainsRune("аеиоуыэюя", r)
+}
+
+// regions returns RV and R2 start indexes
+func regions(w []rune) (rv, r2 int) {
+	rv, r1 := len(w), len(w)
+	for i := range w {
+		if isVowel(w[i]) {
+			rv = i + 1
+			break
+		}
+	}
+
+	for i := 1; i < len(w); i++ {
+		if !isVowel(w[i]) && isVowel(w[i-1]) {
+			r1 = i + 1
+			break
+		}
+	}
+
+	r2 = len(w)
+	for i := r1 + 1; i < len(w); i++ {
+		if !isVowel(w[i]) && isVowel(w[i-1]) {
+			r2 = i + 1
+			break
+		}
+	}
+
+	return rv, r2
+}
+
+// trimEnding removes the first of endings found at the end of w
+// not earlier than from index. If preceded is true ending must
+// follow "а" or "я" which stays in place.
+func trimEnding(w []rune, from int, endings []string, preceded bool) ([]rune, bool) {
+	for _, e := range endings {
+		er := []rune(e)
+		start := len(w) - len(er)
+		if start < from || string(w[start:]) != e {
+			continue
+		}
+		if preceded {
+			if start-1 < from || (w[start-1] != 'а' && w[start-1] != 'я') {
+				continue
+			}
+		}
+		return w[:start], true
+	}
+
+	return w, false
+}
+
+// Stem returns the stem of a single russian word
+func Stem(word string) string {
+	w := []rune(strings.ReplaceAll(strings.ToLower(word), "ё", "е"))
+	rv, r2 := regions(w)
+	if rv >= len(w) {
+		return string(w)
+	}
+
+	// step 1
+	var ok bool
+	if w, ok = trimEnding(w, rv, perfectiveGerund1, true); !ok {
+		w, ok = trimEnding(w, rv, perfectiveGerund2, false)
+	}
+	if !ok {
+		w, _ = trimEnding(w, rv, reflexive, false)
+
+		if w, ok = trimEnding(w, rv, adjective, false); ok {
+			if w, ok = trimEnding(w, rv, participle1, true); !ok {
+				w, _ = trimEnding(w, rv, participle2, false)
+			}
+		} else if w, ok = trimEnding(w, rv, verb1, true); !ok {
+			if w, ok = trimEnding(w, rv, verb2, false); !ok {
+				w, _ = trimEnding(w, rv, noun, false)
+			}
+		}
+	}
+
+	// step 2
+	w, _ = trimEnding(w, rv, []string{"и"}, false)
+
+	// step 3
+	w, _ = trimEnding(w, r2, derivational, false)
+
+	// step 4
+	if w, ok = trimEnding(w, rv, []string{"нн"}, false); ok {
+		return string(w) + "н"
+	}
+	w, _ = trimEnding(w, rv, superlative, false)
+	if w, ok = trimEnding(w, rv, []string{"нн"}, false); ok {
+		return string(w) + "н"
+	}
+	w, _ = trimEnding(w, rv, []string{"ь"}, false)
+
+	return string(w)
+}
@@ -0,0 +1,29 @@
+package store
+
+import "testing"
+
+func TestStem(t *testing.T) {
+	cases := []struct {
+		word string
+		stem string
+	}{
+		{"блины", "блин"},
+		{"блина", "блин"},
+		{"Блинами", "блин"},
+		{"блинчики", "блинчик"},
+		{"курицей", "куриц"},
+		{"грибами", "гриб"},
+		{"салаты", "салат"},
+		{"супы", "суп"},
+		{"ёжики", "ежик"},
+		{"красивейшими", "красив"},
+		{"с", "с"},
+		{"", ""},
+	}
+
+	for _, c := range cases {
+		if got := Stem(c.word); got != c.stem {
+			t.Errorf("Stem(%q) = %q, expected %q", c.word, got, c.stem)
+		}
+	}
+}
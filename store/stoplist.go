@@ -0,0 +1,27 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// StopEntry marks menu item as unavailable (kitchen ran out of it)
+type StopEntry struct {
+	ItemID int `json:"product_id"`
+	// Until is when item becomes available again,
+	// zero value means until removed from the stop list
+	Until time.Time `json:"until"`
+}
+
+// Active reports whether item is still unavailable at t
+func (e StopEntry) Active(t time.Time) bool {
+	return e.Until.IsZero() || t.Before(e.Until)
+}
+
+// StopListSource is implemented by catalog sources which store
+// items availability next to the catalog
+type StopListSource interface {
+	GetStopList(ctx context.Context) ([]StopEntry, error)
+	PutStopEntry(ctx context.Context, e StopEntry) error
+	DeleteStopEntry(ctx context.Context, itemID int) error
+}
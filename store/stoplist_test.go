@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTempCache returns a Cache populated from a temporary copy of
+// testdata catalog, so tests can write stop list next to it.
+// Call returned function to remove temporary files.
+func newTempCache(t *testing.T) (*Cache, func()) {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "mania")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	b, err := ioutil.ReadFile(testCatalog)
+	if err != nil {
+		t.Fatalf("failed to read catalog: %v", err)
+	}
+	path := filepath.Join(dir, "catalog.json")
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		t.Fatalf("failed to write catalog: %v", err)
+	}
+
+	c, err := NewCache(context.Background(), NewFileSource(path))
+	if err != nil {
+		cleanup()
+		t.Fatalf("unexpected error in NewCache: %v", err)
+	}
+
+	return c, cleanup
+}
+
+func TestSetAvailability(t *testing.T) {
+	c, cleanup := newTempCache(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	if err := c.SetAvailability(ctx, 102, false, time.Time{}); err != nil {
+		t.Fatalf("unexpected error in SetAvailability: %v", err)
+	}
+	if c.IsAvailable(102) {
+		t.Error("expected item 102 to be unavailable")
+	}
+
+	items, err := c.GetItemsPage("Блины", 0, 10)
+	if err != nil {
+		t.Fatalf("unexpected error in GetItemsPage: %v", err)
+	}
+	for _, item := range items {
+		if item.ID == 102 {
+			t.Error("unavailable item must not be listed")
+		}
+	}
+	if len(items) != 3 {
+		t.Errorf("unexpected len=%d from GetItemsPage (expected 3)", len(items))
+	}
+
+	// stop list survives reload from the source
+	sl := c.src.(StopListSource)
+	if err := c.refreshStopList(sl); err != nil {
+		t.Fatalf("unexpected error in refreshStopList: %v", err)
+	}
+	if stopList := c.GetStopList(); len(stopList) != 1 || stopList[0].ItemID != 102 {
+		t.Errorf("unexpected stop list after reload: %v", stopList)
+	}
+
+	if err := c.SetAvailability(ctx, 102, true, time.Time{}); err != nil {
+		t.Fatalf("unexpected error in SetAvailability: %v", err)
+	}
+	if !c.IsAvailable(102) {
+		t.Error("expected item 102 to be available")
+	}
+	if err := c.refreshStopList(sl); err != nil {
+		t.Fatalf("unexpected error in refreshStopList: %v", err)
+	}
+	if stopList := c.GetStopList(); len(stopList) != 0 {
+		t.Errorf("expected empty stop list, got %v", stopList)
+	}
+}
+
+func TestStopEntryExpiry(t *testing.T) {
+	c, cleanup := newTempCache(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	if err := c.SetAvailability(ctx, 201, false, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("unexpected error in SetAvailability: %v", err)
+	}
+	if !c.IsAvailable(201) {
+		t.Error("expired stop list entry must not apply")
+	}
+
+	if err := c.SetAvailability(ctx, 201, false, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("unexpected error in SetAvailability: %v", err)
+	}
+	if c.IsAvailable(201) {
+		t.Error("expected item 201 to be unavailable for an hour")
+	}
+}
+
+func TestGetAlternatives(t *testing.T) {
+	c, cleanup := newTempCache(t)
+	defer cleanup()
+
+	if err := c.SetAvailability(context.Background(), 103, false, time.Time{}); err != nil {
+		t.Fatalf("unexpected error in SetAvailability: %v", err)
+	}
+
+	alts := c.GetAlternatives(102, 3)
+	if len(alts) != 2 {
+		t.Fatalf("unexpected len=%d from GetAlternatives (expected 2)", len(alts))
+	}
+	for _, item := range alts {
+		if item.ID == 102 || item.ID == 103 {
+			t.Errorf("unexpected alternative %s", item.Name)
+		}
+	}
+
+	if alts := c.GetAlternatives(401, 3); len(alts) != 1 || alts[0].ID != 402 {
+		t.Errorf("unexpected alternatives for 401: %v", alts)
+	}
+}
@@ -0,0 +1,221 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrTooManyErrors is returned by Refresh when loaded catalog has more
+// validation errors than allowed and live cache is left as is
+var ErrTooManyErrors = errors.New("too many catalog validation errors")
+
+// IssueLevel tells how bad a validation issue is
+type IssueLevel string
+
+// Issue levels. Errors count against the cache threshold,
+// warnings are only reported.
+const (
+	LevelError   IssueLevel = "error"
+	LevelWarning IssueLevel = "warning"
+)
+
+// Validation issue codes
+const (
+	IssueBadDocument     = "bad_document"
+	IssueDanglingProduct = "dangling_product"
+	IssueDuplicateName   = "duplicate_name"
+	IssueSynonymTaken    = "synonym_taken"
+	IssueZeroPrice       = "zero_price"
+	IssueEmptyCategory   = "empty_category"
+	IssueNoComposition   = "no_composition"
+)
+
+// Issue is a single catalog validation finding
+type Issue struct {
+	Level      IssueLevel `json:"level"`
+	Code       string     `json:"code"`
+	Collection string     `json:"collection"`
+	DocID      string     `json:"doc_id"`
+	Message    string     `json:"message"`
+	// Quarantined is true when the document was left out of the catalog
+	Quarantined bool `json:"quarantined"`
+}
+
+// ValidationReport is a result of catalog validation pass
+type ValidationReport struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Errors    int       `json:"errors"`
+	Warnings  int       `json:"warnings"`
+	Issues    []Issue   `json:"issues"`
+	// Refused is true when the catalog had too many errors
+	// and was not swapped in
+	Refused bool `json:"refused"`
+}
+
+// add appends issue to the report updating counters
+func (r *ValidationReport) add(is Issue) {
+	if is.Level == LevelError {
+		r.Errors++
+	} else {
+		r.Warnings++
+	}
+	r.Issues = append(r.Issues, is)
+}
+
+// addDocumentErrors reports documents the source failed to map
+func (r *ValidationReport) addDocumentErrors(errs DocumentErrors) {
+	for _, e := range errs {
+		r.add(Issue{
+			Level:       LevelError,
+			Code:        IssueBadDocument,
+			Collection:  e.Collection,
+			DocID:       e.DocID,
+			Message:     e.Err.Error(),
+			Quarantined: true,
+		})
+	}
+}
+
+// validate checks loaded catalog before indexing. Items which can not
+// be served (zero price, name colliding with another item) are
+// quarantined, categories lose references to missing items.
+// Found issues are added to the report.
+func (c *cacheData) validate(r *ValidationReport) {
+	ids := make([]int, 0, len(c.items))
+	for id := range c.items {
+		ids = append(ids, id)
+	}
+	// lower ID wins name collisions, so keep order stable
+	sort.Ints(ids)
+
+	quarantined := make(map[int]bool)
+	names := make(map[string]int, len(c.items))
+	for _, id := range ids {
+		item := c.items[id]
+		docID := strconv.Itoa(id)
+
+		if item.Price == 0 {
+			r.add(Issue{
+				Level:       LevelError,
+				Code:        IssueZeroPrice,
+				Collection:  "products",
+				DocID:       docID,
+				Message:     fmt.Sprintf("%q has zero price", item.Name),
+				Quarantined: true,
+			})
+			quarantined[id] = true
+			continue
+		}
+
+		name := strings.ToLower(item.Name)
+		if other, ok := names[name]; ok {
+			r.add(Issue{
+				Level:       LevelError,
+				Code:        IssueDuplicateName,
+				Collection:  "products",
+				DocID:       docID,
+				Message:     fmt.Sprintf("%q has the same name as product_id %d", item.Name, other),
+				Quarantined: true,
+			})
+			quarantined[id] = true
+			continue
+		}
+		names[name] = id
+
+		if strings.TrimSpace(item.Composition) == "" {
+			r.add(Issue{
+				Level:      LevelWarning,
+				Code:       IssueNoComposition,
+				Collection: "products",
+				DocID:      docID,
+				Message:    fmt.Sprintf("%q has no composition", item.Name),
+			})
+		}
+	}
+	for id := range quarantined {
+		delete(c.items, id)
+	}
+	for _, id := range ids {
+		if item, ok := c.items[id]; ok {
+			item.Synonyms = claimSynonyms(r, "products", id, item.Name, item.Synonyms, names)
+		}
+	}
+
+	catNames := make(map[string]int, len(c.categories))
+	for _, cat := range c.categories {
+		if _, ok := catNames[strings.ToLower(cat.Name)]; !ok {
+			catNames[strings.ToLower(cat.Name)] = cat.ID
+		}
+	}
+	for _, cat := range c.categories {
+		cat.Synonyms = claimSynonyms(r, "categories", cat.ID, cat.Name, cat.Synonyms, catNames)
+	}
+
+	hasChildren := make(map[int]bool)
+	for _, cat := range c.categories {
+		if cat.ParentID != cat.ID {
+			hasChildren[cat.ParentID] = true
+		}
+	}
+
+	for _, cat := range c.categories {
+		products := make([]int, 0, len(cat.Products))
+		for _, id := range cat.Products {
+			if _, ok := c.items[id]; ok {
+				products = append(products, id)
+				continue
+			}
+			// quarantined items are reported already
+			if quarantined[id] {
+				continue
+			}
+			r.add(Issue{
+				Level:      LevelError,
+				Code:       IssueDanglingProduct,
+				Collection: "categories",
+				DocID:      strconv.Itoa(cat.ID),
+				Message:    fmt.Sprintf("%q refers to missing product_id %d", cat.Name, id),
+			})
+		}
+		cat.Products = products
+
+		if len(cat.Products) == 0 && !hasChildren[cat.ID] {
+			r.add(Issue{
+				Level:      LevelWarning,
+				Code:       IssueEmptyCategory,
+				Collection: "categories",
+				DocID:      strconv.Itoa(cat.ID),
+				Message:    fmt.Sprintf("%q has no products and subcategories", cat.Name),
+			})
+		}
+	}
+}
+
+// claimSynonyms returns synonyms of document id which do not name
+// another document of the collection, names maps lowercased names and
+// claimed synonyms to document IDs. Names win, the first document
+// claims a synonym, taken synonyms are dropped with a warning.
+func claimSynonyms(r *ValidationReport, collection string, id int, name string,
+	synonyms []string, names map[string]int) []string {
+	var res []string
+	for _, s := range synonyms {
+		key := strings.ToLower(s)
+		if other, ok := names[key]; ok && other != id {
+			r.add(Issue{
+				Level:      LevelWarning,
+				Code:       IssueSynonymTaken,
+				Collection: collection,
+				DocID:      strconv.Itoa(id),
+				Message:    fmt.Sprintf("%q synonym %q names document %d", name, s, other),
+			})
+			continue
+		}
+		names[key] = id
+		res = append(res, s)
+	}
+	return res
+}
@@ -0,0 +1,125 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const brokenCatalog = "testdata/broken.json"
+
+func TestValidationReport(t *testing.T) {
+	c, err := NewCache(context.Background(), NewFileSource(brokenCatalog))
+	if err != nil {
+		t.Fatalf("unexpected error in NewCache: %v", err)
+	}
+
+	report := c.ValidationReport()
+	codes := map[string]string{}
+	for _, is := range report.Issues {
+		codes[is.Collection+"/"+is.DocID] = is.Code
+	}
+	expected := map[string]string{
+		"categories/3": IssueBadDocument,
+		"products/105": IssueBadDocument,
+		"products/101": IssueSynonymTaken,
+		"products/102": IssueDuplicateName,
+		"products/103": IssueNoComposition,
+		"products/104": IssueZeroPrice,
+		"categories/1": IssueDanglingProduct,
+		"categories/2": IssueEmptyCategory,
+	}
+	for doc, code := range expected {
+		if codes[doc] != code {
+			t.Errorf("expected %s issue for %s, got %q", code, doc, codes[doc])
+		}
+	}
+	if report.Errors != 6 || report.Warnings != 3 || report.Refused {
+		t.Errorf("unexpected report counters: %+v", report)
+	}
+
+	// bad documents are skipped, the rest is served
+	items, err := c.GetItemsPage("Блины", 0, 10)
+	if err != nil {
+		t.Fatalf("unexpected error in GetItemsPage: %v", err)
+	}
+	if len(items) != 2 || items[0].ID != 101 || items[1].ID != 103 {
+		t.Errorf("expected items 101 and 103, got %v", items)
+	}
+	if item, err := c.GetItem("блины с творогом"); err != nil || item.ID != 103 {
+		t.Errorf("expected name to win over synonym, got %v, %v", item, err)
+	}
+	if item, err := c.GetItem("Панкейки"); err != nil || item.ID != 101 {
+		t.Errorf("expected item 101 by synonym, got %v, %v", item, err)
+	}
+	if st := c.Stats(); st.ValidationErrors != 6 || st.ValidationWarnings != 3 {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+}
+
+func TestLoadValidCatalog(t *testing.T) {
+	c, report, err := LoadValidCatalog(context.Background(), NewFileSource(brokenCatalog))
+	if err != nil {
+		t.Fatalf("unexpected error in LoadValidCatalog: %v", err)
+	}
+	if report.Errors != 6 || report.Warnings != 3 {
+		t.Errorf("unexpected report counters: %+v", report)
+	}
+
+	ids := []int{}
+	for _, item := range c.Items {
+		ids = append(ids, item.ID)
+	}
+	// duplicate name and zero price are quarantined
+	if len(ids) != 2 || ids[0] != 101 || ids[1] != 103 {
+		t.Errorf("expected items 101 and 103, got %v", ids)
+	}
+}
+
+func TestValidationThreshold(t *testing.T) {
+	ctx := context.Background()
+	if _, err := NewCache(ctx, NewFileSource(brokenCatalog), WithMaxValidationErrors(4)); !errors.Is(err, ErrTooManyErrors) {
+		t.Errorf("expected ErrTooManyErrors, got %v", err)
+	}
+
+	dir, err := ioutil.TempDir("", "mania")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "catalog.json")
+	copyFile(t, testCatalog, path)
+
+	c, err := NewCache(ctx, NewFileSource(path), WithMaxValidationErrors(4))
+	if err != nil {
+		t.Fatalf("unexpected error in NewCache: %v", err)
+	}
+
+	copyFile(t, brokenCatalog, path)
+	if err := c.Refresh(ctx); !errors.Is(err, ErrTooManyErrors) {
+		t.Fatalf("expected ErrTooManyErrors, got %v", err)
+	}
+	if !c.ValidationReport().Refused {
+		t.Error("expected refused report")
+	}
+	if st := c.Stats(); st.Items != 10 || st.LastError == "" {
+		t.Errorf("expected old catalog with error, got %+v", st)
+	}
+}
+
+// copyFile copies src file contents to dst
+func copyFile(t *testing.T, src, dst string) {
+	t.Helper()
+
+	b, err := ioutil.ReadFile(src)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", src, err)
+	}
+	if err := ioutil.WriteFile(dst, b, 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", dst, err)
+	}
+}
@@ -0,0 +1,84 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"cloud.google.com/go/firestore"
+)
+
+// Watch subscribes to categories and products collections snapshots
+// and calls apply for every batch of document changes.
+// First snapshot contains every document as added.
+func (db *DB) Watch(ctx context.Context, apply func([]Change)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make(chan error, 2)
+	go func() {
+		errs <- db.watchCollection(ctx, "categories", apply)
+	}()
+	go func() {
+		errs <- db.watchCollection(ctx, "products", apply)
+	}()
+
+	// first failure stops both listeners
+	return <-errs
+}
+
+// watchCollection listens to one collection snapshots
+func (db *DB) watchCollection(
+	ctx context.Context,
+	collection string,
+	apply func([]Change),
+) error {
+	it := db.collection(collection).Snapshots(ctx)
+	defer it.Stop()
+
+	for {
+		snap, err := it.Next()
+		if err != nil {
+			return fmt.Errorf("%s snapshot listener failed: %w", collection, err)
+		}
+
+		changes := make([]Change, 0, len(snap.Changes))
+		for _, dc := range snap.Changes {
+			ch, err := docToChange(collection, dc, db.schema)
+			if err != nil {
+				log.Printf("ERROR: skipping %s document %s change: %v",
+					collection, dc.Doc.Ref.ID, err)
+				continue
+			}
+			changes = append(changes, ch)
+		}
+
+		if len(changes) > 0 {
+			apply(changes)
+		}
+	}
+}
+
+// docToChange maps firestore document change to a catalog Change.
+// Removed documents carry their last known data, so we can
+// still get their IDs.
+func docToChange(collection string, dc firestore.DocumentChange, s Schema) (Change, error) {
+	ch := Change{Removed: dc.Kind == firestore.DocumentRemoved}
+
+	switch collection {
+	case "categories":
+		cat, err := mapToCategory(dc.Doc.Data(), s)
+		if err != nil {
+			return ch, err
+		}
+		ch.Category = &cat
+	default:
+		item, err := mapToItem(dc.Doc.Data(), s)
+		if err != nil {
+			return ch, err
+		}
+		ch.Item = &item
+	}
+
+	return ch, nil
+}
@@ -0,0 +1,254 @@
+package store
+
+import (
+	"context"
+	"mania/money"
+	"os"
+	"testing"
+	"time"
+)
+
+// watchingSource is a file source which streams given changes
+type watchingSource struct {
+	*FileSource
+	changes []Change
+}
+
+func (ws *watchingSource) Watch(ctx context.Context, apply func([]Change)) error {
+	apply(ws.changes)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// waitFor polls cond until it is true or timeout expires
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for cache update")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestApplyChanges(t *testing.T) {
+	c := newTestCache(t)
+	before := c.GetCategoriesPage(0, 10)
+
+	c.applyChanges([]Change{
+		{Item: &Item{ID: 101, Name: "Блинчики", Price: money.FromRubles(170)}},
+		{Item: &Item{ID: 104}, Removed: true},
+		{Item: &Item{ID: 501, Name: "Сырники", Price: money.FromRubles(200)}},
+		{Category: &Category{ID: 5, Name: "Завтраки", Products: []int{501}}},
+		{Category: &Category{ID: 4}, Removed: true},
+	})
+
+	item, err := c.GetItem("блинчики")
+	if err != nil {
+		t.Fatalf("unexpected error in GetItem: %v", err)
+	}
+	if item.Price != money.FromRubles(170) {
+		t.Errorf("expected updated price 170, got %v", item.Price)
+	}
+
+	if _, err := c.GetItem("Блины с семгой"); err == nil {
+		t.Error("expected removed item to be gone")
+	}
+
+	items, err := c.GetItemsPage("Завтраки", 0, 10)
+	if err != nil {
+		t.Fatalf("unexpected error in GetItemsPage: %v", err)
+	}
+	if len(items) != 1 || items[0].Name != "Сырники" {
+		t.Errorf("unexpected items in new category: %v", items)
+	}
+
+	if _, err := c.GetItemsPage("Напитки", 0, 10); err == nil {
+		t.Error("expected removed category to be gone")
+	}
+
+	// pages returned before the change must stay intact
+	if len(before) != 6 || before[3].Name != "Напитки" {
+		t.Errorf("old page was modified in place: %v", before)
+	}
+}
+
+func TestApplyChangesValidation(t *testing.T) {
+	c, err := NewCache(context.Background(), NewFileSource(testCatalog), WithMaxValidationErrors(2))
+	if err != nil {
+		t.Fatalf("unexpected error in NewCache: %v", err)
+	}
+	errorsBefore := c.ValidationReport().Errors
+
+	c.applyChanges([]Change{
+		{Item: &Item{ID: 501, Name: "Сырники", Price: 0}},
+		{Item: &Item{ID: 502, Name: "блинчики", Price: money.FromRubles(100)}},
+		{Category: &Category{ID: 5, Name: "Завтраки", Products: []int{501, 502}}},
+	})
+
+	// zero price and duplicate name are quarantined like on refresh
+	if item, err := c.GetItem("Блинчики"); err != nil || item.ID != 101 {
+		t.Errorf("expected catalog item to keep its name, got %v, %v", item, err)
+	}
+	if _, err := c.GetItem("Сырники"); err == nil {
+		t.Error("expected zero price item to be quarantined")
+	}
+	if items, _ := c.GetItemsPage("Завтраки", 0, 10); len(items) != 0 {
+		t.Errorf("expected no items in new category, got %v", items)
+	}
+	codes := map[string]string{}
+	for _, is := range c.ValidationReport().Issues {
+		codes[is.Collection+"/"+is.DocID] = is.Code
+	}
+	if codes["products/501"] != IssueZeroPrice || codes["products/502"] != IssueDuplicateName {
+		t.Errorf("expected changed items to be reported, got %v", codes)
+	}
+
+	// the next batch keeps quarantined items reported and is refused
+	// over the threshold as a whole
+	c.applyChanges([]Change{
+		{Item: &Item{ID: 101, Name: "Блинчики", Price: money.FromRubles(170)}},
+		{Item: &Item{ID: 503, Name: "Оладьи", Price: 0}},
+	})
+	if item, _ := c.GetItem("Блинчики"); item == nil || item.Price == money.FromRubles(170) {
+		t.Errorf("expected refused batch not to apply, got %v", item)
+	}
+	r := c.ValidationReport()
+	if !r.Refused || r.Errors != errorsBefore+3 {
+		t.Errorf("expected refused report with %d errors, got %+v", errorsBefore+3, r)
+	}
+	if st := c.Stats(); st.LastError == "" {
+		t.Error("expected refused changes error in stats")
+	}
+}
+
+func TestWatchLoop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	src := &watchingSource{
+		FileSource: NewFileSource(testCatalog),
+		changes:    []Change{{Item: &Item{ID: 402, Name: "Чай черный", Price: money.FromRubles(80)}}},
+	}
+	c, err := NewCache(ctx, src)
+	if err != nil {
+		t.Fatalf("unexpected error in NewCache: %v", err)
+	}
+
+	waitFor(t, func() bool {
+		item, err := c.GetItem("Чай черный")
+		return err == nil && item.Price == money.FromRubles(80)
+	})
+}
+
+// TestWatchEmulator runs against Firestore emulator:
+// FIRESTORE_EMULATOR_HOST=localhost:8081 GOOGLE_CLOUD_PROJECT=mania-test go test ./store
+func TestWatchEmulator(t *testing.T) {
+	if os.Getenv("FIRESTORE_EMULATOR_HOST") == "" {
+		t.Skip("FIRESTORE_EMULATOR_HOST is not set")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db, err := New(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error in New: %v", err)
+	}
+
+	cats := db.cl.Collection("categories")
+	products := db.cl.Collection("products")
+	if _, err := cats.Doc("watch-1").Set(ctx, map[string]interface{}{
+		"category_id": "9001",
+		"parent_id":   "0",
+		"name":        "Тест",
+		"icon":        "",
+		"products":    []interface{}{map[string]interface{}{"product_id": "9001"}},
+	}); err != nil {
+		t.Fatalf("failed to seed category: %v", err)
+	}
+	product := map[string]interface{}{
+		"product_id":  "9001",
+		"name":        "Тестовый блин",
+		"composition": "мука",
+		"description": "тест",
+		"price":       "100",
+	}
+	if _, err := products.Doc("watch-9001").Set(ctx, product); err != nil {
+		t.Fatalf("failed to seed product: %v", err)
+	}
+
+	c, err := NewCache(ctx, db)
+	if err != nil {
+		t.Fatalf("unexpected error in NewCache: %v", err)
+	}
+
+	product["price"] = "120"
+	if _, err := products.Doc("watch-9001").Set(ctx, product); err != nil {
+		t.Fatalf("failed to update product: %v", err)
+	}
+	waitFor(t, func() bool {
+		item, err := c.GetItem("Тестовый блин")
+		return err == nil && item.Price == money.FromRubles(120)
+	})
+
+	if _, err := products.Doc("watch-9001").Delete(ctx); err != nil {
+		t.Fatalf("failed to delete product: %v", err)
+	}
+	waitFor(t, func() bool {
+		_, err := c.GetItem("Тестовый блин")
+		return err != nil
+	})
+
+	if _, err := cats.Doc("watch-1").Delete(ctx); err != nil {
+		t.Fatalf("failed to delete category: %v", err)
+	}
+}
+
+// blockingSource is a file source which GetItems waits for release
+// once fetching is signalled
+type blockingSource struct {
+	*FileSource
+	fetching chan struct{}
+	release  chan struct{}
+}
+
+func (bs *blockingSource) GetItems(ctx context.Context) (map[int]*Item, error) {
+	if bs.fetching != nil {
+		close(bs.fetching)
+		<-bs.release
+	}
+	return bs.FileSource.GetItems(ctx)
+}
+
+func TestRefreshKeepsWatchChanges(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	src := &blockingSource{FileSource: NewFileSource(testCatalog)}
+	c, err := NewCache(ctx, src)
+	if err != nil {
+		t.Fatalf("unexpected error in NewCache: %v", err)
+	}
+
+	src.fetching = make(chan struct{})
+	src.release = make(chan struct{})
+	done := make(chan error)
+	go func() { done <- c.Refresh(ctx) }()
+
+	// change lands after the source was read, before the swap
+	<-src.fetching
+	c.applyChanges([]Change{{Item: &Item{ID: 402, Name: "Чай черный", Price: money.FromRubles(80)}}})
+	close(src.release)
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error in Refresh: %v", err)
+	}
+
+	if item, err := c.GetItem("Чай черный"); err != nil || item.Price != money.FromRubles(80) {
+		t.Errorf("expected watch change to survive refresh, got %v, %v", item, err)
+	}
+	if len(c.pending) != 0 {
+		t.Errorf("expected pending changes to be dropped, got %d", len(c.pending))
+	}
+}
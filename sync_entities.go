@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"mania/entities"
+	"mania/tenant"
+)
+
+// syncEntities updates "category" and "item" entity types intents
+// take parameters of to the tenant catalog:
+//
+//	mania sync-entities [-tenant id] [-o agent dir] [-push] [-project id] [-lang ru] [-dry-run]
+//
+// Entity types are written to agent export directory and, with -push,
+// to the tenant agent through Dialogflow API. Dry run prints changes
+// against the directory or the agent without writing them.
+func syncEntities(ctx context.Context, args []string) error {
+	fl := flag.NewFlagSet("sync-entities", flag.ContinueOnError)
+	tenantID := fl.String("tenant", tenant.DefaultID, "tenant to sync entity types of")
+	dir := fl.String("o", "", "agent export directory to write entity types to")
+	push := fl.Bool("push", false, "update entity types of the agent through Dialogflow API")
+	project := fl.String("project", "", "agent project, tenant project or GOOGLE_CLOUD_PROJECT if empty")
+	lang := fl.String("lang", "ru", "agent language")
+	dryRun := fl.Bool("dry-run", false, "print changes without writing them")
+	if err := fl.Parse(args); err != nil {
+		return err
+	}
+	if fl.NArg() != 0 || (*dir == "" && !*push) {
+		return errors.New("usage: mania sync-entities [flags], -o or -push is required")
+	}
+
+	ts, err := findTenant(*tenantID)
+	if err != nil {
+		return err
+	}
+
+	type target struct {
+		name   string
+		client entities.Client
+	}
+	targets := []target{}
+	if *dir != "" {
+		targets = append(targets, target{*dir, entities.NewDirClient(*dir, *lang)})
+	}
+	if *push {
+		if *project == "" {
+			*project = ts.Project
+		}
+		if *project == "" {
+			*project = os.Getenv("GOOGLE_CLOUD_PROJECT")
+		}
+		cl, err := entities.NewAPIClient(ctx, *project, *lang)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, target{"agent " + *project, cl})
+	}
+
+	catalog, err := loadCatalog(ctx, ts)
+	if err != nil {
+		return err
+	}
+	types := entities.Build(catalog)
+
+	for _, t := range targets {
+		changes, err := entities.Sync(ctx, t.client, types, *dryRun)
+		if err != nil {
+			return fmt.Errorf("%s: %w", t.name, err)
+		}
+		if len(changes) == 0 {
+			fmt.Printf("%s: entity types are up to date\n", t.name)
+			continue
+		}
+		fmt.Printf("%s:\n", t.name)
+		for _, ch := range changes {
+			fmt.Println(ch)
+		}
+		if !*dryRun {
+			fmt.Printf("%s: %d changes written\n", t.name, len(changes))
+		}
+	}
+	return nil
+}
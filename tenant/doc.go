@@ -0,0 +1,3 @@
+// Package tenant serves several restaurants from one bot: every
+// tenant has its own catalog, settings and customer sessions.
+package tenant
@@ -0,0 +1,214 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"mania/dialogflow"
+	"mania/intents"
+	"mania/store"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultID is the tenant ID used when tenants are not configured
+const DefaultID = "default"
+
+// WebhookPrefix is a webhook path prefix naming the tenant:
+// /webhook/{tenant}
+const WebhookPrefix = "/webhook/"
+
+var (
+	// ErrUnknownTenant is returned when request names no known tenant
+	ErrUnknownTenant = errors.New("unknown tenant")
+	// ErrDuplicateTenant is returned when tenant ID or project is taken
+	ErrDuplicateTenant = errors.New("duplicate tenant")
+)
+
+// Settings are restaurant settings
+type Settings struct {
+	ID string `yaml:"id"`
+	// Project is Dialogflow agent project ID serving the tenant
+	Project string `yaml:"project"`
+	// CatalogFile is a local catalog file,
+	// Firestore tenants/{id} collections are used if empty
+	CatalogFile string `yaml:"catalog_file"`
+	// Phone receives orders
+	Phone    string `yaml:"phone"`
+	PageSize int    `yaml:"page_size"`
+	// Hours are opening hours told to customers, e.g. "с 10:00 до 22:00"
+	Hours string `yaml:"hours"`
+	// TimeZone is IANA time zone menu schedules are evaluated in,
+	// e.g. "Europe/Moscow", local time zone is used if empty
+	TimeZone string `yaml:"time_zone"`
+	// Schema renames catalog document fields, e.g. {price: cost}
+	// when upstream stores item price in "cost"
+	Schema store.Schema `yaml:"schema"`
+}
+
+// Location returns tenant time zone
+func (s Settings) Location() (*time.Location, error) {
+	if s.TimeZone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("bad time zone: %w", err)
+	}
+	return loc, nil
+}
+
+// config is a tenants file contents
+type config struct {
+	Tenants []Settings `yaml:"tenants"`
+}
+
+// LoadSettings reads tenants settings from YAML file:
+//
+//	tenants:
+//	  - id: center
+//	    project: mania-center
+//	    phone: "+79990000001"
+//	    page_size: 5
+//	    hours: с 10:00 до 22:00
+//	    time_zone: Europe/Moscow
+func LoadSettings(path string) ([]Settings, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tenants file: %w", err)
+	}
+
+	cfg := config{}
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode tenants file %s: %w", path, err)
+	}
+
+	for i, s := range cfg.Tenants {
+		if s.ID == "" {
+			return nil, fmt.Errorf("tenant #%d has no id", i)
+		}
+		if _, err := s.Location(); err != nil {
+			return nil, fmt.Errorf("tenant %s: %w", s.ID, err)
+		}
+		if err := s.Schema.Validate(); err != nil {
+			return nil, fmt.Errorf("tenant %s: %w", s.ID, err)
+		}
+	}
+
+	return cfg.Tenants, nil
+}
+
+// Tenant is a restaurant served by the bot
+type Tenant struct {
+	Settings
+	Cache      *store.Cache
+	Dispatcher *intents.Dispatcher
+}
+
+// New returns a tenant serving cache menu, orders are sent with sn.
+// Options like session storage are applied after tenant settings.
+func New(ctx context.Context, s Settings, c *store.Cache, sn intents.Sender, opts ...intents.DispatcherOption) *Tenant {
+	opts = append([]intents.DispatcherOption{
+		intents.WithPageSize(s.PageSize),
+		intents.WithOrderPhone(s.Phone),
+		intents.WithHours(s.Hours),
+	}, opts...)
+
+	return &Tenant{
+		Settings:   s,
+		Cache:      c,
+		Dispatcher: intents.NewDispatcher(ctx, c, sn, opts...),
+	}
+}
+
+// Registry holds tenants and finds the one a request is for
+type Registry struct {
+	tenants  map[string]*Tenant
+	projects map[string]string
+}
+
+// NewRegistry returns an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{
+		tenants:  make(map[string]*Tenant),
+		projects: make(map[string]string),
+	}
+}
+
+// Add registers tenant, IDs and projects must be unique
+func (r *Registry) Add(t *Tenant) error {
+	if _, ok := r.tenants[t.ID]; ok {
+		return fmt.Errorf("%w: id %s", ErrDuplicateTenant, t.ID)
+	}
+	if t.Project != "" {
+		if id, ok := r.projects[t.Project]; ok {
+			return fmt.Errorf("%w: project %s is served by %s", ErrDuplicateTenant, t.Project, id)
+		}
+		r.projects[t.Project] = t.ID
+	}
+
+	r.tenants[t.ID] = t
+	return nil
+}
+
+// Get returns tenant by ID
+func (r *Registry) Get(id string) (*Tenant, bool) {
+	t, ok := r.tenants[id]
+	return t, ok
+}
+
+// IDs returns sorted tenant IDs
+func (r *Registry) IDs() []string {
+	ids := make([]string, 0, len(r.tenants))
+	for id := range r.tenants {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Resolve finds tenant of webhook request. Tenant is named by
+// the webhook path /webhook/{tenant}, otherwise by Dialogflow agent
+// project of the request session. Requests naming nothing go to
+// the default tenant or the only one registered.
+func (r *Registry) Resolve(path string, req dialogflow.Request) (*Tenant, error) {
+	if strings.HasPrefix(path, WebhookPrefix) {
+		id := strings.Trim(strings.TrimPrefix(path, WebhookPrefix), "/")
+		if t, ok := r.tenants[id]; ok {
+			return t, nil
+		}
+		return nil, fmt.Errorf("%w: %q", ErrUnknownTenant, id)
+	}
+
+	if project := ProjectFromSession(req.Session); project != "" {
+		if id, ok := r.projects[project]; ok {
+			return r.tenants[id], nil
+		}
+	}
+
+	if t, ok := r.tenants[DefaultID]; ok {
+		return t, nil
+	}
+	if len(r.tenants) == 1 {
+		for _, t := range r.tenants {
+			return t, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: session %q", ErrUnknownTenant, req.Session)
+}
+
+// ProjectFromSession returns agent project ID from Dialogflow session
+// name projects/{project}/agent/sessions/{session}
+func ProjectFromSession(session string) string {
+	parts := strings.Split(session, "/")
+	if len(parts) < 2 || parts[0] != "projects" {
+		return ""
+	}
+	return parts[1]
+}
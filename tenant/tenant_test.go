@@ -0,0 +1,191 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"mania/dialogflow"
+	"mania/intents"
+	"mania/store"
+)
+
+// sentOrder is an order text sent to a phone
+type sentOrder struct {
+	text, phone string
+}
+
+// recordingSender keeps sent orders
+type recordingSender struct {
+	sent []sentOrder
+}
+
+func (rs *recordingSender) Send(text, phone string) error {
+	rs.sent = append(rs.sent, sentOrder{text: text, phone: phone})
+	return nil
+}
+
+// newTestRegistry returns registry of tenants from testdata/tenants.yaml
+func newTestRegistry(t *testing.T, ctx context.Context, sn intents.Sender) *Registry {
+	t.Helper()
+
+	settings, err := LoadSettings("testdata/tenants.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error in LoadSettings: %v", err)
+	}
+
+	reg := NewRegistry()
+	for _, s := range settings {
+		c, err := store.NewCache(ctx, store.NewFileSource(s.CatalogFile).WithSchema(s.Schema))
+		if err != nil {
+			t.Fatalf("unexpected error in NewCache for %s: %v", s.ID, err)
+		}
+		if err := reg.Add(New(ctx, s, c, sn)); err != nil {
+			t.Fatalf("unexpected error in Add: %v", err)
+		}
+	}
+
+	return reg
+}
+
+// request returns webhook request of intent in session
+func request(session, intent string, params map[string]interface{}) dialogflow.Request {
+	req := dialogflow.Request{Session: session}
+	req.QueryResult.Intent.DisplayName = intent
+	req.QueryResult.Parameters = params
+	return req
+}
+
+// handle resolves tenant and runs intent handler returning speech text
+func handle(t *testing.T, reg *Registry, path string, req dialogflow.Request) string {
+	t.Helper()
+
+	tn, err := reg.Resolve(path, req)
+	if err != nil {
+		t.Fatalf("unexpected error in Resolve: %v", err)
+	}
+	h, err := tn.Dispatcher.GetHandler(req.QueryResult.Intent.DisplayName)
+	if err != nil {
+		t.Fatalf("unexpected error in GetHandler: %v", err)
+	}
+	resp, err := h(req)
+	if err != nil {
+		t.Fatalf("unexpected error in handler: %v", err)
+	}
+
+	return resp.Text()
+}
+
+func TestLoadSettings(t *testing.T) {
+	settings, err := LoadSettings("testdata/tenants.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error in LoadSettings: %v", err)
+	}
+	if len(settings) != 2 {
+		t.Fatalf("expected 2 tenants, got %d", len(settings))
+	}
+	s := settings[0]
+	if s.ID != "center" || s.Project != "mania-center" || s.Phone != "+79990000001" ||
+		s.PageSize != 1 || s.Hours != "с 10:00 до 22:00" || s.TimeZone != "Europe/Moscow" ||
+		s.Schema["icon"] != "emoji" {
+		t.Errorf("unexpected settings: %+v", s)
+	}
+
+	if _, err := LoadSettings("testdata/missing.yaml"); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reg := newTestRegistry(t, ctx, &recordingSender{})
+
+	cases := []struct {
+		path, session, expected string
+	}{
+		{"/webhook/north", "projects/mania-center/agent/sessions/1", "north"},
+		{"/webhook/center/", "", "center"},
+		{"/", "projects/mania-north/agent/sessions/1", "north"},
+		{"/", "projects/mania-center/agent/sessions/1", "center"},
+	}
+	for _, c := range cases {
+		tn, err := reg.Resolve(c.path, dialogflow.Request{Session: c.session})
+		if err != nil {
+			t.Errorf("unexpected error resolving %s %s: %v", c.path, c.session, err)
+			continue
+		}
+		if tn.ID != c.expected {
+			t.Errorf("expected %s for %s %s, got %s", c.expected, c.path, c.session, tn.ID)
+		}
+	}
+
+	if _, err := reg.Resolve("/webhook/south", dialogflow.Request{}); !errors.Is(err, ErrUnknownTenant) {
+		t.Errorf("expected ErrUnknownTenant for unknown path, got %v", err)
+	}
+	if _, err := reg.Resolve("/", dialogflow.Request{Session: "projects/other/agent/sessions/1"}); !errors.Is(err, ErrUnknownTenant) {
+		t.Errorf("expected ErrUnknownTenant for unknown project, got %v", err)
+	}
+
+	north, _ := reg.Get("north")
+	if err := reg.Add(north); !errors.Is(err, ErrDuplicateTenant) {
+		t.Errorf("expected ErrDuplicateTenant, got %v", err)
+	}
+
+	single := NewRegistry()
+	if err := single.Add(north); err != nil {
+		t.Fatalf("unexpected error in Add: %v", err)
+	}
+	if tn, err := single.Resolve("/", dialogflow.Request{}); err != nil || tn.ID != "north" {
+		t.Errorf("expected the only tenant, got %v, %v", tn, err)
+	}
+}
+
+func TestIsolation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sn := &recordingSender{}
+	reg := newTestRegistry(t, ctx, sn)
+
+	// same Dialogflow session ID reaches both tenants
+	const session = "projects/shared/agent/sessions/42"
+
+	text := handle(t, reg, "/webhook/center", request(session, string(intents.ListCategories), nil))
+	if !strings.Contains(text, "Блины") || strings.Contains(text, "Супы") {
+		t.Errorf("expected center page of 1 category, got %q", text)
+	}
+	text = handle(t, reg, "/webhook/north", request(session, string(intents.ListCategories), nil))
+	if !strings.Contains(text, "Пицца") || strings.Contains(text, "Блины") {
+		t.Errorf("expected north categories only, got %q", text)
+	}
+
+	text = handle(t, reg, "/webhook/center", request(session, string(intents.AddToCartContext), map[string]interface{}{
+		"item": "Блинчики",
+	}))
+	if !strings.Contains(text, "1 товаров") {
+		t.Errorf("expected item in center cart, got %q", text)
+	}
+
+	north, _ := reg.Get("north")
+	h, _ := north.Dispatcher.GetHandler(string(intents.AddToCartContext))
+	if _, err := h(request(session, string(intents.AddToCartContext), map[string]interface{}{
+		"item": "Блинчики",
+	})); err == nil {
+		t.Error("north must not know center items")
+	}
+
+	checkout := map[string]interface{}{"phonenum": "+79991234567"}
+	text = handle(t, reg, "/webhook/north", request(session, string(intents.Checkout), checkout))
+	if text != "Корзина пуста" {
+		t.Errorf("expected empty north cart, got %q", text)
+	}
+
+	text = handle(t, reg, "/webhook/center", request(session, string(intents.Checkout), checkout))
+	if !strings.Contains(text, "с 10:00 до 22:00") {
+		t.Errorf("expected center hours in reply, got %q", text)
+	}
+	if len(sn.sent) != 1 || sn.sent[0].phone != "+79990000001" || !strings.Contains(sn.sent[0].text, "Блинчики") {
+		t.Errorf("expected order sent to center phone, got %+v", sn.sent)
+	}
+}